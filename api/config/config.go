@@ -2,6 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+
+	"telegram-api/ratelimit"
 )
 
 // Config содержит все настройки API сервиса
@@ -17,12 +21,85 @@ type Config struct {
 	OpenRouterAPIKey string
 	OpenRouterURL    string
 	AIModel          string
+	AIToolsEnabled   bool
+
+	// Сроки этапов обработки сообщения в чате, секунды: запросы к БД, распознавание речи,
+	// сбор контекста и ответ модели со всеми вызовами инструментов
+	ChatDatabaseTimeout   int
+	ChatTranscribeTimeout int
+	ChatContextTimeout    int
+	ChatModelTimeout      int
+
+	// ModelCheckInterval как долго (в секундах) /readyz использует результат
+	// проверки доступности OpenRouter
+	ModelCheckInterval int
+
+	// AvailableModels модели, из которых пользователь может выбрать свою (AIModel доступна всегда)
+	AvailableModels []string
 
 	// Telegram
 	TelegramBotToken string
 
+	// TelegramAuthMaxAge срок действия initData в секундах; TelegramTestEnvironment
+	// включает ключ тестового окружения Telegram для проверки подписи Ed25519
+	TelegramAuthMaxAge      int
+	TelegramTestEnvironment bool
+
 	// API
 	APIPort string
+
+	// ServiceToken токен для внутренних запросов от Telegram бота
+	ServiceToken string
+
+	// AdminUserIDs Telegram ID администраторов
+	AdminUserIDs []int64
+
+	// Embeddings ("local" или "openai")
+	EmbeddingsProvider string
+	EmbeddingsURL      string
+	EmbeddingsAPIKey   string
+	EmbeddingsModel    string
+
+	// Knowledge base
+	KnowledgeTopK     int
+	KnowledgeMinScore float64
+
+	// Speech-to-text ("openai", "whisper" или пусто, чтобы отключить)
+	STTProvider string
+	STTURL      string
+	STTAPIKey   string
+	STTModel    string
+	STTLanguage string
+
+	// Logging: уровень (debug, info, warn, error), формат (json или text)
+	// и скрытие текстов сообщений и имен пользователей
+	LogLevel           string
+	LogFormat          string
+	LogRedactContent   bool
+	LogRedactUsernames bool
+
+	// Tracing: адрес OTLP/HTTP коллектора (пусто — трассировка отключена),
+	// его заголовки, имя сервиса и доля записываемых трасс
+	OTLPEndpoint      string
+	OTLPHeaders       map[string]string
+	TracingService    string
+	TracingSampleRate float64
+
+	// Rate limiting: хранилище корзин ("memory" или "postgres"), лимиты на IP адрес
+	// и на пользователя в формате "120/m", отдельные лимиты маршрутов
+	// ("POST /api/chat=20/m,...") и сети прокси, которым доверяется X-Forwarded-For
+	RateLimitBackend string
+	RateLimitIP      string
+	RateLimitUser    string
+	RateLimitRoutes  string
+	TrustedProxies   []string
+
+	// Sessions: ключи подписи токенов "id:secret" (первым подписываются новые токены,
+	// остальные только проверяются; пусто — ключ выводится из токена бота)
+	// и сроки действия access и refresh токенов в секундах
+	SessionSigningKeys []string
+	SessionAccessTTL   int
+	SessionRefreshTTL  int
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -39,12 +116,67 @@ func Load() *Config {
 		OpenRouterAPIKey: getEnv("OPENROUTER_API_KEY", ""),
 		OpenRouterURL:    getEnv("OPENROUTER_URL", "https://openrouter.ai/api/v1"),
 		AIModel:          getEnv("AI_MODEL", "deepseek/deepseek-chat-v3.1:free"),
+		AIToolsEnabled:   getEnv("AI_TOOLS_ENABLED", "true") == "true",
+		AvailableModels:  getEnvList("AVAILABLE_MODELS"),
+
+		ChatDatabaseTimeout:   getEnvInt("CHAT_DATABASE_TIMEOUT", 5),
+		ChatTranscribeTimeout: getEnvInt("CHAT_TRANSCRIBE_TIMEOUT", 60),
+		ChatContextTimeout:    getEnvInt("CHAT_CONTEXT_TIMEOUT", 10),
+		ChatModelTimeout:      getEnvInt("CHAT_MODEL_TIMEOUT", 45),
+
+		ModelCheckInterval: getEnvInt("HEALTH_MODEL_CHECK_INTERVAL", 60),
 
 		// Telegram
-		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramBotToken:        getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramAuthMaxAge:      getEnvInt("TELEGRAM_AUTH_MAX_AGE", 86400),
+		TelegramTestEnvironment: getEnv("TELEGRAM_TEST_ENVIRONMENT", "false") == "true",
 
 		// API
-		APIPort: getEnv("API_PORT", "8080"),
+		APIPort:      getEnv("API_PORT", "8080"),
+		ServiceToken: getEnv("API_SERVICE_TOKEN", ""),
+
+		AdminUserIDs: getEnvInt64List("ADMIN_TELEGRAM_IDS"),
+
+		// Embeddings
+		EmbeddingsProvider: getEnv("EMBEDDINGS_PROVIDER", "local"),
+		EmbeddingsURL:      getEnv("EMBEDDINGS_URL", "https://api.openai.com/v1"),
+		EmbeddingsAPIKey:   getEnv("EMBEDDINGS_API_KEY", ""),
+		EmbeddingsModel:    getEnv("EMBEDDINGS_MODEL", "text-embedding-3-small"),
+
+		// Knowledge base
+		KnowledgeTopK:     getEnvInt("KNOWLEDGE_TOP_K", 4),
+		KnowledgeMinScore: getEnvFloat("KNOWLEDGE_MIN_SCORE", 0.15),
+
+		// Speech-to-text
+		STTProvider: getEnv("STT_PROVIDER", ""),
+		STTURL:      getEnv("STT_URL", "https://api.openai.com/v1"),
+		STTAPIKey:   getEnv("STT_API_KEY", ""),
+		STTModel:    getEnv("STT_MODEL", "whisper-1"),
+		STTLanguage: getEnv("STT_LANGUAGE", ""),
+
+		// Logging
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		LogFormat:          getEnv("LOG_FORMAT", "json"),
+		LogRedactContent:   getEnv("LOG_REDACT_CONTENT", "true") == "true",
+		LogRedactUsernames: getEnv("LOG_REDACT_USERNAMES", "true") == "true",
+
+		// Tracing
+		OTLPEndpoint:      getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTLPHeaders:       getEnvMap("OTEL_EXPORTER_OTLP_HEADERS"),
+		TracingService:    getEnv("OTEL_SERVICE_NAME", "telegram-api"),
+		TracingSampleRate: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+
+		// Rate limiting
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitIP:      getEnv("RATE_LIMIT_IP", "300/m"),
+		RateLimitUser:    getEnv("RATE_LIMIT_USER", "120/m"),
+		RateLimitRoutes:  getEnv("RATE_LIMIT_ROUTES", "POST /api/chat=20/m,POST /api/chat/voice=10/m,POST /api/chat/regenerate=10/m,POST /api/chat/inline=20/m,POST /api/documents=10/m"),
+		TrustedProxies:   getEnvList("TRUSTED_PROXIES"),
+
+		// Sessions
+		SessionSigningKeys: getEnvList("SESSION_SIGNING_KEYS"),
+		SessionAccessTTL:   getEnvInt("SESSION_ACCESS_TTL", 900),
+		SessionRefreshTTL:  getEnvInt("SESSION_REFRESH_TTL", 7*24*3600),
 	}
 }
 
@@ -56,6 +188,41 @@ func (c *Config) Validate() error {
 	if c.TelegramBotToken == "" {
 		return &ConfigError{Field: "TELEGRAM_BOT_TOKEN", Message: "Telegram bot token is required"}
 	}
+	switch c.EmbeddingsProvider {
+	case "local":
+	case "openai":
+		if c.EmbeddingsAPIKey == "" {
+			return &ConfigError{Field: "EMBEDDINGS_API_KEY", Message: "Embeddings API key is required for the openai provider"}
+		}
+	default:
+		return &ConfigError{Field: "EMBEDDINGS_PROVIDER", Message: "Embeddings provider must be local or openai"}
+	}
+	switch c.STTProvider {
+	case "", "openai", "whisper":
+	default:
+		return &ConfigError{Field: "STT_PROVIDER", Message: "Speech-to-text provider must be openai, whisper or empty"}
+	}
+	if c.TracingSampleRate < 0 || c.TracingSampleRate > 1 {
+		return &ConfigError{Field: "OTEL_TRACES_SAMPLER_ARG", Message: "Trace sample ratio must be between 0 and 1"}
+	}
+	if c.RateLimitBackend != "memory" && c.RateLimitBackend != "postgres" {
+		return &ConfigError{Field: "RATE_LIMIT_BACKEND", Message: "Rate limit backend must be memory or postgres"}
+	}
+	if _, err := ratelimit.ParseLimit(c.RateLimitIP); err != nil {
+		return &ConfigError{Field: "RATE_LIMIT_IP", Message: err.Error()}
+	}
+	if _, err := ratelimit.ParseLimit(c.RateLimitUser); err != nil {
+		return &ConfigError{Field: "RATE_LIMIT_USER", Message: err.Error()}
+	}
+	if _, err := ratelimit.ParseRoutes(c.RateLimitRoutes); err != nil {
+		return &ConfigError{Field: "RATE_LIMIT_ROUTES", Message: err.Error()}
+	}
+	if c.TelegramAuthMaxAge <= 0 {
+		return &ConfigError{Field: "TELEGRAM_AUTH_MAX_AGE", Message: "Telegram auth max age must be positive"}
+	}
+	if c.SessionAccessTTL <= 0 || c.SessionRefreshTTL < c.SessionAccessTTL {
+		return &ConfigError{Field: "SESSION_REFRESH_TTL", Message: "Session TTLs must be positive and the refresh TTL must not be shorter than the access TTL"}
+	}
 	return nil
 }
 
@@ -67,6 +234,56 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt получает целочисленную переменную окружения
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvFloat получает дробную переменную окружения
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList получает список строк, разделенных запятыми
+func getEnvList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if value := strings.TrimSpace(part); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt64List получает список чисел, разделенных запятыми
+func getEnvInt64List(key string) []int64 {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if value, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvMap получает пары key=value, разделенные запятыми
+func getEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(part, "=")
+		if name = strings.TrimSpace(name); ok && name != "" {
+			values[name] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
 // ConfigError представляет ошибку конфигурации
 type ConfigError struct {
 	Field   string
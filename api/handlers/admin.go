@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultUsersPageSize размер страницы списка пользователей по умолчанию
+	defaultUsersPageSize = 50
+	// maxUsersPageSize максимальный размер страницы списка пользователей
+	maxUsersPageSize = 200
+)
+
+// AdminHandler обработчик админского API: пользователи, тарифы и блокировки
+type AdminHandler struct {
+	adminSvc *services.AdminService
+}
+
+// NewAdminHandler создает новый обработчик админского API
+func NewAdminHandler(adminSvc *services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminSvc: adminSvc,
+	}
+}
+
+// ListUsers возвращает страницу пользователей; q ищет по Telegram ID, username или имени
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultUsersPageSize)))
+	if err != nil || limit < 1 || limit > maxUsersPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	users, total, err := h.adminSvc.ListUsers(c.Query("q"), limit, offset)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error listing users", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	if users == nil {
+		users = []*models.UserSummary{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetUser возвращает карточку пользователя с использованием и блокировками
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.adminSvc.GetUser(c.Request.Context(), userID)
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// ListPlans возвращает доступные тарифы
+func (h *AdminHandler) ListPlans(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"plans": services.Plans()})
+}
+
+// SetPlan меняет тариф пользователя
+func (h *AdminHandler) SetPlan(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.adminSvc.SetPlan(c.Request.Context(), userID, req.Plan)
+	switch {
+	case errors.Is(err, services.ErrUnknownPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+		return
+	case errors.Is(err, models.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Error setting plan", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set plan"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Plan changed",
+		"admin_id", c.GetInt64("user_id"), "user_id", userID, "plan", account.Plan)
+
+	c.JSON(http.StatusOK, account)
+}
+
+// AddBonus начисляет пользователю бонусные сообщения
+func (h *AdminHandler) AddBonus(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BonusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.adminSvc.AddBonus(c.Request.Context(), userID, req.Messages)
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error adding bonus messages", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add bonus messages"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Bonus messages granted",
+		"admin_id", c.GetInt64("user_id"), "user_id", userID, "messages", req.Messages)
+
+	c.JSON(http.StatusOK, account)
+}
+
+// Ban блокирует пользователя бессрочно или на duration_hours часов
+func (h *AdminHandler) Ban(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID := c.GetInt64("user_id")
+	if userID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot ban themselves"})
+		return
+	}
+
+	ban, err := h.adminSvc.Ban(c.Request.Context(), userID, adminID, req)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error banning user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "User banned", "admin_id", adminID, "user_id", userID, "reason", ban.Reason)
+
+	c.JSON(http.StatusCreated, ban)
+}
+
+// Unban снимает блокировку пользователя
+func (h *AdminHandler) Unban(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	adminID := c.GetInt64("user_id")
+	err := h.adminSvc.Unban(c.Request.Context(), userID, adminID)
+	if errors.Is(err, models.ErrBanNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not banned"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error unbanning user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban user"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "User unbanned", "admin_id", adminID, "user_id", userID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// targetUserID разбирает Telegram ID пользователя из пути
+func targetUserID(c *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler обработчик персональных API ключей
+type APIKeyHandler struct {
+	apiKeySvc *services.APIKeyService
+}
+
+// NewAPIKeyHandler создает новый обработчик API ключей
+func NewAPIKeyHandler(apiKeySvc *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeySvc: apiKeySvc,
+	}
+}
+
+// List возвращает действующие ключи пользователя без самих ключей
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keys, err := h.apiKeySvc.List(c.Request.Context(), userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting api keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// Create создает ключ; ключ возвращается только в этом ответе
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.apiKeySvc.Create(c.Request.Context(), userID.(int64), req)
+	if errors.Is(err, services.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "scopes": models.APIKeyScopes})
+		return
+	}
+	if errors.Is(err, services.ErrAPIKeyLimit) {
+		c.JSON(http.StatusConflict, gin.H{"error": "API key limit reached, revoke some keys first"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error creating api key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// Revoke отзывает ключ пользователя
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	err = h.apiKeySvc.Revoke(c.Request.Context(), userID.(int64), keyID)
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking api key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler обработчик сессий: обмен initData или данных Login Widget на токены,
+// обновление и отзыв
+type AuthHandler struct {
+	telegramAuth *services.TelegramAuthService
+	sessionSvc   *services.SessionService
+}
+
+// NewAuthHandler создает новый обработчик сессий
+func NewAuthHandler(telegramAuth *services.TelegramAuthService, sessionSvc *services.SessionService) *AuthHandler {
+	return &AuthHandler{
+		telegramAuth: telegramAuth,
+		sessionSvc:   sessionSvc,
+	}
+}
+
+// CreateSession проверяет initData (заголовок X-Telegram-Init-Data или поле init_data)
+// и выдает токены сессии
+func (h *AuthHandler) CreateSession(c *gin.Context) {
+	initData := c.GetHeader("X-Telegram-Init-Data")
+	if initData == "" {
+		var req models.SessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		initData = req.InitData
+	}
+	if initData == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing Telegram WebApp data"})
+		return
+	}
+
+	webAppData, err := h.telegramAuth.ValidateWebAppData(initData)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid Telegram WebApp data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.sessionSvc.Create(c.Request.Context(), webAppData, c.Request.UserAgent())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error creating session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// Login проверяет данные Telegram Login Widget (объект, переданный в data-onauth)
+// и выдает токены сессии для работы с API из браузера вне Telegram
+func (h *AuthHandler) Login(c *gin.Context) {
+	var body map[string]any
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login data"})
+		return
+	}
+
+	// Подпись считается по текстовым значениям полей, поэтому числа сохраняются
+	// в том виде, в котором их передал виджет
+	fields := make(map[string]string, len(body))
+	for key, value := range body {
+		switch v := value.(type) {
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login data"})
+			return
+		}
+	}
+
+	loginData, err := h.telegramAuth.ValidateLoginWidget(fields)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid Telegram login data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.sessionSvc.Create(c.Request.Context(), loginData, c.Request.UserAgent())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error creating session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// Refresh выдает новую пару токенов по refresh токену
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessionSvc.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrTokenExpired) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error refreshing session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeSession отзывает сессию, токеном которой выполнен запрос
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request is not authenticated with a session token"})
+		return
+	}
+
+	err := h.sessionSvc.Revoke(c.Request.Context(), sessionID)
+	if err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+		slog.ErrorContext(c.Request.Context(), "Error revoking session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions отзывает все сессии пользователя, например после утечки токена
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	revoked, err := h.sessionSvc.RevokeAll(c.Request.Context(), userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking sessions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BroadcastHandler обработчик рассылок (только администраторы)
+type BroadcastHandler struct {
+	broadcastSvc *services.BroadcastService
+}
+
+// NewBroadcastHandler создает новый обработчик рассылок
+func NewBroadcastHandler(broadcastSvc *services.BroadcastService) *BroadcastHandler {
+	return &BroadcastHandler{
+		broadcastSvc: broadcastSvc,
+	}
+}
+
+// Create создает рассылку и запускает ее отправку
+func (h *BroadcastHandler) Create(c *gin.Context) {
+	var req models.BroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID := c.GetInt64("user_id")
+	broadcast, err := h.broadcastSvc.Create(adminID, req)
+	if errors.Is(err, services.ErrUnknownPlan) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan in segment"})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidHTML) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error creating broadcast", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create broadcast"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Broadcast created",
+		"admin_id", adminID, "broadcast_id", broadcast.ID, "recipients", broadcast.Stats.Total)
+
+	c.JSON(http.StatusCreated, broadcast)
+}
+
+// List возвращает последние рассылки со статистикой доставки
+func (h *BroadcastHandler) List(c *gin.Context) {
+	broadcasts, err := h.broadcastSvc.List()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error listing broadcasts", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list broadcasts"})
+		return
+	}
+	if broadcasts == nil {
+		broadcasts = []*models.Broadcast{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"broadcasts": broadcasts,
+		"count":      len(broadcasts),
+	})
+}
+
+// Get возвращает рассылку со статистикой доставки
+func (h *BroadcastHandler) Get(c *gin.Context) {
+	h.respond(c, h.broadcastSvc.Get)
+}
+
+// Pause приостанавливает рассылку
+func (h *BroadcastHandler) Pause(c *gin.Context) {
+	h.respond(c, h.broadcastSvc.Pause)
+}
+
+// Resume продолжает приостановленную рассылку
+func (h *BroadcastHandler) Resume(c *gin.Context) {
+	h.respond(c, h.broadcastSvc.Resume)
+}
+
+// Cancel отменяет рассылку
+func (h *BroadcastHandler) Cancel(c *gin.Context) {
+	h.respond(c, h.broadcastSvc.Cancel)
+}
+
+// respond выполняет действие над рассылкой из пути и возвращает ее состояние
+func (h *BroadcastHandler) respond(c *gin.Context, action func(id int64) (*models.Broadcast, error)) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid broadcast ID"})
+		return
+	}
+
+	broadcast, err := action(id)
+	switch {
+	case errors.Is(err, models.ErrBroadcastNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Broadcast not found"})
+		return
+	case errors.Is(err, models.ErrBroadcastState):
+		c.JSON(http.StatusConflict, gin.H{"error": "Broadcast status does not allow this action"})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Error updating broadcast", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update broadcast"})
+		return
+	}
+
+	c.JSON(http.StatusOK, broadcast)
+}
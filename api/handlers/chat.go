@@ -1,10 +1,17 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"telegram-api/logging"
+	"telegram-api/metrics"
 	"telegram-api/models"
 	"telegram-api/services"
 
@@ -16,21 +23,94 @@ type ChatHandler struct {
 	messageRepo     models.MessageRepository
 	openRouterSvc   *services.OpenRouterService
 	telegramAuthSvc *services.TelegramAuthService
+	contextBuilder  *services.ContextBuilder
+	settingsSvc     *services.SettingsService
+	quotaSvc        *services.QuotaService
+	memorySvc       *services.MemoryService
+	toolRegistry    *services.ToolRegistry
+	sharedTools     *services.ToolRegistry
+	transcriber     services.Transcriber
+	timeouts        ChatTimeouts
 }
 
+// ChatTimeouts сроки этапов обработки сообщения. Каждый этап получает дедлайн
+// от контекста запроса, поэтому отключение клиента прерывает любой этап.
+type ChatTimeouts struct {
+	Database   time.Duration // проверка лимита и сохранение сообщений
+	Transcribe time.Duration // распознавание голосового сообщения
+	Context    time.Duration // история, память, база знаний и документы
+	Model      time.Duration // ответ модели со всеми вызовами инструментов
+}
+
+// statusClientClosedRequest статус nginx для запросов, прерванных клиентом
+const statusClientClosedRequest = 499
+
+// chatTurn сообщение пользователя, на которое нужно ответить
+type chatTurn struct {
+	query        string // текст вопроса для поиска по базе знаний и документам
+	content      string // текст для истории (в группах — с именем автора)
+	documentID   int64
+	transcript   string
+	conversation models.Conversation
+}
+
+// continuePrompt сообщение, которым пользователь просит продолжить оборвавшийся ответ
+const continuePrompt = "Продолжи свой предыдущий ответ с того места, где он оборвался."
+
+// maxTranscriptLength ограничивает длину распознанного голосового сообщения.
+// Диктовка обычно длиннее набранного текста, поэтому лимит выше, чем в ChatRequest.
+const maxTranscriptLength = 2000
+
 // NewChatHandler создает новый обработчик чата
 func NewChatHandler(
 	messageRepo models.MessageRepository,
 	openRouterSvc *services.OpenRouterService,
 	telegramAuthSvc *services.TelegramAuthService,
+	contextBuilder *services.ContextBuilder,
+	settingsSvc *services.SettingsService,
+	quotaSvc *services.QuotaService,
+	memorySvc *services.MemoryService,
+	toolRegistry *services.ToolRegistry,
+	transcriber services.Transcriber,
+	timeouts ChatTimeouts,
 ) *ChatHandler {
 	return &ChatHandler{
 		messageRepo:     messageRepo,
 		openRouterSvc:   openRouterSvc,
 		telegramAuthSvc: telegramAuthSvc,
+		contextBuilder:  contextBuilder,
+		settingsSvc:     settingsSvc,
+		quotaSvc:        quotaSvc,
+		memorySvc:       memorySvc,
+		toolRegistry:    toolRegistry,
+		sharedTools:     toolRegistry.Shared(),
+		transcriber:     transcriber,
+		timeouts:        timeouts,
 	}
 }
 
+// stage возвращает контекст этапа обработки с дедлайном
+func stage(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), timeout)
+}
+
+// interrupted отвечает на ошибку этапа, прерванного отключением клиента
+// или дедлайном, и возвращает true. Остальные ошибки обрабатывает вызывающий.
+func interrupted(c *gin.Context, ctx context.Context, name string, err error) bool {
+	switch {
+	case c.Request.Context().Err() != nil:
+		// Отвечать некому: клиент уже закрыл соединение
+		slog.InfoContext(ctx, "Request canceled by client", "stage", name)
+		c.AbortWithStatus(statusClientClosedRequest)
+		return true
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		slog.WarnContext(ctx, "Request stage timed out", "stage", name, "error", err)
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		return true
+	}
+	return false
+}
+
 // SendMessage обрабатывает отправку сообщения
 func (h *ChatHandler) SendMessage(c *gin.Context) {
 	// Получаем данные пользователя из контекста
@@ -42,81 +122,525 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 
 	userIDInt64 := userID.(int64)
 
-	// Проверяем лимит сообщений (50 в день)
-	messageCount, err := h.messageRepo.GetUserMessageCount(userIDInt64)
+	// Проверяем лимит сообщений; списывается он только после успешного ответа
+	usage, ok := h.checkDailyLimit(c, userIDInt64)
+	if !ok {
+		return
+	}
+
+	// Парсим запрос
+	var req models.ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	turn := chatTurn{
+		query:      req.Message,
+		content:    req.Message,
+		documentID: req.DocumentID,
+	}
+
+	// Сообщения из групп принимаются только от бота: он проверяет, что пользователь
+	// действительно состоит в группе
+	if req.ChatID != 0 {
+		if c.GetString("auth_method") != "service" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Group conversations are available only to the bot"})
+			return
+		}
+		if !h.groupEnabled(c, req.ChatID) {
+			return
+		}
+
+		turn.conversation = models.Conversation{ChatID: req.ChatID, ThreadID: req.ThreadID}
+		turn.documentID = 0
+		if req.AuthorName != "" {
+			turn.content = req.AuthorName + ": " + req.Message
+		}
+	}
+
+	h.respond(c, userIDInt64, usage, turn)
+}
+
+// groupEnabled проверяет, что администраторы не отключили бота в группе
+func (h *ChatHandler) groupEnabled(c *gin.Context, chatID int64) bool {
+	group, err := h.settingsSvc.GetGroup(chatID)
 	if err != nil {
-		log.Printf("Error getting message count: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Error getting group settings", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return false
+	}
+	if !group.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Bot is disabled in this chat"})
+		return false
+	}
+	return true
+}
+
+// SendVoice распознает голосовое сообщение из поля формы "file" и отвечает на него.
+// Необязательное поле формы "document_id" работает так же, как в SendMessage.
+func (h *ChatHandler) SendVoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	if messageCount >= 50 {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "Daily message limit reached (50 messages)",
-			"limit": 50,
-			"used":  messageCount,
+	userIDInt64 := userID.(int64)
+
+	if h.transcriber == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Speech recognition is not configured",
+			"code":  "transcription_unavailable",
 		})
 		return
 	}
 
-	// Парсим запрос
-	var req models.ChatRequest
+	// Проверяем лимит до распознавания, чтобы не тратить ресурсы впустую
+	usage, ok := h.checkDailyLimit(c, userIDInt64)
+	if !ok {
+		return
+	}
+
+	filename, data, ok := readFormFile(c, services.MaxAudioSize)
+	if !ok {
+		return
+	}
+	documentID, _ := strconv.ParseInt(c.PostForm("document_id"), 10, 64)
+
+	ctx, cancel := stage(c, h.timeouts.Transcribe)
+	defer cancel()
+
+	transcript, err := h.transcriber.Transcribe(ctx, filename, data)
+	if err != nil && interrupted(c, ctx, "transcribe", err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error transcribing voice message", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to transcribe audio"})
+		return
+	}
+
+	transcript = strings.TrimSpace(transcript)
+	if transcript == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No speech recognized"})
+		return
+	}
+	if runes := []rune(transcript); len(runes) > maxTranscriptLength {
+		transcript = string(runes[:maxTranscriptLength])
+	}
+
+	h.respond(c, userIDInt64, usage, chatTurn{
+		query:      transcript,
+		content:    transcript,
+		documentID: documentID,
+		transcript: transcript,
+	})
+}
+
+// Inline отвечает на отдельный вопрос из inline режима бота. Ответ публикуется
+// в произвольном чате и не продолжает разговор, поэтому история не используется
+// и не пополняется, а память, документы и личные инструменты недоступны. Вопрос сохраняется в
+// отдельный диалог только для учета дневного лимита.
+func (h *ChatHandler) Inline(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDInt64 := userID.(int64)
+
+	var req models.InlineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	usage, ok := h.checkDailyLimit(c, userIDInt64)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := stage(c, h.timeouts.Context)
+	chatContext, err := h.contextBuilder.BuildStateless(ctx, userIDInt64, req.Message)
+	cancel()
+	if err != nil && interrupted(c, ctx, "context", err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error building chat context", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build chat context"})
+		return
+	}
+
+	question := &models.Message{
+		UserID:         userIDInt64,
+		ConversationID: models.InlineConversationID,
+		Content:        req.Message,
+		Role:           "user",
+		CreatedAt:      time.Now(),
+	}
+	chatContext.Messages = append(chatContext.Messages, question)
+
+	assistantMessage, ok := h.generate(c, userIDInt64, h.sharedTools, chatContext)
+	if !ok {
+		return
+	}
+
+	// Ответ получен: вопрос учитывается в лимите
+	ctx, cancel = h.saveContext(c)
+	defer cancel()
+	if err := h.quotaSvc.Consume(ctx, userIDInt64, usage); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to consume bonus message", "user_id", userIDInt64, "error", err)
+	}
+	if err := h.messageRepo.Save(ctx, question); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving inline question", "error", err)
+	}
+
+	slog.InfoContext(c.Request.Context(), "Inline request processed",
+		"user_id", userIDInt64, "response_length", len(assistantMessage.Content))
+
+	c.JSON(http.StatusOK, models.ChatResponse{
+		Message:   assistantMessage.Content,
+		Timestamp: assistantMessage.CreatedAt,
+		Citations: chatContext.Citations,
+	})
+}
+
+// checkDailyLimit проверяет, что у пользователя остались сообщения в дневном лимите
+// или бонусные, и возвращает текущее использование. Ничего не списывается:
+// сообщение учитывается в respond только после успешного ответа модели.
+func (h *ChatHandler) checkDailyLimit(c *gin.Context, userID int64) (*models.Usage, bool) {
+	ctx, cancel := stage(c, h.timeouts.Database)
+	defer cancel()
+
+	usage, err := h.quotaSvc.Check(ctx, userID)
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		metrics.QuotaRejections.Inc(usage.Plan)
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": fmt.Sprintf("Daily message limit reached (%d messages)", usage.DailyLimit),
+			"limit": usage.DailyLimit,
+			"used":  usage.DailyMessages,
+		})
+		return nil, false
+	}
+	if err != nil && interrupted(c, ctx, "quota", err) {
+		return nil, false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting usage", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+
+	return usage, true
+}
+
+// respond получает ответ модели на сообщение пользователя и отправляет его клиенту.
+// usage — использование из checkDailyLimit. Сообщение пользователя сохраняется
+// и учитывается в лимите только вместе с успешным ответом, поэтому ошибки,
+// таймауты и неверные запросы не расходуют ни дневной лимит, ни бонусы.
+func (h *ChatHandler) respond(c *gin.Context, userID int64, usage *models.Usage, turn chatTurn) {
+	slog.DebugContext(c.Request.Context(), "Chat request",
+		"user_id", userID, "conversation", turn.conversation.ID(), logging.KeyContent, turn.content)
+
 	// Создаем сообщение пользователя
 	userMessage := &models.Message{
-		UserID:    userIDInt64,
-		Content:   req.Message,
-		Role:      "user",
-		CreatedAt: time.Now(),
+		UserID:         userID,
+		ConversationID: turn.conversation.ID(),
+		Content:        turn.content,
+		Role:           "user",
+		CreatedAt:      time.Now(),
 	}
 
-	// Сохраняем сообщение пользователя
-	if err := h.messageRepo.Save(userMessage); err != nil {
-		log.Printf("Error saving user message: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+	// Собираем контекст: история сообщений, база знаний и документы
+	ctx, cancel := stage(c, h.timeouts.Context)
+	chatContext, err := h.contextBuilder.Build(ctx, userID, turn.conversation, turn.query, turn.documentID)
+	cancel()
+	if errors.Is(err, models.ErrDocumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil && interrupted(c, ctx, "context", err) {
 		return
 	}
-
-	// Получаем историю сообщений для контекста (последние 10)
-	history, err := h.messageRepo.GetByUserID(userIDInt64, 10)
 	if err != nil {
-		log.Printf("Error getting message history: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Error building chat context", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get message history"})
 		return
 	}
 
+	// Сообщение пользователя еще не сохранено, поэтому его нет в истории
+	chatContext.Messages = append(chatContext.Messages, userMessage)
+
 	// Отправляем в OpenRouter
-	assistantMessage, err := h.openRouterSvc.SendMessage(history)
-	if err != nil {
-		log.Printf("Error sending message to OpenRouter: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI response"})
+	started := time.Now()
+	assistantMessage, ok := h.generate(c, userID, h.tools(turn.conversation), chatContext)
+	if !ok {
 		return
 	}
 
-	// Устанавливаем UserID для ответа
-	assistantMessage.UserID = userIDInt64
+	// Ответ принадлежит автору вопроса и тому же диалогу
+	assistantMessage.UserID = userID
+	assistantMessage.ConversationID = turn.conversation.ID()
+	assistantMessage.Model = chatContext.Model
+	assistantMessage.Persona = chatContext.Persona
+
+	// Ответ получен: сохраняем сообщение пользователя, оно учитывается в лимите
+	ctx, cancel = h.saveContext(c)
+	defer cancel()
+	if err := h.quotaSvc.Consume(ctx, userID, usage); err != nil {
+		// Бонусы могли закончиться параллельным запросом; ответ все равно отдаем
+		slog.WarnContext(c.Request.Context(), "Failed to consume bonus message", "user_id", userID, "error", err)
+	}
+	if err := h.messageRepo.Save(ctx, userMessage); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving user message", "error", err)
+	}
 
 	// Сохраняем ответ ассистента
-	if err := h.messageRepo.Save(assistantMessage); err != nil {
-		log.Printf("Error saving assistant message: %v", err)
+	if err := h.messageRepo.Save(ctx, assistantMessage); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving assistant message", "error", err)
 		// Не возвращаем ошибку, так как ответ уже получен
 	}
 
 	// Логируем успешный запрос
-	log.Printf("Chat request processed: UserID=%d, MessageCount=%d, ResponseLength=%d",
-		userIDInt64, messageCount+1, len(assistantMessage.Content))
+	slog.InfoContext(c.Request.Context(), "Chat request processed",
+		"user_id", userID, "message_count", usage.DailyMessages+1, "response_length", len(assistantMessage.Content))
 
 	// Возвращаем ответ
 	c.JSON(http.StatusOK, models.ChatResponse{
-		Message:   assistantMessage.Content,
-		Timestamp: assistantMessage.CreatedAt,
+		MessageID:       assistantMessage.ID,
+		Message:         assistantMessage.Content,
+		Timestamp:       assistantMessage.CreatedAt,
+		Citations:       chatContext.Citations,
+		Transcript:      turn.transcript,
+		MemoryProposals: h.memoryProposals(c, userID, turn.conversation, started),
+	})
+}
+
+// memoryProposals возвращает факты, которые модель предложила запомнить при ответе,
+// чтобы клиент попросил пользователя их подтвердить. В группах инструмент недоступен.
+func (h *ChatHandler) memoryProposals(c *gin.Context, userID int64, conversation models.Conversation, since time.Time) []*models.MemoryProposal {
+	if conversation.IsGroup() || h.toolRegistry == nil {
+		return nil
+	}
+
+	ctx, cancel := stage(c, h.timeouts.Database)
+	defer cancel()
+
+	proposals, err := h.memorySvc.Proposals(ctx, userID, since)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting memory proposals", "error", err)
+		return nil
+	}
+	return proposals
+}
+
+// tools возвращает инструменты для диалога. В группах ответ видят все участники,
+// поэтому там доступны только инструменты без личных данных пользователя.
+func (h *ChatHandler) tools(conversation models.Conversation) *services.ToolRegistry {
+	if conversation.IsGroup() {
+		return h.sharedTools
+	}
+	return h.toolRegistry
+}
+
+// generate получает ответ модели в пределах срока этапа. При ошибке отвечает клиенту
+// и возвращает false.
+func (h *ChatHandler) generate(c *gin.Context, userID int64, tools *services.ToolRegistry, chatContext *services.ChatContext) (*models.Message, bool) {
+	ctx, cancel := stage(c, h.timeouts.Model)
+	defer cancel()
+
+	assistantMessage, err := h.openRouterSvc.SendMessageWithTools(
+		ctx,
+		chatContext.Model,
+		chatContext.Messages,
+		tools,
+		services.ToolContext{UserID: userID},
+	)
+	switch {
+	case err == nil:
+		return assistantMessage, true
+	case errors.Is(err, services.ErrCircuitOpen):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "AI service is temporarily unavailable",
+			"code":  "model_unavailable",
+		})
+	case interrupted(c, ctx, "model", err):
+	default:
+		slog.ErrorContext(c.Request.Context(), "Error sending message to OpenRouter", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI response"})
+	}
+	return nil, false
+}
+
+// saveContext контекст сохранения полученного ответа. Ответ модели уже оплачен,
+// поэтому он сохраняется в историю, даже если клиент успел отключиться.
+func (h *ChatHandler) saveContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(c.Request.Context()), h.timeouts.Database)
+}
+
+// Regenerate заново генерирует ответ ассистента на тот же вопрос пользователя.
+// Новый ответ заменяет старый в истории; каждый успешный вызов модели
+// учитывается в дневном лимите так же, как новое сообщение.
+func (h *ChatHandler) Regenerate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDInt64 := userID.(int64)
+
+	var req models.RegenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usage, ok := h.checkDailyLimit(c, userIDInt64)
+	if !ok {
+		return
+	}
+
+	message, conversation, ok := h.assistantMessage(c, userIDInt64, req.MessageID)
+	if !ok {
+		return
+	}
+	if conversation.IsGroup() && !h.groupEnabled(c, conversation.ChatID) {
+		return
+	}
+
+	ctx, cancel := stage(c, h.timeouts.Context)
+	question := h.precedingQuestion(ctx, userIDInt64, conversation, message.ID)
+	chatContext, err := h.contextBuilder.BuildBefore(ctx, userIDInt64, conversation, question, message.ID)
+	cancel()
+	if err != nil && interrupted(c, ctx, "context", err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error building chat context", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get message history"})
+		return
+	}
+
+	started := time.Now()
+	assistantMessage, ok := h.generate(c, userIDInt64, h.tools(conversation), chatContext)
+	if !ok {
+		return
+	}
+
+	message.Content = assistantMessage.Content
+	message.Model = chatContext.Model
+	message.Persona = chatContext.Persona
+	ctx, cancel = h.saveContext(c)
+	defer cancel()
+	if err := h.quotaSvc.Consume(ctx, userIDInt64, usage); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to consume bonus message", "user_id", userIDInt64, "error", err)
+	}
+	if err := h.messageRepo.SaveRegeneration(ctx, userIDInt64, message.ID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving regeneration", "error", err)
+	}
+	if err := h.messageRepo.UpdateAnswer(ctx, message); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error updating assistant message", "error", err)
+	}
+
+	slog.InfoContext(c.Request.Context(), "Chat response regenerated",
+		"user_id", userIDInt64, "message_id", message.ID, "response_length", len(assistantMessage.Content))
+
+	c.JSON(http.StatusOK, models.ChatResponse{
+		MessageID:       message.ID,
+		Message:         assistantMessage.Content,
+		Timestamp:       assistantMessage.CreatedAt,
+		Citations:       chatContext.Citations,
+		MemoryProposals: h.memoryProposals(c, userIDInt64, conversation, started),
+	})
+}
+
+// Continue просит модель продолжить оборвавшийся ответ в том же диалоге.
+// Просьба сохраняется как сообщение пользователя и расходует дневной лимит.
+func (h *ChatHandler) Continue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDInt64 := userID.(int64)
+
+	var req models.RegenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usage, ok := h.checkDailyLimit(c, userIDInt64)
+	if !ok {
+		return
+	}
+
+	_, conversation, ok := h.assistantMessage(c, userIDInt64, req.MessageID)
+	if !ok {
+		return
+	}
+	if conversation.IsGroup() && !h.groupEnabled(c, conversation.ChatID) {
+		return
+	}
+
+	h.respond(c, userIDInt64, usage, chatTurn{
+		query:        continuePrompt,
+		content:      continuePrompt,
+		conversation: conversation,
 	})
 }
 
+// assistantMessage загружает ответ ассистента пользователя и диалог, к которому он относится
+func (h *ChatHandler) assistantMessage(c *gin.Context, userID, messageID int64) (*models.Message, models.Conversation, bool) {
+	ctx, cancel := stage(c, h.timeouts.Database)
+	defer cancel()
+
+	message, err := h.messageRepo.GetByID(ctx, userID, messageID)
+	if errors.Is(err, models.ErrMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return nil, models.Conversation{}, false
+	}
+	if err != nil && interrupted(c, ctx, "message", err) {
+		return nil, models.Conversation{}, false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting message", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, models.Conversation{}, false
+	}
+	if message.Role != "assistant" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not an assistant message"})
+		return nil, models.Conversation{}, false
+	}
+
+	conversation, err := models.ParseConversationID(message.ConversationID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error parsing conversation", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, models.Conversation{}, false
+	}
+
+	return message, conversation, true
+}
+
+// precedingQuestion возвращает последнее сообщение пользователя перед ответом ассистента
+func (h *ChatHandler) precedingQuestion(ctx context.Context, userID int64, conversation models.Conversation, messageID int64) string {
+	history, err := h.messageRepo.GetConversation(ctx, userID, conversation.ID(), messageID, 1)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting preceding message", "error", err)
+		return ""
+	}
+	if len(history) == 0 || history[0].Role != "user" {
+		return ""
+	}
+	return history[0].Content
+}
+
 // GetHistory получает историю сообщений пользователя
 func (h *ChatHandler) GetHistory(c *gin.Context) {
 	// Получаем данные пользователя из контекста
@@ -129,9 +653,15 @@ func (h *ChatHandler) GetHistory(c *gin.Context) {
 	userIDInt64 := userID.(int64)
 
 	// Получаем историю сообщений
-	messages, err := h.messageRepo.GetByUserID(userIDInt64, 50)
+	ctx, cancel := stage(c, h.timeouts.Database)
+	defer cancel()
+
+	messages, err := h.messageRepo.GetByUserID(ctx, userIDInt64, 50)
+	if err != nil && interrupted(c, ctx, "history", err) {
+		return
+	}
 	if err != nil {
-		log.Printf("Error getting message history: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Error getting message history", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get message history"})
 		return
 	}
@@ -153,17 +683,18 @@ func (h *ChatHandler) GetStats(c *gin.Context) {
 
 	userIDInt64 := userID.(int64)
 
-	// Получаем количество сообщений за сегодня
-	messageCount, err := h.messageRepo.GetUserMessageCount(userIDInt64)
+	ctx, cancel := stage(c, h.timeouts.Database)
+	defer cancel()
+
+	usage, err := h.quotaSvc.Usage(ctx, userIDInt64)
+	if err != nil && interrupted(c, ctx, "stats", err) {
+		return
+	}
 	if err != nil {
-		log.Printf("Error getting message count: %v", err)
+		slog.ErrorContext(c.Request.Context(), "Error getting usage", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stats"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"daily_messages": messageCount,
-		"daily_limit":    50,
-		"remaining":      50 - messageCount,
-	})
+	c.JSON(http.StatusOK, usage)
 }
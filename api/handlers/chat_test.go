@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testUserID int64 = 42
+
+// memoryMessages хранит сообщения в памяти вместо таблицы messages
+type memoryMessages struct {
+	models.MessageRepository
+
+	mu            sync.Mutex
+	messages      []*models.Message
+	regenerations int
+}
+
+func (r *memoryMessages) Save(_ context.Context, message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message.ID = int64(len(r.messages) + 1)
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *memoryMessages) GetConversation(_ context.Context, userID int64, conversationID string, beforeID int64, limit int) ([]*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var history []*models.Message
+	for _, message := range r.messages {
+		if message.UserID == userID && message.ConversationID == conversationID && (beforeID == 0 || message.ID < beforeID) {
+			history = append(history, message)
+		}
+	}
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+func (r *memoryMessages) GetByID(_ context.Context, userID, messageID int64) (*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, message := range r.messages {
+		if message.ID == messageID && message.UserID == userID {
+			return message, nil
+		}
+	}
+	return nil, models.ErrMessageNotFound
+}
+
+func (r *memoryMessages) UpdateAnswer(_ context.Context, message *models.Message) error {
+	return nil
+}
+
+func (r *memoryMessages) SaveRegeneration(context.Context, int64, int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.regenerations++
+	return nil
+}
+
+func (r *memoryMessages) GetUserMessageCount(_ context.Context, userID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := r.regenerations
+	for _, message := range r.messages {
+		if message.UserID == userID && message.Role == "user" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// count возвращает число сохраненных сообщений с ролью role
+func (r *memoryMessages) count(role string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, message := range r.messages {
+		if message.Role == role {
+			n++
+		}
+	}
+	return n
+}
+
+type stubAccounts struct {
+	models.AccountRepository
+}
+
+func (stubAccounts) Get(_ context.Context, userID int64) (*models.Account, error) {
+	return &models.Account{UserID: userID, Plan: models.DefaultPlan}, nil
+}
+
+func (stubAccounts) UseBonus(context.Context, int64) error {
+	return models.ErrNoBonusMessages
+}
+
+type stubSettings struct {
+	models.SettingsRepository
+}
+
+func (stubSettings) Get(_ context.Context, userID int64) (*models.UserSettings, error) {
+	return &models.UserSettings{UserID: userID}, nil
+}
+
+type stubMemories struct {
+	models.MemoryRepository
+}
+
+func (stubMemories) GetByUserID(context.Context, int64) ([]*models.Memory, error) {
+	return nil, nil
+}
+
+func (stubMemories) GetProposals(context.Context, int64, time.Time) ([]*models.MemoryProposal, error) {
+	return nil, nil
+}
+
+type stubDocuments struct {
+	models.DocumentRepository
+}
+
+func (stubDocuments) GetByUserID(int64, int) ([]*models.Document, error) {
+	return nil, nil
+}
+
+type stubKnowledge struct {
+	models.KnowledgeRepository
+}
+
+func (stubKnowledge) GetChunks(string) ([]*models.KnowledgeChunk, error) {
+	return nil, nil
+}
+
+type stubEmbeddings struct {
+	services.EmbeddingProvider
+}
+
+func (stubEmbeddings) Model() string { return "stub" }
+
+// fakeModel имитирует OpenRouter: отвечает ошибкой, пока fail установлен
+type fakeModel struct {
+	mu       sync.Mutex
+	fail     bool
+	requests []models.OpenRouterRequest
+}
+
+func (m *fakeModel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request models.OpenRouterRequest
+	json.NewDecoder(r.Body).Decode(&request)
+
+	m.mu.Lock()
+	m.requests = append(m.requests, request)
+	fail := m.fail
+	m.mu.Unlock()
+
+	if fail {
+		http.Error(w, `{"error":{"message":"upstream error"}}`, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"choices":[{"message":{"content":"Ответ"},"finish_reason":"stop"}]}`))
+}
+
+func (m *fakeModel) setFail(fail bool) {
+	m.mu.Lock()
+	m.fail = fail
+	m.mu.Unlock()
+}
+
+// newTestChatHandler собирает ChatHandler с хранилищами в памяти и поддельной моделью
+func newTestChatHandler(t *testing.T) (*ChatHandler, *memoryMessages, *fakeModel) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	model := &fakeModel{}
+	server := httptest.NewServer(model)
+	t.Cleanup(server.Close)
+
+	messages := &memoryMessages{}
+	settingsSvc := services.NewSettingsService(stubSettings{}, nil, nil, "test-model")
+	memorySvc := services.NewMemoryService(stubMemories{})
+	contextBuilder := services.NewContextBuilder(
+		messages,
+		services.NewDocumentService(stubDocuments{}),
+		services.NewKnowledgeService(stubKnowledge{}, stubEmbeddings{}, 3, 0.5),
+		memorySvc,
+		settingsSvc,
+	)
+
+	handler := NewChatHandler(
+		messages,
+		services.NewOpenRouterService("test-key", server.URL, "test-model"),
+		nil,
+		contextBuilder,
+		settingsSvc,
+		services.NewQuotaService(stubAccounts{}, messages),
+		memorySvc,
+		nil,
+		nil,
+		ChatTimeouts{Database: 5 * time.Second, Context: 5 * time.Second, Model: 5 * time.Second},
+	)
+	return handler, messages, model
+}
+
+// serve вызывает обработчик от имени тестового пользователя
+func serve(handle gin.HandlerFunc, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/chat", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", testUserID)
+	handle(c)
+	return recorder
+}
+
+func TestSendMessageFailedAnswerIsNotCounted(t *testing.T) {
+	handler, messages, model := newTestChatHandler(t)
+
+	model.setFail(true)
+	if w := serve(handler.SendMessage, models.ChatRequest{Message: "Вопрос"}); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failed answer: status %d, want 500: %s", w.Code, w.Body)
+	}
+	if n := messages.count("user"); n != 0 {
+		t.Fatalf("failed answer saved %d user messages, want 0", n)
+	}
+
+	usage, err := handler.quotaSvc.Usage(context.Background(), testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.DailyMessages != 0 {
+		t.Errorf("failed answer counted against the quota: %d messages used", usage.DailyMessages)
+	}
+
+	model.setFail(false)
+	if w := serve(handler.SendMessage, models.ChatRequest{Message: "Вопрос"}); w.Code != http.StatusOK {
+		t.Fatalf("successful answer: status %d, want 200: %s", w.Code, w.Body)
+	}
+	if user, assistant := messages.count("user"), messages.count("assistant"); user != 1 || assistant != 1 {
+		t.Errorf("successful answer saved %d user and %d assistant messages, want 1 and 1", user, assistant)
+	}
+
+	// Несохраненный вопрос все равно передается модели
+	last := model.requests[len(model.requests)-1].Messages
+	if len(last) == 0 || last[len(last)-1].Content != "Вопрос" {
+		t.Errorf("model request does not end with the question: %+v", last)
+	}
+}
+
+func TestRegenerateCountsAgainstDailyLimit(t *testing.T) {
+	handler, messages, _ := newTestChatHandler(t)
+	ctx := context.Background()
+
+	plan, _ := services.FindPlan(models.DefaultPlan)
+	messages.Save(ctx, &models.Message{UserID: testUserID, Role: "user", Content: "Вопрос"})
+	answer := &models.Message{UserID: testUserID, Role: "assistant", Content: "Ответ"}
+	messages.Save(ctx, answer)
+	// До лимита остается одно сообщение
+	for i := 1; i < plan.DailyLimit-1; i++ {
+		messages.Save(ctx, &models.Message{UserID: testUserID, Role: "user", Content: "Еще вопрос", ConversationID: "other"})
+	}
+
+	request := models.RegenerateRequest{MessageID: answer.ID}
+	if w := serve(handler.Regenerate, request); w.Code != http.StatusOK {
+		t.Fatalf("regeneration within the limit: status %d, want 200: %s", w.Code, w.Body)
+	}
+
+	usage, err := handler.quotaSvc.Usage(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.DailyMessages != plan.DailyLimit {
+		t.Errorf("regeneration was not counted: %d of %d messages used", usage.DailyMessages, plan.DailyLimit)
+	}
+
+	if w := serve(handler.Regenerate, request); w.Code != http.StatusTooManyRequests {
+		t.Errorf("regeneration after the limit: status %d, want 429", w.Code)
+	}
+}
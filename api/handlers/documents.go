@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentHandler обработчик для загрузки документов
+type DocumentHandler struct {
+	documentSvc *services.DocumentService
+}
+
+// NewDocumentHandler создает новый обработчик документов
+func NewDocumentHandler(documentSvc *services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{
+		documentSvc: documentSvc,
+	}
+}
+
+// Upload принимает файл (PDF, DOCX, TXT, Markdown) в поле формы "file"
+func (h *DocumentHandler) Upload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDInt64 := userID.(int64)
+
+	filename, data, ok := readFormFile(c, services.MaxDocumentSize)
+	if !ok {
+		return
+	}
+
+	document, err := h.documentSvc.Upload(userIDInt64, filename, data)
+	switch {
+	case errors.Is(err, services.ErrUnsupportedDocument):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Supported formats: PDF, DOCX, TXT, Markdown"})
+		return
+	case errors.Is(err, services.ErrEmptyDocument):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No text could be extracted from the document"})
+		return
+	case errors.Is(err, services.ErrDocumentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Document content is too large to process",
+			"code":  "content_too_large",
+		})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Error uploading document", "error", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to process document"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Document uploaded",
+		"user_id", userIDInt64, "document_id", document.ID, "chunks", document.ChunkCount)
+
+	c.JSON(http.StatusCreated, document)
+}
+
+// List возвращает документы пользователя
+func (h *DocumentHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	documents, err := h.documentSvc.List(userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting documents", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"count":     len(documents),
+	})
+}
+
+// Delete удаляет документ пользователя
+func (h *DocumentHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	documentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	err = h.documentSvc.Delete(userID.(int64), documentID)
+	if errors.Is(err, models.ErrDocumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error deleting document", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// readFormFile читает файл из поля формы "file", отвечая ошибкой при неудаче
+func readFormFile(c *gin.Context, maxSize int64) (string, []byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required in the \"file\" form field"})
+		return "", nil, false
+	}
+
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "File is too large",
+			"max_bytes": maxSize,
+		})
+		return "", nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return "", nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return "", nil, false
+	}
+
+	return filepath.Base(fileHeader.Filename), data, true
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// reportDateLayout формат дат в параметрах отчета
+	reportDateLayout = "2006-01-02"
+	// defaultReportDays период отчета по умолчанию
+	defaultReportDays = 30
+)
+
+// FeedbackHandler обработчик оценок ответов ассистента
+type FeedbackHandler struct {
+	feedbackSvc *services.FeedbackService
+}
+
+// NewFeedbackHandler создает новый обработчик оценок
+func NewFeedbackHandler(feedbackSvc *services.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackSvc: feedbackSvc,
+	}
+}
+
+// Rate сохраняет оценку и необязательный комментарий к ответу ассистента
+func (h *FeedbackHandler) Rate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	messageID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := h.feedbackSvc.Rate(c.Request.Context(), userID.(int64), messageID, req)
+	if errors.Is(err, models.ErrMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if errors.Is(err, services.ErrNotAnswer) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only assistant messages can be rated"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving feedback", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// Report возвращает удовлетворенность ответами по моделям и персонам.
+// Параметры: period (day, week, month; по умолчанию week), from и to (YYYY-MM-DD,
+// to включительно; по умолчанию последние 30 дней).
+func (h *FeedbackHandler) Report(c *gin.Context) {
+	to := time.Now().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if value := c.Query("to"); value != "" {
+		date, err := time.Parse(reportDateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultReportDays)
+	if value := c.Query("from"); value != "" {
+		date, err := time.Parse(reportDateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = date
+	}
+
+	period := c.DefaultQuery("period", "week")
+	rows, err := h.feedbackSvc.Report(from, to, period)
+	if errors.Is(err, services.ErrInvalidReportRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be day, week or month and from must precede to"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting feedback report", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feedback report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from.Format(reportDateLayout),
+		"to":     to.AddDate(0, 0, -1).Format(reportDateLayout),
+		"period": period,
+		"rows":   rows,
+	})
+}
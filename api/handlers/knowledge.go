@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KnowledgeHandler обработчик для управления базой знаний (только администраторы)
+type KnowledgeHandler struct {
+	knowledgeSvc *services.KnowledgeService
+}
+
+// NewKnowledgeHandler создает новый обработчик базы знаний
+func NewKnowledgeHandler(knowledgeSvc *services.KnowledgeService) *KnowledgeHandler {
+	return &KnowledgeHandler{
+		knowledgeSvc: knowledgeSvc,
+	}
+}
+
+// Upload добавляет документ в базу знаний; необязательное поле формы "title" задает заголовок
+func (h *KnowledgeHandler) Upload(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+
+	filename, data, ok := readFormFile(c, services.MaxDocumentSize)
+	if !ok {
+		return
+	}
+
+	document, err := h.knowledgeSvc.Upload(c.Request.Context(), adminID, c.PostForm("title"), filename, data)
+	switch {
+	case errors.Is(err, services.ErrUnsupportedDocument):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Supported formats: PDF, DOCX, TXT, Markdown"})
+		return
+	case errors.Is(err, services.ErrEmptyDocument):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No text could be extracted from the document"})
+		return
+	case errors.Is(err, services.ErrDocumentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Document content is too large to process",
+			"code":  "content_too_large",
+		})
+		return
+	case err != nil:
+		slog.ErrorContext(c.Request.Context(), "Error uploading knowledge document", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process document"})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Knowledge document uploaded",
+		"admin_id", adminID, "document_id", document.ID, "chunks", document.ChunkCount)
+
+	c.JSON(http.StatusCreated, document)
+}
+
+// List возвращает документы базы знаний
+func (h *KnowledgeHandler) List(c *gin.Context) {
+	documents, err := h.knowledgeSvc.List()
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting knowledge documents", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"count":     len(documents),
+	})
+}
+
+// Delete удаляет документ из базы знаний
+func (h *KnowledgeHandler) Delete(c *gin.Context) {
+	documentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	err = h.knowledgeSvc.Delete(documentID)
+	if errors.Is(err, models.ErrKnowledgeDocumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error deleting knowledge document", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Search показывает, какие фрагменты попадут в контекст для запроса q
+func (h *KnowledgeHandler) Search(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	excerpts, err := h.knowledgeSvc.Search(c.Request.Context(), query)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error searching knowledge base", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search knowledge base"})
+		return
+	}
+
+	results := make([]gin.H, len(excerpts))
+	for i, excerpt := range excerpts {
+		results[i] = gin.H{
+			"chunk": excerpt.Chunk,
+			"score": excerpt.Score,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
+	})
+}
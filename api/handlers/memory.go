@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemoryHandler обработчик для просмотра и редактирования памяти о пользователе
+type MemoryHandler struct {
+	memorySvc *services.MemoryService
+}
+
+// NewMemoryHandler создает новый обработчик памяти
+func NewMemoryHandler(memorySvc *services.MemoryService) *MemoryHandler {
+	return &MemoryHandler{
+		memorySvc: memorySvc,
+	}
+}
+
+// List возвращает все факты, которые ассистент помнит о пользователе
+func (h *MemoryHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	memories, err := h.memorySvc.List(c.Request.Context(), userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting memories", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get memories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memories": memories,
+		"count":    len(memories),
+	})
+}
+
+// Create добавляет факт о пользователе вручную
+func (h *MemoryHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	memory, err := h.memorySvc.Add(c.Request.Context(), userID.(int64), req.Content, "user")
+	if errors.Is(err, services.ErrMemoryLimit) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Memory limit reached, delete some facts first"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error saving memory", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save memory"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, memory)
+}
+
+// Delete удаляет один факт о пользователе
+func (h *MemoryHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	memoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid memory ID"})
+		return
+	}
+
+	err = h.memorySvc.Delete(c.Request.Context(), userID.(int64), memoryID)
+	if errors.Is(err, models.ErrMemoryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error deleting memory", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete memory"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Clear удаляет все факты о пользователе
+func (h *MemoryHandler) Clear(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	deleted, err := h.memorySvc.Clear(c.Request.Context(), userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error clearing memories", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear memories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// ListProposals возвращает факты, которые ассистент предложил запомнить
+func (h *MemoryHandler) ListProposals(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	proposals, err := h.memorySvc.Proposals(c.Request.Context(), userID.(int64), time.Time{})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting memory proposals", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get memory proposals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposals": proposals,
+		"count":     len(proposals),
+	})
+}
+
+// ConfirmProposal сохраняет предложенный ассистентом факт в память
+func (h *MemoryHandler) ConfirmProposal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	proposalID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	memory, err := h.memorySvc.Confirm(c.Request.Context(), userID.(int64), proposalID)
+	if errors.Is(err, models.ErrProposalNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory proposal not found"})
+		return
+	}
+	if errors.Is(err, services.ErrMemoryLimit) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Memory limit reached, delete some facts first"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error confirming memory proposal", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save memory"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, memory)
+}
+
+// RejectProposal отклоняет предложенный ассистентом факт
+func (h *MemoryHandler) RejectProposal(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	proposalID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	err = h.memorySvc.Reject(c.Request.Context(), userID.(int64), proposalID)
+	if errors.Is(err, models.ErrProposalNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Memory proposal not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error rejecting memory proposal", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject memory proposal"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-api/models"
+	"telegram-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler обработчик пользовательских настроек
+type SettingsHandler struct {
+	settingsSvc *services.SettingsService
+}
+
+// NewSettingsHandler создает новый обработчик настроек
+func NewSettingsHandler(settingsSvc *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsSvc: settingsSvc,
+	}
+}
+
+// Get возвращает настройки пользователя вместе с доступными моделями и персонами
+func (h *SettingsHandler) Get(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	settings, err := h.settingsSvc.Get(c.Request.Context(), userID.(int64))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting settings", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+		"models":   h.settingsSvc.AvailableModels(),
+		"personas": services.Personas(),
+	})
+}
+
+// Update меняет модель и/или персону пользователя
+func (h *SettingsHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.SettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settingsSvc.Update(c.Request.Context(), userID.(int64), req)
+	if errors.Is(err, services.ErrUnknownModel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Model is not available"})
+		return
+	}
+	if errors.Is(err, services.ErrUnknownPersona) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown persona"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error updating settings", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// ResetContext начинает новый диалог. История сохраняется,
+// но предыдущие сообщения больше не передаются модели.
+func (h *SettingsHandler) ResetContext(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.settingsSvc.ResetContext(c.Request.Context(), userID.(int64)); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error resetting context", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset context"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetGroup возвращает настройки бота в группе вместе с доступными моделями и персонами.
+// Права администратора группы проверяет бот.
+func (h *SettingsHandler) GetGroup(c *gin.Context) {
+	chatID, ok := groupChatID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.settingsSvc.GetGroup(chatID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting group settings", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+		"models":   h.settingsSvc.AvailableModels(),
+		"personas": services.Personas(),
+	})
+}
+
+// UpdateGroup включает или выключает бота в группе, меняет модель и/или персону
+func (h *SettingsHandler) UpdateGroup(c *gin.Context) {
+	chatID, ok := groupChatID(c)
+	if !ok {
+		return
+	}
+
+	var req models.GroupSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settingsSvc.UpdateGroup(chatID, req)
+	if errors.Is(err, services.ErrUnknownModel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Model is not available"})
+		return
+	}
+	if errors.Is(err, services.ErrUnknownPersona) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown persona"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error updating group settings", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// ResetGroupContext начинает новый диалог в группе (во всех темах форума)
+func (h *SettingsHandler) ResetGroupContext(c *gin.Context) {
+	chatID, ok := groupChatID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.settingsSvc.ResetGroupContext(chatID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error resetting group context", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset context"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// groupChatID разбирает идентификатор группы из пути
+func groupChatID(c *gin.Context) (int64, bool) {
+	chatID, err := strconv.ParseInt(c.Param("chat_id"), 10, 64)
+	if err != nil || chatID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
+		return 0, false
+	}
+	return chatID, true
+}
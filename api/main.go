@@ -1,15 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"time"
 
 	"telegram-api/config"
 	"telegram-api/handlers"
+	"telegram-api/health"
+	"telegram-api/logging"
+	"telegram-api/metrics"
 	"telegram-api/middleware"
 	"telegram-api/models"
+	"telegram-api/ratelimit"
 	"telegram-api/services"
+	"telegram-api/tracing"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
@@ -24,29 +34,155 @@ func main() {
 		log.Fatalf("Configuration error: %v", err)
 	}
 
+	// Настраиваем логирование
+	if err := logging.Setup(logging.Config{
+		Level:           cfg.LogLevel,
+		Format:          cfg.LogFormat,
+		RedactContent:   cfg.LogRedactContent,
+		RedactUsernames: cfg.LogRedactUsernames,
+	}); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
+	// Настраиваем трассировку
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		Endpoint:    cfg.OTLPEndpoint,
+		Headers:     cfg.OTLPHeaders,
+		ServiceName: cfg.TracingService,
+		SampleRatio: cfg.TracingSampleRate,
+	})
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Error flushing traces", "error", err)
+		}
+	}()
+
 	// Инициализируем базу данных
 	db, err := initDatabase(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
 	// Инициализируем сервисы
 	messageRepo := models.NewMessageRepository(db)
 	openRouterSvc := services.NewOpenRouterService(cfg.OpenRouterAPIKey, cfg.OpenRouterURL, cfg.AIModel)
-	telegramAuthSvc := services.NewTelegramAuthService(cfg.TelegramBotToken)
+	telegramAuthSvc := services.NewTelegramAuthService(
+		cfg.TelegramBotToken,
+		time.Duration(cfg.TelegramAuthMaxAge)*time.Second,
+		cfg.TelegramTestEnvironment,
+	)
+	signingKeys, err := sessionSigningKeys(cfg)
+	if err != nil {
+		slog.Error("Invalid SESSION_SIGNING_KEYS", "error", err)
+		os.Exit(1)
+	}
+	sessionSvc := services.NewSessionService(
+		models.NewSessionRepository(db),
+		signingKeys,
+		time.Duration(cfg.SessionAccessTTL)*time.Second,
+		time.Duration(cfg.SessionRefreshTTL)*time.Second,
+	)
+	apiKeySvc := services.NewAPIKeyService(models.NewAPIKeyRepository(db))
+	documentSvc := services.NewDocumentService(models.NewDocumentRepository(db))
+	knowledgeSvc := services.NewKnowledgeService(
+		models.NewKnowledgeRepository(db),
+		newEmbeddingProvider(cfg),
+		cfg.KnowledgeTopK,
+		cfg.KnowledgeMinScore,
+	)
+	memorySvc := services.NewMemoryService(models.NewMemoryRepository(db))
+	settingsSvc := services.NewSettingsService(
+		models.NewSettingsRepository(db),
+		models.NewGroupRepository(db),
+		cfg.AvailableModels,
+		cfg.AIModel,
+	)
+	accountRepo := models.NewAccountRepository(db)
+	quotaSvc := services.NewQuotaService(accountRepo, messageRepo)
+	banRepo := models.NewBanRepository(db)
+	adminSvc := services.NewAdminService(
+		models.NewUserRepository(db),
+		accountRepo,
+		banRepo,
+		messageRepo,
+		quotaSvc,
+	)
+	contextBuilder := services.NewContextBuilder(messageRepo, documentSvc, knowledgeSvc, memorySvc, settingsSvc)
+
+	// Инструменты ассистента (модель должна поддерживать tool calling)
+	var toolRegistry *services.ToolRegistry
+	if cfg.AIToolsEnabled {
+		toolRegistry = services.NewToolRegistry()
+		services.RegisterBuiltinTools(toolRegistry, messageRepo)
+		services.RegisterMemoryTools(toolRegistry, memorySvc)
+	}
 
 	// Инициализируем обработчики
-	chatHandler := handlers.NewChatHandler(messageRepo, openRouterSvc, telegramAuthSvc)
+	chatHandler := handlers.NewChatHandler(
+		messageRepo,
+		openRouterSvc,
+		telegramAuthSvc,
+		contextBuilder,
+		settingsSvc,
+		quotaSvc,
+		memorySvc,
+		toolRegistry,
+		newTranscriber(cfg),
+		handlers.ChatTimeouts{
+			Database:   time.Duration(cfg.ChatDatabaseTimeout) * time.Second,
+			Transcribe: time.Duration(cfg.ChatTranscribeTimeout) * time.Second,
+			Context:    time.Duration(cfg.ChatContextTimeout) * time.Second,
+			Model:      time.Duration(cfg.ChatModelTimeout) * time.Second,
+		},
+	)
+	documentHandler := handlers.NewDocumentHandler(documentSvc)
+	knowledgeHandler := handlers.NewKnowledgeHandler(knowledgeSvc)
+	memoryHandler := handlers.NewMemoryHandler(memorySvc)
+	settingsHandler := handlers.NewSettingsHandler(settingsSvc)
+	feedbackHandler := handlers.NewFeedbackHandler(services.NewFeedbackService(models.NewFeedbackRepository(db), messageRepo))
+	adminHandler := handlers.NewAdminHandler(adminSvc)
+	broadcastHandler := handlers.NewBroadcastHandler(services.NewBroadcastService(models.NewBroadcastRepository(db)))
+	authHandler := handlers.NewAuthHandler(telegramAuthSvc, sessionSvc)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc)
 
 	// Настраиваем Gin
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 
+	// Адрес клиента берется из X-Forwarded-For, только если запрос пришел от доверенного прокси
+	// (nginx), иначе ограничение по IP можно обойти подменой заголовка
+	trustedProxies := cfg.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = privateNetworks
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
+	// Ограничение частоты запросов; формат лимитов проверен в cfg.Validate
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitBackend == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(db)
+	}
+	ipLimit, _ := ratelimit.ParseLimit(cfg.RateLimitIP)
+	userLimit, _ := ratelimit.ParseLimit(cfg.RateLimitUser)
+	routeLimits, _ := ratelimit.ParseRoutes(cfg.RateLimitRoutes)
+
 	// Middleware
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.TracingMiddleware())
 	r.Use(middleware.CORSMiddleware())
 	r.Use(middleware.LoggingMiddleware())
-	r.Use(gin.Recovery())
+	r.Use(middleware.MetricsMiddleware())
+	r.Use(middleware.RecoveryMiddleware())
 
 	// Публичные маршруты
 	r.GET("/health", func(c *gin.Context) {
@@ -56,22 +192,153 @@ func main() {
 		})
 	})
 
+	// Проверки для docker; nginx не проксирует /livez и /readyz наружу
+	readiness := health.NewChecker()
+	readiness.Add("database", 2*time.Second, health.Ping(db))
+	readiness.Add("migrations", 2*time.Second, health.Schema(db, schemaMarkers...))
+	readiness.Add("openrouter", 5*time.Second, health.Cached(
+		time.Duration(cfg.ModelCheckInterval)*time.Second,
+		openRouterSvc.Ping,
+	))
+	readiness.Add("openrouter_circuit", time.Second, openRouterSvc.CheckCircuit)
+	r.GET("/livez", gin.WrapH(health.Live()))
+	r.GET("/readyz", gin.WrapH(readiness.Handler()))
+
+	// Метрики Prometheus; nginx не проксирует /metrics наружу
+	metrics.RegisterDBStats(metrics.Default, db)
+	r.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+
+	// Выдача и обновление токенов сессии
+	auth := r.Group("/api/auth")
+	auth.Use(middleware.IPRateLimitMiddleware(rateLimitStore, ipLimit, cfg.ServiceToken))
+	{
+		auth.POST("/session", authHandler.CreateSession)
+		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.Refresh)
+	}
+
 	// Защищенные маршруты
 	api := r.Group("/api")
-	api.Use(middleware.AuthMiddleware(telegramAuthSvc))
+	api.Use(middleware.IPRateLimitMiddleware(rateLimitStore, ipLimit, cfg.ServiceToken))
+	api.Use(middleware.AuthMiddleware(telegramAuthSvc, sessionSvc, apiKeySvc, cfg.ServiceToken, banRepo))
+	api.Use(middleware.APIKeyScopeMiddleware())
+	api.Use(middleware.UserRateLimitMiddleware(rateLimitStore, userLimit, routeLimits))
 	{
+		api.DELETE("/auth/session", authHandler.RevokeSession)
+		api.DELETE("/auth/sessions", authHandler.RevokeAllSessions)
+
+		api.GET("/keys", apiKeyHandler.List)
+		api.POST("/keys", apiKeyHandler.Create)
+		api.DELETE("/keys/:id", apiKeyHandler.Revoke)
+
 		api.POST("/chat", chatHandler.SendMessage)
+		api.POST("/chat/voice", chatHandler.SendVoice)
+		api.POST("/chat/regenerate", chatHandler.Regenerate)
+		api.POST("/chat/continue", chatHandler.Continue)
+		api.POST("/chat/inline", chatHandler.Inline)
 		api.GET("/history", chatHandler.GetHistory)
+		api.POST("/messages/:id/feedback", feedbackHandler.Rate)
+		api.POST("/history/reset", settingsHandler.ResetContext)
 		api.GET("/stats", chatHandler.GetStats)
+
+		api.POST("/documents", documentHandler.Upload)
+		api.GET("/documents", documentHandler.List)
+		api.DELETE("/documents/:id", documentHandler.Delete)
+
+		api.GET("/memory", memoryHandler.List)
+		api.POST("/memory", memoryHandler.Create)
+		api.DELETE("/memory", memoryHandler.Clear)
+		api.DELETE("/memory/:id", memoryHandler.Delete)
+		api.GET("/memory/proposals", memoryHandler.ListProposals)
+		api.POST("/memory/proposals/:id/confirm", memoryHandler.ConfirmProposal)
+		api.DELETE("/memory/proposals/:id", memoryHandler.RejectProposal)
+
+		api.GET("/settings", settingsHandler.Get)
+		api.PATCH("/settings", settingsHandler.Update)
+
+		// Настройки групп меняет бот после проверки прав администратора группы
+		groups := api.Group("/groups/:chat_id")
+		groups.Use(middleware.ServiceOnlyMiddleware())
+		{
+			groups.GET("/settings", settingsHandler.GetGroup)
+			groups.PATCH("/settings", settingsHandler.UpdateGroup)
+			groups.POST("/reset", settingsHandler.ResetGroupContext)
+		}
+	}
+
+	// Маршруты администраторов (Telegram ID из ADMIN_TELEGRAM_IDS)
+	admin := r.Group("/admin")
+	admin.Use(middleware.IPRateLimitMiddleware(rateLimitStore, ipLimit, cfg.ServiceToken))
+	admin.Use(middleware.AuthMiddleware(telegramAuthSvc, sessionSvc, apiKeySvc, cfg.ServiceToken, banRepo))
+	admin.Use(middleware.APIKeyScopeMiddleware())
+	admin.Use(middleware.AdminMiddleware(cfg.AdminUserIDs))
+	admin.Use(middleware.UserRateLimitMiddleware(rateLimitStore, userLimit, routeLimits))
+	{
+		admin.GET("/users", adminHandler.ListUsers)
+		admin.GET("/users/:id", adminHandler.GetUser)
+		admin.PUT("/users/:id/plan", adminHandler.SetPlan)
+		admin.POST("/users/:id/bonus", adminHandler.AddBonus)
+		admin.POST("/users/:id/ban", adminHandler.Ban)
+		admin.DELETE("/users/:id/ban", adminHandler.Unban)
+		admin.GET("/plans", adminHandler.ListPlans)
+
+		admin.POST("/knowledge", knowledgeHandler.Upload)
+		admin.GET("/knowledge", knowledgeHandler.List)
+		admin.GET("/knowledge/search", knowledgeHandler.Search)
+		admin.DELETE("/knowledge/:id", knowledgeHandler.Delete)
+
+		admin.GET("/feedback/report", feedbackHandler.Report)
+
+		admin.POST("/broadcasts", broadcastHandler.Create)
+		admin.GET("/broadcasts", broadcastHandler.List)
+		admin.GET("/broadcasts/:id", broadcastHandler.Get)
+		admin.POST("/broadcasts/:id/pause", broadcastHandler.Pause)
+		admin.POST("/broadcasts/:id/resume", broadcastHandler.Resume)
+		admin.POST("/broadcasts/:id/cancel", broadcastHandler.Cancel)
 	}
 
 	// Запускаем сервер
-	log.Printf("Starting API server on port %s", cfg.APIPort)
+	slog.Info("Starting API server", "port", cfg.APIPort)
 	if err := r.Run(":" + cfg.APIPort); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		slog.Error("Failed to start server", "error", err)
+		os.Exit(1)
 	}
 }
 
+// schemaMarkers по одной колонке из каждого скрипта db-init: по ним /readyz
+// проверяет, что все скрипты применены
+var schemaMarkers = []string{
+	"users.user_id",                  // 01-init.sql
+	"messages.content",               // 02-messages.sql
+	"document_chunks.document_id",    // 03-documents.sql
+	"kb_chunks.embedding_model",      // 04-knowledge-base.sql
+	"user_memories.user_id",          // 05-user-memories.sql
+	"user_settings.context_reset_at", // 06-user-settings.sql
+	"group_settings.enabled",         // 07-group-chats.sql
+	"message_feedback.rating",        // 08-feedback.sql
+	"user_bans.user_id",              // 09-admin.sql
+	"broadcast_deliveries.status",    // 10-broadcasts.sql
+	"users.last_seen_at",             // 11-user-lifecycle.sql
+	"rate_limit_buckets.tokens",      // 12-rate-limits.sql
+	"user_sessions.generation",       // 13-sessions.sql
+	"api_keys.key_hash",              // 14-api-keys.sql
+	"memory_proposals.content",       // 15-memory-proposals.sql
+	"message_regenerations.user_id",  // 16-message-regenerations.sql
+}
+
+// sessionSigningKeys возвращает ключи подписи токенов сессии. Без SESSION_SIGNING_KEYS
+// ключ выводится из токена бота, чтобы сессии переживали перезапуск.
+func sessionSigningKeys(cfg *config.Config) ([]services.SigningKey, error) {
+	if len(cfg.SessionSigningKeys) > 0 {
+		return services.ParseSigningKeys(cfg.SessionSigningKeys)
+	}
+	sum := sha256.Sum256([]byte("session:" + cfg.TelegramBotToken))
+	return []services.SigningKey{{ID: "default", Secret: sum[:]}}, nil
+}
+
+// privateNetworks доверенные прокси по умолчанию: nginx работает в той же сети docker
+var privateNetworks = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
 // initDatabase инициализирует подключение к базе данных
 func initDatabase(cfg *config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
@@ -89,6 +356,26 @@ func initDatabase(cfg *config.Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
 	return db, nil
 }
+
+// newEmbeddingProvider создает провайдер эмбеддингов согласно конфигурации
+func newEmbeddingProvider(cfg *config.Config) services.EmbeddingProvider {
+	if cfg.EmbeddingsProvider == "openai" {
+		return services.NewOpenAIEmbeddingProvider(cfg.EmbeddingsAPIKey, cfg.EmbeddingsURL, cfg.EmbeddingsModel)
+	}
+	return services.NewHashEmbeddingProvider(512)
+}
+
+// newTranscriber создает распознаватель речи согласно конфигурации (nil, если отключен)
+func newTranscriber(cfg *config.Config) services.Transcriber {
+	switch cfg.STTProvider {
+	case "openai":
+		return services.NewOpenAITranscriber(cfg.STTAPIKey, cfg.STTURL, cfg.STTModel, cfg.STTLanguage)
+	case "whisper":
+		return services.NewWhisperServerTranscriber(cfg.STTURL, cfg.STTLanguage)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// Метрики API сервиса
+var (
+	// HTTPRequestDuration длительность обработки HTTP запросов по маршрутам
+	HTTPRequestDuration = Default.NewHistogramVec(
+		"http_request_duration_seconds",
+		"HTTP request latency by route and status.",
+		DefaultBuckets,
+		"method", "route", "status",
+	)
+
+	// ModelRequestDuration длительность запросов к OpenRouter по моделям
+	ModelRequestDuration = Default.NewHistogramVec(
+		"openrouter_request_duration_seconds",
+		"OpenRouter chat completion latency by model.",
+		DefaultBuckets,
+		"model",
+	)
+
+	// ModelErrors ошибки запросов к OpenRouter: reason — HTTP статус или тип ошибки
+	ModelErrors = Default.NewCounterVec(
+		"openrouter_errors_total",
+		"Failed OpenRouter requests by model and reason.",
+		"model", "reason",
+	)
+
+	// ModelTokens израсходованные токены по моделям (type: prompt или completion)
+	ModelTokens = Default.NewCounterVec(
+		"openrouter_tokens_total",
+		"Tokens used by OpenRouter requests by model and type.",
+		"model", "type",
+	)
+
+	// QuotaRejections запросы, отклоненные из-за дневного лимита, по тарифам
+	QuotaRejections = Default.NewCounterVec(
+		"quota_rejections_total",
+		"Requests rejected because the daily message limit was reached.",
+		"plan",
+	)
+
+	// RateLimitRejections запросы, отклоненные ограничением частоты (scope: ip или user)
+	RateLimitRejections = Default.NewCounterVec(
+		"rate_limit_rejections_total",
+		"Requests rejected by the rate limiter by scope.",
+		"scope",
+	)
+)
+
+// RegisterDBStats добавляет метрики пула соединений с БД
+func RegisterDBStats(registry *Registry, db *sql.DB) {
+	registry.NewGaugeFunc("db_open_connections", "Established database connections.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	registry.NewGaugeFunc("db_in_use_connections", "Database connections currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	registry.NewGaugeFunc("db_idle_connections", "Idle database connections.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	registry.NewGaugeFunc("db_max_open_connections", "Maximum number of open database connections.", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	registry.NewCounterFunc("db_wait_count_total", "Connections waited for because the pool was exhausted.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	registry.NewCounterFunc("db_wait_duration_seconds_total", "Total time spent waiting for a database connection.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
@@ -1,18 +1,48 @@
 package middleware
 
 import (
-	"fmt"
-	"log"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
+	"telegram-api/models"
 	"telegram-api/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware middleware для аутентификации через Telegram WebApp
-func AuthMiddleware(telegramAuth *services.TelegramAuthService) gin.HandlerFunc {
+// AuthMiddleware middleware для аутентификации через Telegram WebApp: по токену сессии
+// или персональному API ключу (Authorization: Bearer) или по initData. Запросы от Telegram
+// бота аутентифицируются по сервисному токену. Заблокированные пользователи получают 403
+// с причиной и сроком блокировки.
+func AuthMiddleware(telegramAuth *services.TelegramAuthService, sessions *services.SessionService, apiKeys *services.APIKeyService, serviceToken string, banRepo models.BanRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Запрос от Telegram бота от имени пользователя
+		if token := c.GetHeader("X-Service-Token"); token != "" {
+			if handleServiceAuth(c, token, serviceToken) {
+				rejectBanned(c, banRepo)
+			}
+			return
+		}
+
+		// Персональный API ключ или токен сессии, выданный POST /api/auth/session
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			authenticated := false
+			if strings.HasPrefix(token, services.APIKeyPrefix) {
+				authenticated = handleAPIKeyAuth(c, apiKeys, token)
+			} else {
+				authenticated = handleSessionAuth(c, sessions, token)
+			}
+			if authenticated {
+				rejectBanned(c, banRepo)
+			}
+			return
+		}
+
 		// Получаем данные аутентификации из заголовка
 		initData := c.GetHeader("X-Telegram-Init-Data")
 		if initData == "" {
@@ -39,8 +69,147 @@ func AuthMiddleware(telegramAuth *services.TelegramAuthService) gin.HandlerFunc
 		c.Set("username", webAppData.Username)
 		c.Set("first_name", webAppData.FirstName)
 		c.Set("last_name", webAppData.LastName)
+		c.Set("auth_method", "webapp")
+
+		rejectBanned(c, banRepo)
+	}
+}
+
+// handleServiceAuth проверяет сервисный токен и устанавливает пользователя из заголовков
+func handleServiceAuth(c *gin.Context, token, serviceToken string) bool {
+	if !validServiceToken(token, serviceToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+		c.Abort()
+		return false
+	}
+
+	userID, err := strconv.ParseInt(c.GetHeader("X-Telegram-User-ID"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid X-Telegram-User-ID header"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", userID)
+	c.Set("username", c.GetHeader("X-Telegram-Username"))
+	c.Set("first_name", "")
+	c.Set("last_name", "")
+	c.Set("auth_method", "service")
+
+	return true
+}
+
+// handleSessionAuth проверяет токен сессии и устанавливает пользователя сессии
+func handleSessionAuth(c *gin.Context, sessions *services.SessionService, token string) bool {
+	session, err := sessions.Authenticate(c.Request.Context(), token)
+	if errors.Is(err, services.ErrTokenExpired) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session token expired", "code": "token_expired"})
+		c.Abort()
+		return false
+	}
+	if errors.Is(err, services.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session token"})
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", session.UserID)
+	c.Set("username", session.Username)
+	c.Set("first_name", session.FirstName)
+	c.Set("last_name", session.LastName)
+	c.Set("auth_method", "session")
+	c.Set("session_id", session.ID)
+
+	return true
+}
+
+// handleAPIKeyAuth проверяет персональный API ключ и устанавливает его владельца
+func handleAPIKeyAuth(c *gin.Context, apiKeys *services.APIKeyService, token string) bool {
+	key, err := apiKeys.Authenticate(c.Request.Context(), token)
+	if errors.Is(err, services.ErrInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking api key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", key.UserID)
+	c.Set("username", "")
+	c.Set("first_name", "")
+	c.Set("last_name", "")
+	c.Set("auth_method", "api_key")
+	c.Set("api_key_id", key.ID)
+	c.Set("api_key_scopes", key.Scopes)
+
+	return true
+}
+
+// validServiceToken сравнивает токен с сервисным за постоянное время
+func validServiceToken(token, serviceToken string) bool {
+	return serviceToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(serviceToken)) == 1
+}
+
+// rejectBanned отклоняет запросы заблокированного пользователя, остальные пропускает дальше
+func rejectBanned(c *gin.Context, banRepo models.BanRepository) {
+	ban, err := banRepo.GetActive(c.Request.Context(), c.GetInt64("user_id"))
+	if errors.Is(err, models.ErrBanNotFound) {
+		c.Next()
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking ban", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "User is banned",
+		"code":       "banned",
+		"reason":     ban.Reason,
+		"expires_at": ban.ExpiresAt,
+	})
+	c.Abort()
+}
+
+// ServiceOnlyMiddleware пропускает только запросы от Telegram бота; используется после AuthMiddleware
+func ServiceOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != "service" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Service access required"})
+			c.Abort()
+			return
+		}
 
-		log.Printf("Auth middleware: set UserID=%d in context for %s", webAppData.UserID, webAppData.Username)
+		c.Next()
+	}
+}
+
+// AdminMiddleware пропускает только администраторов; используется после AuthMiddleware
+func AdminMiddleware(adminUserIDs []int64) gin.HandlerFunc {
+	admins := make(map[int64]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if id, ok := userID.(int64); !ok || !admins[id] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -51,8 +220,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Telegram-Init-Data, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Telegram-Init-Data, X-Request-ID, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -63,17 +233,42 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
-// LoggingMiddleware middleware для логирования
-func LoggingMiddleware() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("[%s] %s %s %d %s %s %s\n",
-			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
-			param.ClientIP,
-			param.Method,
-			param.StatusCode,
-			param.Latency,
-			param.Path,
-			param.ErrorMessage,
-		)
-	})
+// APIKeyScopeMiddleware проверяет, что область действия API ключа разрешает маршрут;
+// используется после AuthMiddleware. Управление ключами и сессиями и админский API
+// по API ключу недоступны, чтобы утекший ключ нельзя было превратить в новые.
+func APIKeyScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != "api_key" {
+			c.Next()
+			return
+		}
+
+		scope := requiredScope(c)
+		if scope == "" || !slices.Contains(c.GetStringSlice("api_key_scopes"), scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API key is not allowed to access this endpoint",
+				"scope": scope,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requiredScope возвращает область действия, нужную для маршрута, или пустую строку,
+// если маршрут недоступен по API ключу
+func requiredScope(c *gin.Context) string {
+	route := c.FullPath()
+	switch {
+	case strings.HasPrefix(route, "/api/keys"), strings.HasPrefix(route, "/api/auth"), strings.HasPrefix(route, "/admin"):
+		return ""
+	case route == "/api/chat" || strings.HasPrefix(route, "/api/chat/"):
+		return models.APIKeyScopeChat
+	case c.Request.Method == http.MethodGet:
+		return models.APIKeyScopeRead
+	default:
+		return models.APIKeyScopeWrite
+	}
 }
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"telegram-api/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader заголовок с идентификатором запроса
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID ограничивает идентификаторы, принятые от клиента: они попадают в логи
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware назначает запросу идентификатор и возвращает его в X-Request-ID.
+// Идентификатор из заголовка запроса (например, от Telegram бота) сохраняется,
+// чтобы по нему можно было найти в логах обе стороны обработки.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = logging.NewRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// LoggingMiddleware пишет в лог каждый запрос: маршрут, статус, длительность и пользователя
+func LoggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if userID := c.GetInt64("user_id"); userID != 0 {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if errors := c.Errors.String(); errors != "" {
+			attrs = append(attrs, "error", errors)
+		}
+
+		slog.Log(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// RecoveryMiddleware перехватывает панику в обработчике, пишет ее в лог со стеком
+// и отвечает 500
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "Panic while handling request",
+			"error", recovered, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	})
+}
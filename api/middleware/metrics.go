@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"telegram-api/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware записывает длительность и статус запросов по шаблонам маршрутов,
+// чтобы идентификаторы в путях не создавали отдельных серий
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.Observe(
+			time.Since(start).Seconds(),
+			c.Request.Method,
+			route,
+			strconv.Itoa(c.Writer.Status()),
+		)
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"telegram-api/metrics"
+	"telegram-api/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPRateLimitMiddleware ограничивает частоту запросов с одного IP адреса; ставится до
+// аутентификации, чтобы перебор initData тоже ограничивался. Запросы Telegram бота
+// с верным сервисным токеном не ограничиваются: все пользователи бота приходят с его адреса,
+// их ограничивает UserRateLimitMiddleware.
+func IPRateLimitMiddleware(store ratelimit.Store, limit ratelimit.Limit, serviceToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit.Disabled() || validServiceToken(c.GetHeader("X-Service-Token"), serviceToken) {
+			c.Next()
+			return
+		}
+
+		if takeToken(c, store, "ip:"+c.ClientIP(), limit, "ip") {
+			c.Next()
+		}
+	}
+}
+
+// UserRateLimitMiddleware ограничивает частоту запросов пользователя; используется после
+// AuthMiddleware. Маршруты из routes (ключ — "METHOD /path/:param") расходуют отдельную
+// корзину со своим лимитом, остальные — общую корзину с лимитом limit.
+func UserRateLimitMiddleware(store ratelimit.Store, limit ratelimit.Limit, routes map[string]ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "user:" + strconv.FormatInt(c.GetInt64("user_id"), 10)
+		userLimit := limit
+
+		route := c.Request.Method + " " + c.FullPath()
+		if routeLimit, ok := routes[route]; ok {
+			key += ":" + route
+			userLimit = routeLimit
+		}
+
+		if userLimit.Disabled() || takeToken(c, store, key, userLimit, "user") {
+			c.Next()
+		}
+	}
+}
+
+// takeToken берет токен из корзины key и выставляет заголовки X-RateLimit-*.
+// Если токенов нет, отвечает 429 с Retry-After и возвращает false. При недоступности
+// хранилища запрос пропускается: отказ лимитера не должен останавливать сервис.
+func takeToken(c *gin.Context, store ratelimit.Store, key string, limit ratelimit.Limit, scope string) bool {
+	result, err := store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error checking rate limit", "scope", scope, "error", err)
+		return true
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	metrics.RateLimitRejections.Inc(scope)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many requests",
+		"code":        "rate_limited",
+		"retry_after": retryAfter,
+	})
+	c.Abort()
+	return false
+}
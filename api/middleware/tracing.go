@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"telegram-api/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TracingMiddleware создает серверный спан на каждый запрос. Если клиент передал
+// traceparent (например, Telegram бот), спан продолжает его трассу.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.Start(ctx, name, tracing.KindServer,
+			tracing.String("http.request.method", c.Request.Method),
+			tracing.String("http.route", route),
+			tracing.String("url.path", c.Request.URL.Path),
+			tracing.String("client.address", c.ClientIP()),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Int("http.response.status_code", status))
+		if userID := c.GetInt64("user_id"); userID != 0 {
+			span.SetAttributes(tracing.Int64("enduser.id", userID))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	}
+}
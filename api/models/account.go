@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultPlan тариф пользователей, которым тариф не назначен
+const DefaultPlan = "free"
+
+// ErrNoBonusMessages возвращается, если у пользователя не осталось бонусных сообщений
+var ErrNoBonusMessages = errors.New("no bonus messages left")
+
+// Account представляет тариф и бонусные сообщения пользователя
+type Account struct {
+	UserID        int64     `json:"user_id" db:"user_id"`
+	Plan          string    `json:"plan" db:"plan"`
+	BonusMessages int       `json:"bonus_messages" db:"bonus_messages"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Usage представляет использование сообщений пользователем за сегодня
+type Usage struct {
+	Plan          string `json:"plan"`
+	DailyMessages int    `json:"daily_messages"`
+	DailyLimit    int    `json:"daily_limit"`
+	BonusMessages int    `json:"bonus_messages"`
+	Remaining     int    `json:"remaining"` // с учетом бонусных сообщений
+}
+
+// DailyCount число сообщений пользователя за день
+type DailyCount struct {
+	Date     time.Time `json:"date"`
+	Messages int       `json:"messages"`
+}
+
+// PlanRequest представляет запрос на смену тарифа
+type PlanRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+// BonusRequest представляет запрос на начисление бонусных сообщений
+type BonusRequest struct {
+	Messages int `json:"messages" binding:"required,min=1,max=100000"`
+}
+
+// AccountRepository интерфейс для работы с тарифами пользователей
+type AccountRepository interface {
+	Get(ctx context.Context, userID int64) (*Account, error)
+	SetPlan(ctx context.Context, userID int64, plan string) (*Account, error)
+	AddBonus(ctx context.Context, userID int64, messages int) (*Account, error)
+	UseBonus(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// AccountRepositoryImpl реализует интерфейс AccountRepository
+type AccountRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewAccountRepository создает новый репозиторий тарифов
+func NewAccountRepository(db *sql.DB) AccountRepository {
+	return &AccountRepositoryImpl{db: db}
+}
+
+// Get получает тариф пользователя; без сохраненной записи — бесплатный тариф
+func (r *AccountRepositoryImpl) Get(ctx context.Context, userID int64) (*Account, error) {
+	account := &Account{UserID: userID, Plan: DefaultPlan}
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT plan, bonus_messages, updated_at FROM user_accounts WHERE user_id = $1`,
+		userID,
+	).Scan(&account.Plan, &account.BonusMessages, &account.UpdatedAt)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return account, nil
+}
+
+// SetPlan меняет тариф пользователя
+func (r *AccountRepositoryImpl) SetPlan(ctx context.Context, userID int64, plan string) (*Account, error) {
+	query := `
+		INSERT INTO user_accounts (user_id, plan, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET plan = EXCLUDED.plan, updated_at = EXCLUDED.updated_at
+		RETURNING user_id, plan, bonus_messages, updated_at
+	`
+
+	account, err := r.scan(r.db.QueryRowContext(ctx, query, userID, plan))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set plan: %w", err)
+	}
+
+	return account, nil
+}
+
+// AddBonus начисляет пользователю бонусные сообщения
+func (r *AccountRepositoryImpl) AddBonus(ctx context.Context, userID int64, messages int) (*Account, error) {
+	query := `
+		INSERT INTO user_accounts (user_id, plan, bonus_messages, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			bonus_messages = user_accounts.bonus_messages + EXCLUDED.bonus_messages,
+			updated_at = EXCLUDED.updated_at
+		RETURNING user_id, plan, bonus_messages, updated_at
+	`
+
+	account, err := r.scan(r.db.QueryRowContext(ctx, query, userID, DefaultPlan, messages))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add bonus messages: %w", err)
+	}
+
+	return account, nil
+}
+
+// UseBonus списывает одно бонусное сообщение
+func (r *AccountRepositoryImpl) UseBonus(ctx context.Context, userID int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE user_accounts SET bonus_messages = bonus_messages - 1 WHERE user_id = $1 AND bonus_messages > 0`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to use bonus message: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to use bonus message: %w", err)
+	}
+	if affected == 0 {
+		return ErrNoBonusMessages
+	}
+
+	return nil
+}
+
+func (r *AccountRepositoryImpl) scan(row *sql.Row) (*Account, error) {
+	account := &Account{}
+	if err := row.Scan(&account.UserID, &account.Plan, &account.BonusMessages, &account.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
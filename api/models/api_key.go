@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAPIKeyNotFound возвращается, если ключа нет у пользователя или он отозван
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// Области действия API ключей
+const (
+	APIKeyScopeRead  = "read"  // GET запросы: история, статистика, настройки, документы
+	APIKeyScopeChat  = "chat"  // сообщения ассистенту: /api/chat и вложенные маршруты
+	APIKeyScopeWrite = "write" // остальные изменения: настройки, память, документы, оценки
+)
+
+// APIKeyScopes все области действия в порядке вывода
+var APIKeyScopes = []string{APIKeyScopeRead, APIKeyScopeChat, APIKeyScopeWrite}
+
+// APIKey представляет персональный API ключ пользователя
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // начало ключа, по которому его можно узнать
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// APIKeyRequest представляет запрос на создание ключа; без scopes ключ получает read и chat
+type APIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyCreated созданный ключ; Key возвращается только в ответе на создание
+type APIKeyCreated struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRepository интерфейс для работы с API ключами
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListByUser(ctx context.Context, userID int64) ([]*APIKey, error)
+	Revoke(ctx context.Context, userID, keyID int64) error
+	Touch(ctx context.Context, keyID int64) error
+}
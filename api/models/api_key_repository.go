@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyRepositoryImpl реализует интерфейс APIKeyRepository
+type APIKeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository создает новый репозиторий API ключей
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+// Create сохраняет новый ключ
+func (r *APIKeyRepositoryImpl) Create(ctx context.Context, key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		key.CreatedAt,
+	).Scan(&key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash получает действующий ключ по хешу
+func (r *APIKeyRepositoryImpl) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	keys, err := r.query(ctx, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, keyHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return keys[0], nil
+}
+
+// ListByUser получает действующие ключи пользователя (от новых к старым)
+func (r *APIKeyRepositoryImpl) ListByUser(ctx context.Context, userID int64) ([]*APIKey, error) {
+	keys, err := r.query(ctx, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, created_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke отзывает ключ пользователя
+func (r *APIKeyRepositoryImpl) Revoke(ctx context.Context, userID, keyID int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, keyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Touch отмечает время последнего использования ключа
+func (r *APIKeyRepositoryImpl) Touch(ctx context.Context, keyID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+
+	return nil
+}
+
+// query выполняет запрос и читает ключи в порядке выборки
+func (r *APIKeyRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+	for rows.Next() {
+		key := &APIKey{}
+		err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			pq.Array(&key.Scopes),
+			&key.LastUsedAt,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBanNotFound возвращается, если у пользователя нет действующей блокировки
+var ErrBanNotFound = errors.New("ban not found")
+
+// Ban представляет блокировку пользователя администратором
+type Ban struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Reason    string     `json:"reason" db:"reason"`
+	AdminID   int64      `json:"admin_id" db:"admin_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"` // nil — бессрочно
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy *int64     `json:"revoked_by,omitempty" db:"revoked_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// BanRequest представляет запрос на блокировку пользователя
+type BanRequest struct {
+	Reason        string `json:"reason" binding:"max=500"`
+	DurationHours int    `json:"duration_hours" binding:"min=0,max=87600"` // 0 — бессрочно, не больше 10 лет
+}
+
+// BanRepository интерфейс для работы с блокировками
+type BanRepository interface {
+	Create(ctx context.Context, ban *Ban) error
+	GetActive(ctx context.Context, userID int64) (*Ban, error)
+	Revoke(ctx context.Context, userID, adminID int64) error
+	List(ctx context.Context, userID int64) ([]*Ban, error)
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// activeBanCondition отбирает действующие блокировки
+const activeBanCondition = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+
+// BanRepositoryImpl реализует интерфейс BanRepository
+type BanRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewBanRepository создает новый репозиторий блокировок
+func NewBanRepository(db *sql.DB) BanRepository {
+	return &BanRepositoryImpl{db: db}
+}
+
+// Create сохраняет блокировку пользователя
+func (r *BanRepositoryImpl) Create(ctx context.Context, ban *Ban) error {
+	query := `
+		INSERT INTO user_bans (user_id, reason, admin_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		ban.UserID,
+		ban.Reason,
+		ban.AdminID,
+		ban.ExpiresAt,
+		ban.CreatedAt,
+	).Scan(&ban.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to save ban: %w", err)
+	}
+
+	return nil
+}
+
+// GetActive получает действующую блокировку пользователя
+func (r *BanRepositoryImpl) GetActive(ctx context.Context, userID int64) (*Ban, error) {
+	bans, err := r.query(ctx, `
+		SELECT id, user_id, reason, admin_id, expires_at, revoked_at, revoked_by, created_at
+		FROM user_bans
+		WHERE user_id = $1 AND `+activeBanCondition+`
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ban: %w", err)
+	}
+	if len(bans) == 0 {
+		return nil, ErrBanNotFound
+	}
+
+	return bans[0], nil
+}
+
+// Revoke досрочно снимает все действующие блокировки пользователя
+func (r *BanRepositoryImpl) Revoke(ctx context.Context, userID, adminID int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_bans SET revoked_at = CURRENT_TIMESTAMP, revoked_by = $2
+		WHERE user_id = $1 AND `+activeBanCondition,
+		userID, adminID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke ban: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke ban: %w", err)
+	}
+	if affected == 0 {
+		return ErrBanNotFound
+	}
+
+	return nil
+}
+
+// List получает историю блокировок пользователя (от новых к старым)
+func (r *BanRepositoryImpl) List(ctx context.Context, userID int64) ([]*Ban, error) {
+	bans, err := r.query(ctx, `
+		SELECT id, user_id, reason, admin_id, expires_at, revoked_at, revoked_by, created_at
+		FROM user_bans
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// query выполняет запрос и читает блокировки в порядке выборки
+func (r *BanRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*Ban, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []*Ban
+	for rows.Next() {
+		ban := &Ban{}
+		err := rows.Scan(
+			&ban.ID,
+			&ban.UserID,
+			&ban.Reason,
+			&ban.AdminID,
+			&ban.ExpiresAt,
+			&ban.RevokedAt,
+			&ban.RevokedBy,
+			&ban.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		bans = append(bans, ban)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bans: %w", err)
+	}
+
+	return bans, nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestBanRequestDurationBounds(t *testing.T) {
+	tests := []struct {
+		hours int
+		valid bool
+	}{
+		{hours: -1, valid: false},
+		{hours: 0, valid: true},
+		{hours: 87600, valid: true},
+		{hours: 87601, valid: false},
+	}
+
+	for _, tt := range tests {
+		err := binding.Validator.ValidateStruct(BanRequest{DurationHours: tt.hours})
+		if (err == nil) != tt.valid {
+			t.Errorf("DurationHours = %d: valid = %v, want %v (err: %v)", tt.hours, err == nil, tt.valid, err)
+		}
+	}
+}
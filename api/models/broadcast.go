@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Статусы рассылки
+const (
+	BroadcastRunning   = "running"
+	BroadcastPaused    = "paused"
+	BroadcastCompleted = "completed"
+	BroadcastCancelled = "cancelled"
+)
+
+// Ошибки рассылок
+var (
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+	ErrBroadcastState    = errors.New("broadcast status does not allow this action")
+)
+
+// BroadcastSegment фильтр получателей рассылки; пустые поля не ограничивают выборку
+type BroadcastSegment struct {
+	Plan       string `json:"plan,omitempty"`                        // тариф
+	ActiveDays int    `json:"active_days,omitempty" binding:"min=0"` // обращались к боту за последние N дней
+	Language   string `json:"language,omitempty" binding:"max=16"`   // язык интерфейса Telegram, например ru
+	Premium    *bool  `json:"premium,omitempty"`                     // только пользователи с Telegram Premium или без него
+}
+
+// BroadcastStats статусы доставки рассылки
+type BroadcastStats struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Blocked int `json:"blocked"` // пользователь заблокировал бота
+}
+
+// Broadcast представляет рассылку сообщения пользователям бота
+type Broadcast struct {
+	ID         int64            `json:"id" db:"id"`
+	Text       string           `json:"text" db:"text"`
+	ButtonText string           `json:"button_text,omitempty" db:"button_text"`
+	ButtonURL  string           `json:"button_url,omitempty" db:"button_url"`
+	Segment    BroadcastSegment `json:"segment" db:"segment"`
+	Status     string           `json:"status" db:"status"`
+	AdminID    int64            `json:"admin_id" db:"admin_id"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty" db:"finished_at"`
+	Stats      BroadcastStats   `json:"stats"`
+}
+
+// BroadcastRequest представляет запрос на создание рассылки. Текст отправляется
+// с HTML разметкой Telegram; кнопка добавляется, если заданы и текст, и ссылка.
+type BroadcastRequest struct {
+	Text       string           `json:"text" binding:"required,max=4096"`
+	ButtonText string           `json:"button_text" binding:"max=64,required_with=ButtonURL"`
+	ButtonURL  string           `json:"button_url" binding:"omitempty,url,max=2048,required_with=ButtonText"`
+	Segment    BroadcastSegment `json:"segment"`
+}
+
+// BroadcastRepository интерфейс для работы с рассылками
+type BroadcastRepository interface {
+	Create(broadcast *Broadcast) error
+	Get(id int64) (*Broadcast, error)
+	List(limit int) ([]*Broadcast, error)
+	SetStatus(id int64, status string, from ...string) error
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// broadcastSelect выбирает рассылки вместе со статистикой доставки
+const broadcastSelect = `
+	SELECT
+		b.id, b.text, b.button_text, b.button_url, b.segment, b.status, b.admin_id, b.created_at, b.finished_at,
+		COUNT(d.user_id),
+		COUNT(*) FILTER (WHERE d.status = 'pending'),
+		COUNT(*) FILTER (WHERE d.status = 'sent'),
+		COUNT(*) FILTER (WHERE d.status = 'failed'),
+		COUNT(*) FILTER (WHERE d.status = 'blocked')
+	FROM broadcasts b
+	LEFT JOIN broadcast_deliveries d ON d.broadcast_id = b.id
+`
+
+// BroadcastRepositoryImpl реализует интерфейс BroadcastRepository
+type BroadcastRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewBroadcastRepository создает новый репозиторий рассылок
+func NewBroadcastRepository(db *sql.DB) BroadcastRepository {
+	return &BroadcastRepositoryImpl{db: db}
+}
+
+// Create сохраняет рассылку и ставит в очередь доставку всем подходящим под сегмент
+// пользователям, кроме заблокировавших бота и заблокированных администраторами.
+// Рассылка без получателей сразу считается завершенной.
+func (r *BroadcastRepositoryImpl) Create(broadcast *Broadcast) error {
+	segment, err := json.Marshal(broadcast.Segment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal segment: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO broadcasts (text, button_text, button_url, segment, status, admin_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(
+		query,
+		broadcast.Text,
+		broadcast.ButtonText,
+		broadcast.ButtonURL,
+		segment,
+		BroadcastRunning,
+		broadcast.AdminID,
+		broadcast.CreatedAt,
+	).Scan(&broadcast.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save broadcast: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO broadcast_deliveries (broadcast_id, user_id)
+		SELECT $1, u.user_id
+		FROM users u
+		LEFT JOIN user_accounts a ON a.user_id = u.user_id
+		WHERE u.blocked_at IS NULL
+		AND ($2 = '' OR COALESCE(a.plan, '`+DefaultPlan+`') = $2)
+		AND ($3 = 0 OR u.last_seen_at >= CURRENT_TIMESTAMP - $3 * INTERVAL '1 day')
+		AND ($4 = '' OR u.language_code = $4 OR u.language_code LIKE $4 || '-%')
+		AND ($5::BOOLEAN IS NULL OR u.is_premium = $5)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_bans b WHERE b.user_id = u.user_id AND `+activeBanCondition+`
+		)
+	`, broadcast.ID, broadcast.Segment.Plan, broadcast.Segment.ActiveDays,
+		strings.ToLower(broadcast.Segment.Language), broadcast.Segment.Premium)
+	if err != nil {
+		return fmt.Errorf("failed to queue broadcast deliveries: %w", err)
+	}
+
+	recipients, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to queue broadcast deliveries: %w", err)
+	}
+
+	broadcast.Status = BroadcastRunning
+	if recipients == 0 {
+		now := time.Now()
+		if _, err := tx.Exec(
+			`UPDATE broadcasts SET status = $1, finished_at = $2 WHERE id = $3`,
+			BroadcastCompleted, now, broadcast.ID,
+		); err != nil {
+			return fmt.Errorf("failed to complete broadcast: %w", err)
+		}
+		broadcast.Status = BroadcastCompleted
+		broadcast.FinishedAt = &now
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit broadcast: %w", err)
+	}
+
+	broadcast.Stats = BroadcastStats{Total: int(recipients), Pending: int(recipients)}
+	return nil
+}
+
+// Get получает рассылку со статистикой доставки
+func (r *BroadcastRepositoryImpl) Get(id int64) (*Broadcast, error) {
+	broadcasts, err := r.query(broadcastSelect+`WHERE b.id = $1 GROUP BY b.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+	if len(broadcasts) == 0 {
+		return nil, ErrBroadcastNotFound
+	}
+
+	return broadcasts[0], nil
+}
+
+// List получает последние рассылки (от новых к старым)
+func (r *BroadcastRepositoryImpl) List(limit int) ([]*Broadcast, error) {
+	broadcasts, err := r.query(broadcastSelect+`GROUP BY b.id ORDER BY b.id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list broadcasts: %w", err)
+	}
+
+	return broadcasts, nil
+}
+
+// SetStatus переводит рассылку в статус status, если ее текущий статус входит в from
+func (r *BroadcastRepositoryImpl) SetStatus(id int64, status string, from ...string) error {
+	var finishedAt *time.Time
+	if status == BroadcastCompleted || status == BroadcastCancelled {
+		now := time.Now()
+		finishedAt = &now
+	}
+
+	result, err := r.db.Exec(
+		`UPDATE broadcasts SET status = $1, finished_at = $2 WHERE id = $3 AND status = ANY($4)`,
+		status, finishedAt, id, pq.Array(from),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast: %w", err)
+	}
+	if affected == 0 {
+		return ErrBroadcastState
+	}
+
+	return nil
+}
+
+// query выполняет запрос и читает рассылки в порядке выборки
+func (r *BroadcastRepositoryImpl) query(query string, args ...interface{}) ([]*Broadcast, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var broadcasts []*Broadcast
+	for rows.Next() {
+		broadcast := &Broadcast{}
+		var segment []byte
+		err := rows.Scan(
+			&broadcast.ID,
+			&broadcast.Text,
+			&broadcast.ButtonText,
+			&broadcast.ButtonURL,
+			&segment,
+			&broadcast.Status,
+			&broadcast.AdminID,
+			&broadcast.CreatedAt,
+			&broadcast.FinishedAt,
+			&broadcast.Stats.Total,
+			&broadcast.Stats.Pending,
+			&broadcast.Stats.Sent,
+			&broadcast.Stats.Failed,
+			&broadcast.Stats.Blocked,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan broadcast: %w", err)
+		}
+		if err := json.Unmarshal(segment, &broadcast.Segment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal segment: %w", err)
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broadcasts: %w", err)
+	}
+
+	return broadcasts, nil
+}
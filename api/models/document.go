@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDocumentNotFound возвращается, если документ не найден у пользователя
+var ErrDocumentNotFound = errors.New("document not found")
+
+// Document представляет загруженный пользователем документ
+type Document struct {
+	ID         int64     `json:"id" db:"id"`
+	UserID     int64     `json:"user_id" db:"user_id"`
+	Filename   string    `json:"filename" db:"filename"`
+	MimeType   string    `json:"mime_type" db:"mime_type"`
+	SizeBytes  int64     `json:"size_bytes" db:"size_bytes"`
+	ChunkCount int       `json:"chunk_count" db:"chunk_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// DocumentChunk представляет фрагмент текста документа
+type DocumentChunk struct {
+	ID         int64  `json:"id" db:"id"`
+	DocumentID int64  `json:"document_id" db:"document_id"`
+	ChunkIndex int    `json:"chunk_index" db:"chunk_index"`
+	Content    string `json:"content" db:"content"`
+}
+
+// DocumentRepository интерфейс для работы с документами
+type DocumentRepository interface {
+	Save(document *Document, chunks []*DocumentChunk) error
+	GetByID(userID, documentID int64) (*Document, error)
+	GetByUserID(userID int64, limit int) ([]*Document, error)
+	GetChunks(documentIDs []int64) ([]*DocumentChunk, error)
+	// SearchChunks получает не больше limit фрагментов, содержащих хотя бы одно из слов terms
+	SearchChunks(documentIDs []int64, terms []string, limit int) ([]*DocumentChunk, error)
+	Delete(userID, documentID int64) error
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// DocumentRepositoryImpl реализует интерфейс DocumentRepository
+type DocumentRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewDocumentRepository создает новый репозиторий документов
+func NewDocumentRepository(db *sql.DB) DocumentRepository {
+	return &DocumentRepositoryImpl{db: db}
+}
+
+// Save сохраняет документ и его фрагменты в одной транзакции
+func (r *DocumentRepositoryImpl) Save(document *Document, chunks []*DocumentChunk) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO documents (user_id, filename, mime_type, size_bytes, chunk_count, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(
+		query,
+		document.UserID,
+		document.Filename,
+		document.MimeType,
+		document.SizeBytes,
+		len(chunks),
+		document.CreatedAt,
+	).Scan(&document.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save document: %w", err)
+	}
+	document.ChunkCount = len(chunks)
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO document_chunks (document_id, chunk_index, content)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare chunk insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, chunk := range chunks {
+		chunk.DocumentID = document.ID
+		if err := stmt.QueryRow(chunk.DocumentID, chunk.ChunkIndex, chunk.Content).Scan(&chunk.ID); err != nil {
+			return fmt.Errorf("failed to save document chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit document: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID получает документ пользователя по идентификатору
+func (r *DocumentRepositoryImpl) GetByID(userID, documentID int64) (*Document, error) {
+	query := `
+		SELECT id, user_id, filename, mime_type, size_bytes, chunk_count, created_at
+		FROM documents
+		WHERE id = $1 AND user_id = $2
+	`
+
+	document := &Document{}
+	err := r.db.QueryRow(query, documentID, userID).Scan(
+		&document.ID,
+		&document.UserID,
+		&document.Filename,
+		&document.MimeType,
+		&document.SizeBytes,
+		&document.ChunkCount,
+		&document.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return document, nil
+}
+
+// GetByUserID получает последние документы пользователя
+func (r *DocumentRepositoryImpl) GetByUserID(userID int64, limit int) ([]*Document, error) {
+	query := `
+		SELECT id, user_id, filename, mime_type, size_bytes, chunk_count, created_at
+		FROM documents
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*Document
+	for rows.Next() {
+		document := &Document{}
+		err := rows.Scan(
+			&document.ID,
+			&document.UserID,
+			&document.Filename,
+			&document.MimeType,
+			&document.SizeBytes,
+			&document.ChunkCount,
+			&document.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// GetChunks получает фрагменты указанных документов
+func (r *DocumentRepositoryImpl) GetChunks(documentIDs []int64) ([]*DocumentChunk, error) {
+	if len(documentIDs) == 0 {
+		return nil, nil
+	}
+
+	chunks, err := r.queryChunks(`
+		SELECT id, document_id, chunk_index, content
+		FROM document_chunks
+		WHERE document_id = ANY($1)
+		ORDER BY document_id, chunk_index
+	`, pq.Array(documentIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// SearchChunks получает фрагменты указанных документов, в которых встречается хотя бы
+// одно из слов. При обрезке по limit предпочтение отдается более новым документам.
+func (r *DocumentRepositoryImpl) SearchChunks(documentIDs []int64, terms []string, limit int) ([]*DocumentChunk, error) {
+	if len(documentIDs) == 0 || len(terms) == 0 {
+		return nil, nil
+	}
+
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = "%" + term + "%"
+	}
+
+	chunks, err := r.queryChunks(`
+		SELECT id, document_id, chunk_index, content
+		FROM document_chunks
+		WHERE document_id = ANY($1) AND content ILIKE ANY($2)
+		ORDER BY document_id DESC, chunk_index
+		LIMIT $3
+	`, pq.Array(documentIDs), pq.Array(patterns), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search document chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// queryChunks выполняет запрос и читает фрагменты в порядке выборки
+func (r *DocumentRepositoryImpl) queryChunks(query string, args ...interface{}) ([]*DocumentChunk, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chunks []*DocumentChunk
+	for rows.Next() {
+		chunk := &DocumentChunk{}
+		if err := rows.Scan(&chunk.ID, &chunk.DocumentID, &chunk.ChunkIndex, &chunk.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan document chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating document chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// Delete удаляет документ пользователя вместе с фрагментами
+func (r *DocumentRepositoryImpl) Delete(userID, documentID int64) error {
+	result, err := r.db.Exec(`DELETE FROM documents WHERE id = $1 AND user_id = $2`, documentID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if affected == 0 {
+		return ErrDocumentNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Feedback представляет оценку ответа ассистента пользователем
+type Feedback struct {
+	ID        int64     `json:"id" db:"id"`
+	MessageID int64     `json:"message_id" db:"message_id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Rating    int       `json:"rating" db:"rating"` // 1 — понравился, -1 — не понравился
+	Comment   string    `json:"comment,omitempty" db:"comment"`
+	Model     string    `json:"model" db:"model"`
+	Persona   string    `json:"persona" db:"persona"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FeedbackRequest представляет запрос на оценку ответа
+type FeedbackRequest struct {
+	Rating  int    `json:"rating" binding:"required,oneof=-1 1"`
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// FeedbackReportRow агрегированные оценки модели и персоны за период
+type FeedbackReportRow struct {
+	Period       time.Time `json:"period"`
+	Model        string    `json:"model"`
+	Persona      string    `json:"persona"`
+	Total        int       `json:"total"`
+	Positive     int       `json:"positive"`
+	Negative     int       `json:"negative"`
+	Comments     int       `json:"comments"`
+	Satisfaction float64   `json:"satisfaction"` // доля положительных оценок
+}
+
+// FeedbackRepository интерфейс для работы с оценками ответов
+type FeedbackRepository interface {
+	Save(feedback *Feedback) error
+	Report(from, to time.Time, period string) ([]*FeedbackReportRow, error)
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeedbackRepositoryImpl реализует интерфейс FeedbackRepository
+type FeedbackRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewFeedbackRepository создает новый репозиторий оценок
+func NewFeedbackRepository(db *sql.DB) FeedbackRepository {
+	return &FeedbackRepositoryImpl{db: db}
+}
+
+// Save сохраняет оценку; повторная оценка того же ответа заменяет предыдущую.
+// Пустой комментарий не затирает сохраненный ранее.
+func (r *FeedbackRepositoryImpl) Save(feedback *Feedback) error {
+	query := `
+		INSERT INTO message_feedback (message_id, user_id, rating, comment, model, persona, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (message_id, user_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			comment = COALESCE(NULLIF(EXCLUDED.comment, ''), message_feedback.comment),
+			model = EXCLUDED.model,
+			persona = EXCLUDED.persona,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, comment, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		feedback.MessageID,
+		feedback.UserID,
+		feedback.Rating,
+		feedback.Comment,
+		feedback.Model,
+		feedback.Persona,
+		feedback.UpdatedAt,
+	).Scan(&feedback.ID, &feedback.Comment, &feedback.CreatedAt, &feedback.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+
+	return nil
+}
+
+// Report агрегирует оценки за [from, to) по периодам (day, week или month), моделям и персонам
+func (r *FeedbackRepositoryImpl) Report(from, to time.Time, period string) ([]*FeedbackReportRow, error) {
+	query := `
+		SELECT
+			date_trunc($1, created_at) AS period,
+			model,
+			persona,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE rating > 0),
+			COUNT(*) FILTER (WHERE rating < 0),
+			COUNT(*) FILTER (WHERE comment <> '')
+		FROM message_feedback
+		WHERE created_at >= $2 AND created_at < $3
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`
+
+	rows, err := r.db.Query(query, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feedback report: %w", err)
+	}
+	defer rows.Close()
+
+	var report []*FeedbackReportRow
+	for rows.Next() {
+		row := &FeedbackReportRow{}
+		err := rows.Scan(
+			&row.Period,
+			&row.Model,
+			&row.Persona,
+			&row.Total,
+			&row.Positive,
+			&row.Negative,
+			&row.Comments,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feedback report: %w", err)
+		}
+		if row.Total > 0 {
+			row.Satisfaction = float64(row.Positive) / float64(row.Total)
+		}
+		report = append(report, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feedback report: %w", err)
+	}
+
+	return report, nil
+}
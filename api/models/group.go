@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// groupConversationPrefix префикс идентификатора диалога группы
+const groupConversationPrefix = "group:"
+
+// InlineConversationID диалог вопросов из inline режима. Вопросы сохраняются только
+// для учета дневного лимита: в историю и контекст личного диалога они не попадают.
+const InlineConversationID = "inline"
+
+// Conversation определяет, чья история передается модели: личный диалог
+// пользователя (нулевое значение), группа или тема форума в группе
+type Conversation struct {
+	ChatID   int64
+	ThreadID int64
+}
+
+// IsGroup сообщает, что диалог идет в группе
+func (c Conversation) IsGroup() bool {
+	return c.ChatID != 0
+}
+
+// ID возвращает идентификатор диалога для таблицы messages
+func (c Conversation) ID() string {
+	switch {
+	case !c.IsGroup():
+		return ""
+	case c.ThreadID != 0:
+		return fmt.Sprintf("%s%d:%d", groupConversationPrefix, c.ChatID, c.ThreadID)
+	default:
+		return fmt.Sprintf("%s%d", groupConversationPrefix, c.ChatID)
+	}
+}
+
+// ParseConversationID восстанавливает диалог по идентификатору из таблицы messages
+func ParseConversationID(id string) (Conversation, error) {
+	if id == "" {
+		return Conversation{}, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(id, groupConversationPrefix), ":")
+	if !strings.HasPrefix(id, groupConversationPrefix) || len(parts) > 2 {
+		return Conversation{}, fmt.Errorf("invalid conversation id %q", id)
+	}
+
+	var conversation Conversation
+	var err error
+	if conversation.ChatID, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return Conversation{}, fmt.Errorf("invalid conversation id %q: %w", id, err)
+	}
+	if len(parts) == 2 {
+		if conversation.ThreadID, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return Conversation{}, fmt.Errorf("invalid conversation id %q: %w", id, err)
+		}
+	}
+	return conversation, nil
+}
+
+// GroupSettings представляет настройки бота в группе.
+// Пустые Model и Persona означают значения по умолчанию.
+type GroupSettings struct {
+	ChatID         int64      `json:"chat_id" db:"chat_id"`
+	Enabled        bool       `json:"enabled" db:"enabled"`
+	Model          string     `json:"model" db:"model"`
+	Persona        string     `json:"persona" db:"persona"`
+	ContextResetAt *time.Time `json:"context_reset_at,omitempty" db:"context_reset_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// GroupSettingsRequest представляет запрос на изменение настроек группы.
+// Не переданные поля не меняются.
+type GroupSettingsRequest struct {
+	Enabled *bool   `json:"enabled"`
+	Model   *string `json:"model"`
+	Persona *string `json:"persona"`
+}
+
+// GroupRepository интерфейс для работы с настройками групп
+type GroupRepository interface {
+	Get(chatID int64) (*GroupSettings, error)
+	Save(settings *GroupSettings) error
+	ResetContext(chatID int64, at time.Time) error
+}
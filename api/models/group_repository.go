@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GroupRepositoryImpl реализует интерфейс GroupRepository
+type GroupRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewGroupRepository создает новый репозиторий настроек групп
+func NewGroupRepository(db *sql.DB) GroupRepository {
+	return &GroupRepositoryImpl{db: db}
+}
+
+// Get получает настройки группы; если их нет, возвращает настройки по умолчанию
+func (r *GroupRepositoryImpl) Get(chatID int64) (*GroupSettings, error) {
+	query := `
+		SELECT chat_id, enabled, model, persona, context_reset_at, updated_at
+		FROM group_settings
+		WHERE chat_id = $1
+	`
+
+	settings := &GroupSettings{}
+	var resetAt sql.NullTime
+	err := r.db.QueryRow(query, chatID).Scan(
+		&settings.ChatID,
+		&settings.Enabled,
+		&settings.Model,
+		&settings.Persona,
+		&resetAt,
+		&settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &GroupSettings{ChatID: chatID, Enabled: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group settings: %w", err)
+	}
+
+	if resetAt.Valid {
+		settings.ContextResetAt = &resetAt.Time
+	}
+
+	return settings, nil
+}
+
+// Save сохраняет настройки группы
+func (r *GroupRepositoryImpl) Save(settings *GroupSettings) error {
+	query := `
+		INSERT INTO group_settings (chat_id, enabled, model, persona, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (chat_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, model = EXCLUDED.model,
+			persona = EXCLUDED.persona, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, settings.ChatID, settings.Enabled, settings.Model, settings.Persona, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save group settings: %w", err)
+	}
+
+	return nil
+}
+
+// ResetContext начинает новый диалог во всей группе, включая темы форума
+func (r *GroupRepositoryImpl) ResetContext(chatID int64, at time.Time) error {
+	query := `
+		INSERT INTO group_settings (chat_id, context_reset_at, updated_at)
+		VALUES ($1, $2, $2)
+		ON CONFLICT (chat_id) DO UPDATE
+		SET context_reset_at = EXCLUDED.context_reset_at, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.Exec(query, chatID, at); err != nil {
+		return fmt.Errorf("failed to reset group context: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrKnowledgeDocumentNotFound возвращается, если документа нет в базе знаний
+var ErrKnowledgeDocumentNotFound = errors.New("knowledge document not found")
+
+// KnowledgeDocument представляет документ базы знаний
+type KnowledgeDocument struct {
+	ID         int64     `json:"id" db:"id"`
+	Title      string    `json:"title" db:"title"`
+	Filename   string    `json:"filename" db:"filename"`
+	UploadedBy int64     `json:"uploaded_by" db:"uploaded_by"`
+	ChunkCount int       `json:"chunk_count" db:"chunk_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// KnowledgeChunk представляет фрагмент документа базы знаний с эмбеддингом
+type KnowledgeChunk struct {
+	ID             int64     `json:"id" db:"id"`
+	DocumentID     int64     `json:"document_id" db:"document_id"`
+	DocumentTitle  string    `json:"document_title" db:"title"`
+	ChunkIndex     int       `json:"chunk_index" db:"chunk_index"`
+	Content        string    `json:"content" db:"content"`
+	Embedding      []float32 `json:"-" db:"embedding"`
+	EmbeddingModel string    `json:"-" db:"embedding_model"`
+}
+
+// Citation ссылка на фрагмент базы знаний, использованный в ответе
+type Citation struct {
+	Index      int    `json:"index"`
+	DocumentID int64  `json:"document_id"`
+	Title      string `json:"title"`
+	ChunkIndex int    `json:"chunk_index"`
+	Snippet    string `json:"snippet"`
+}
+
+// KnowledgeRepository интерфейс для работы с базой знаний
+type KnowledgeRepository interface {
+	Save(document *KnowledgeDocument, chunks []*KnowledgeChunk) error
+	List() ([]*KnowledgeDocument, error)
+	Delete(documentID int64) error
+	GetChunks(embeddingModel string) ([]*KnowledgeChunk, error)
+}
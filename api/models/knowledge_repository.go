@@ -0,0 +1,176 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// KnowledgeRepositoryImpl реализует интерфейс KnowledgeRepository
+type KnowledgeRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewKnowledgeRepository создает новый репозиторий базы знаний
+func NewKnowledgeRepository(db *sql.DB) KnowledgeRepository {
+	return &KnowledgeRepositoryImpl{db: db}
+}
+
+// Save сохраняет документ базы знаний и его фрагменты в одной транзакции
+func (r *KnowledgeRepositoryImpl) Save(document *KnowledgeDocument, chunks []*KnowledgeChunk) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO kb_documents (title, filename, uploaded_by, chunk_count, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	err = tx.QueryRow(
+		query,
+		document.Title,
+		document.Filename,
+		document.UploadedBy,
+		len(chunks),
+		document.CreatedAt,
+	).Scan(&document.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save knowledge document: %w", err)
+	}
+	document.ChunkCount = len(chunks)
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO kb_chunks (document_id, chunk_index, content, embedding, embedding_model)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare chunk insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, chunk := range chunks {
+		chunk.DocumentID = document.ID
+		chunk.DocumentTitle = document.Title
+		err := stmt.QueryRow(
+			chunk.DocumentID,
+			chunk.ChunkIndex,
+			chunk.Content,
+			pq.Array(chunk.Embedding),
+			chunk.EmbeddingModel,
+		).Scan(&chunk.ID)
+		if err != nil {
+			return fmt.Errorf("failed to save knowledge chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit knowledge document: %w", err)
+	}
+
+	return nil
+}
+
+// List возвращает все документы базы знаний
+func (r *KnowledgeRepositoryImpl) List() ([]*KnowledgeDocument, error) {
+	query := `
+		SELECT id, title, filename, uploaded_by, chunk_count, created_at
+		FROM kb_documents
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*KnowledgeDocument
+	for rows.Next() {
+		document := &KnowledgeDocument{}
+		err := rows.Scan(
+			&document.ID,
+			&document.Title,
+			&document.Filename,
+			&document.UploadedBy,
+			&document.ChunkCount,
+			&document.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan knowledge document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating knowledge documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// Delete удаляет документ базы знаний вместе с фрагментами
+func (r *KnowledgeRepositoryImpl) Delete(documentID int64) error {
+	result, err := r.db.Exec(`DELETE FROM kb_documents WHERE id = $1`, documentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete knowledge document: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete knowledge document: %w", err)
+	}
+	if affected == 0 {
+		return ErrKnowledgeDocumentNotFound
+	}
+
+	return nil
+}
+
+// GetChunks возвращает все фрагменты, проиндексированные указанной моделью эмбеддингов
+func (r *KnowledgeRepositoryImpl) GetChunks(embeddingModel string) ([]*KnowledgeChunk, error) {
+	query := `
+		SELECT c.id, c.document_id, d.title, c.chunk_index, c.content, c.embedding, c.embedding_model
+		FROM kb_chunks c
+		JOIN kb_documents d ON d.id = c.document_id
+		WHERE c.embedding_model = $1
+		ORDER BY c.document_id, c.chunk_index
+	`
+
+	rows, err := r.db.Query(query, embeddingModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []*KnowledgeChunk
+	for rows.Next() {
+		chunk := &KnowledgeChunk{}
+		var embedding pq.Float32Array
+		err := rows.Scan(
+			&chunk.ID,
+			&chunk.DocumentID,
+			&chunk.DocumentTitle,
+			&chunk.ChunkIndex,
+			&chunk.Content,
+			&embedding,
+			&chunk.EmbeddingModel,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan knowledge chunk: %w", err)
+		}
+		chunk.Embedding = embedding
+		chunks = append(chunks, chunk)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating knowledge chunks: %w", err)
+	}
+
+	return chunks, nil
+}
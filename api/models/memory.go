@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMemoryNotFound возвращается, если факт не найден у пользователя
+	ErrMemoryNotFound = errors.New("memory not found")
+	// ErrProposalNotFound возвращается, если предложение не найдено у пользователя или уже рассмотрено
+	ErrProposalNotFound = errors.New("memory proposal not found")
+)
+
+// Memory представляет факт о пользователе, который помнит ассистент
+type Memory struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Content   string    `json:"content" db:"content"`
+	Source    string    `json:"source" db:"source"` // "user" или "assistant"
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MemoryProposal факт, который ассистент предложил запомнить; сохраняется
+// в память только после подтверждения пользователем
+type MemoryProposal struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MemoryRequest представляет запрос на добавление факта пользователем
+type MemoryRequest struct {
+	Content string `json:"content" binding:"required,max=300"`
+}
+
+// MemoryRepository интерфейс для работы с памятью пользователей
+type MemoryRepository interface {
+	Save(ctx context.Context, memory *Memory) error
+	GetByUserID(ctx context.Context, userID int64) ([]*Memory, error)
+	Delete(ctx context.Context, userID, memoryID int64) error
+	DeleteAll(ctx context.Context, userID int64) (int64, error)
+
+	SaveProposal(ctx context.Context, proposal *MemoryProposal) error
+	// GetProposals возвращает предложения пользователя, созданные не раньше since
+	GetProposals(ctx context.Context, userID int64, since time.Time) ([]*MemoryProposal, error)
+	// TakeProposal удаляет предложение и возвращает его
+	TakeProposal(ctx context.Context, userID, proposalID int64) (*MemoryProposal, error)
+	// DeleteProposalsBefore удаляет предложения пользователя, созданные раньше before
+	DeleteProposalsBefore(ctx context.Context, userID int64, before time.Time) error
+}
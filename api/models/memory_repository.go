@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MemoryRepositoryImpl реализует интерфейс MemoryRepository
+type MemoryRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewMemoryRepository создает новый репозиторий памяти
+func NewMemoryRepository(db *sql.DB) MemoryRepository {
+	return &MemoryRepositoryImpl{db: db}
+}
+
+// Save сохраняет факт о пользователе
+func (r *MemoryRepositoryImpl) Save(ctx context.Context, memory *Memory) error {
+	query := `
+		INSERT INTO user_memories (user_id, content, source, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		memory.UserID,
+		memory.Content,
+		memory.Source,
+		memory.CreatedAt,
+	).Scan(&memory.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID получает все факты о пользователе (от старых к новым)
+func (r *MemoryRepositoryImpl) GetByUserID(ctx context.Context, userID int64) ([]*Memory, error) {
+	query := `
+		SELECT id, user_id, content, source, created_at
+		FROM user_memories
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memories: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []*Memory
+	for rows.Next() {
+		memory := &Memory{}
+		err := rows.Scan(
+			&memory.ID,
+			&memory.UserID,
+			&memory.Content,
+			&memory.Source,
+			&memory.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		memories = append(memories, memory)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating memories: %w", err)
+	}
+
+	return memories, nil
+}
+
+// Delete удаляет факт о пользователе
+func (r *MemoryRepositoryImpl) Delete(ctx context.Context, userID, memoryID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_memories WHERE id = $1 AND user_id = $2`, memoryID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+	if affected == 0 {
+		return ErrMemoryNotFound
+	}
+
+	return nil
+}
+
+// DeleteAll удаляет все факты о пользователе и возвращает их количество
+func (r *MemoryRepositoryImpl) DeleteAll(ctx context.Context, userID int64) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_memories WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete memories: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete memories: %w", err)
+	}
+
+	return affected, nil
+}
+
+// SaveProposal сохраняет предложение запомнить факт
+func (r *MemoryRepositoryImpl) SaveProposal(ctx context.Context, proposal *MemoryProposal) error {
+	query := `
+		INSERT INTO memory_proposals (user_id, content, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query, proposal.UserID, proposal.Content, proposal.CreatedAt).Scan(&proposal.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save memory proposal: %w", err)
+	}
+
+	return nil
+}
+
+// GetProposals получает предложения пользователя, созданные не раньше since (от старых к новым)
+func (r *MemoryRepositoryImpl) GetProposals(ctx context.Context, userID int64, since time.Time) ([]*MemoryProposal, error) {
+	query := `
+		SELECT id, user_id, content, created_at
+		FROM memory_proposals
+		WHERE user_id = $1 AND created_at >= $2
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory proposals: %w", err)
+	}
+	defer rows.Close()
+
+	var proposals []*MemoryProposal
+	for rows.Next() {
+		proposal := &MemoryProposal{}
+		err := rows.Scan(
+			&proposal.ID,
+			&proposal.UserID,
+			&proposal.Content,
+			&proposal.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan memory proposal: %w", err)
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating memory proposals: %w", err)
+	}
+
+	return proposals, nil
+}
+
+// TakeProposal удаляет предложение пользователя и возвращает его. Удаление и чтение
+// выполняются одним запросом, поэтому предложение нельзя подтвердить дважды.
+func (r *MemoryRepositoryImpl) TakeProposal(ctx context.Context, userID, proposalID int64) (*MemoryProposal, error) {
+	query := `
+		DELETE FROM memory_proposals
+		WHERE id = $1 AND user_id = $2
+		RETURNING id, user_id, content, created_at
+	`
+
+	proposal := &MemoryProposal{}
+	err := r.db.QueryRowContext(ctx, query, proposalID, userID).Scan(
+		&proposal.ID,
+		&proposal.UserID,
+		&proposal.Content,
+		&proposal.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProposalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take memory proposal: %w", err)
+	}
+
+	return proposal, nil
+}
+
+// DeleteProposalsBefore удаляет устаревшие предложения пользователя
+func (r *MemoryRepositoryImpl) DeleteProposalsBefore(ctx context.Context, userID int64, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM memory_proposals WHERE user_id = $1 AND created_at < $2`, userID, before)
+	if err != nil {
+		return fmt.Errorf("failed to delete memory proposals: %w", err)
+	}
+
+	return nil
+}
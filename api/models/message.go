@@ -1,27 +1,57 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
+// ErrMessageNotFound возвращается, если сообщение не найдено у пользователя
+var ErrMessageNotFound = errors.New("message not found")
+
 // Message представляет сообщение в чате
 type Message struct {
-	ID        int64     `json:"id" db:"id"`
-	UserID    int64     `json:"user_id" db:"user_id"`
-	Content   string    `json:"content" db:"content"`
-	Role      string    `json:"role" db:"role"` // "user" или "assistant"
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID             int64     `json:"id" db:"id"`
+	UserID         int64     `json:"user_id" db:"user_id"`
+	ConversationID string    `json:"conversation_id,omitempty" db:"conversation_id"` // пусто для личного диалога
+	Content        string    `json:"content" db:"content"`
+	Role           string    `json:"role" db:"role"`                 // "user", "assistant" или "system" (только в контексте модели)
+	Model          string    `json:"model,omitempty" db:"model"`     // модель, сгенерировавшая ответ ассистента
+	Persona        string    `json:"persona,omitempty" db:"persona"` // персона ответа ассистента
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 }
 
 // ChatRequest представляет запрос на отправку сообщения
 type ChatRequest struct {
+	Message    string `json:"message" binding:"required,max=300"`
+	DocumentID int64  `json:"document_id,omitempty"` // документ, о котором спрашивает пользователь
+
+	// Сообщение из группы (только для запросов от бота)
+	ChatID     int64  `json:"chat_id,omitempty"`
+	ThreadID   int64  `json:"thread_id,omitempty"` // тема форума
+	AuthorName string `json:"author_name,omitempty" binding:"max=255"`
+}
+
+// InlineRequest представляет отдельный вопрос из inline режима, который не сохраняется в историю
+type InlineRequest struct {
 	Message string `json:"message" binding:"required,max=300"`
 }
 
+// RegenerateRequest представляет запрос на повторную генерацию или продолжение ответа
+type RegenerateRequest struct {
+	MessageID int64 `json:"message_id" binding:"required"` // ответ ассистента
+}
+
 // ChatResponse представляет ответ от API
 type ChatResponse struct {
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
+	MessageID  int64      `json:"message_id,omitempty"` // идентификатор ответа ассистента
+	Message    string     `json:"message"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Citations  []Citation `json:"citations,omitempty"`  // источники из базы знаний
+	Transcript string     `json:"transcript,omitempty"` // распознанный текст голосового сообщения
+	// MemoryProposals факты, которые ассистент предложил запомнить; сохраняются после подтверждения
+	MemoryProposals []*MemoryProposal `json:"memory_proposals,omitempty"`
 }
 
 // TelegramWebAppData представляет данные от Telegram WebApp
@@ -36,19 +66,57 @@ type TelegramWebAppData struct {
 
 // OpenRouterRequest представляет запрос к OpenRouter API
 type OpenRouterRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
+	Model       string                  `json:"model"`
+	Messages    []ChatCompletionMessage `json:"messages"`
+	MaxTokens   int                     `json:"max_tokens,omitempty"`
+	Temperature float64                 `json:"temperature,omitempty"`
+	Tools       []ToolDefinition        `json:"tools,omitempty"`
+}
+
+// ChatCompletionMessage представляет сообщение в формате chat completions API
+type ChatCompletionMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolDefinition описывает инструмент, доступный модели
+type ToolDefinition struct {
+	Type     string                 `json:"type"` // всегда "function"
+	Function ToolFunctionDefinition `json:"function"`
+}
+
+// ToolFunctionDefinition описывает функцию и JSON схему ее аргументов
+type ToolFunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolCall представляет вызов инструмента, запрошенный моделью
+type ToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 // OpenRouterResponse представляет ответ от OpenRouter API
 type OpenRouterResponse struct {
 	Choices []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
@@ -56,7 +124,13 @@ type OpenRouterResponse struct {
 
 // MessageRepository интерфейс для работы с сообщениями
 type MessageRepository interface {
-	Save(message *Message) error
-	GetByUserID(userID int64, limit int) ([]*Message, error)
-	GetUserMessageCount(userID int64) (int, error)
+	Save(ctx context.Context, message *Message) error
+	GetByUserID(ctx context.Context, userID int64, limit int) ([]*Message, error)
+	GetConversation(ctx context.Context, userID int64, conversationID string, beforeID int64, limit int) ([]*Message, error)
+	GetByID(ctx context.Context, userID, messageID int64) (*Message, error)
+	UpdateAnswer(ctx context.Context, message *Message) error
+	SaveRegeneration(ctx context.Context, userID, messageID int64) error
+	GetUserMessageCount(ctx context.Context, userID int64) (int, error)
+	GetDailyCounts(ctx context.Context, userID int64, days int) ([]*DailyCount, error)
+	Search(ctx context.Context, userID int64, query string, limit int) ([]*Message, error)
 }
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+
+	"telegram-api/tracing"
 )
 
 // MessageRepositoryImpl реализует интерфейс MessageRepository
@@ -15,43 +19,244 @@ func NewMessageRepository(db *sql.DB) MessageRepository {
 	return &MessageRepositoryImpl{db: db}
 }
 
+// startSpan создает спан запроса к таблице messages
+func (r *MessageRepositoryImpl) startSpan(ctx context.Context, method, operation string) (context.Context, *tracing.Span) {
+	return tracing.Start(ctx, "MessageRepository."+method, tracing.KindClient,
+		tracing.String("db.system.name", "postgresql"),
+		tracing.String("db.collection.name", "messages"),
+		tracing.String("db.operation.name", operation),
+	)
+}
+
 // Save сохраняет сообщение в базе данных
-func (r *MessageRepositoryImpl) Save(message *Message) error {
+func (r *MessageRepositoryImpl) Save(ctx context.Context, message *Message) error {
+	ctx, span := r.startSpan(ctx, "Save", "INSERT")
+	defer span.End()
+
 	query := `
-		INSERT INTO messages (user_id, content, role, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO messages (user_id, conversation_id, content, role, model, persona, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		message.UserID,
+		message.ConversationID,
 		message.Content,
 		message.Role,
+		message.Model,
+		message.Persona,
 		message.CreatedAt,
 	).Scan(&message.ID)
 
 	if err != nil {
+		span.RecordError(err)
 		return fmt.Errorf("failed to save message: %w", err)
 	}
 
 	return nil
 }
 
-// GetByUserID получает последние сообщения пользователя
-func (r *MessageRepositoryImpl) GetByUserID(userID int64, limit int) ([]*Message, error) {
+// GetByUserID получает последние сообщения личного диалога пользователя
+func (r *MessageRepositoryImpl) GetByUserID(ctx context.Context, userID int64, limit int) ([]*Message, error) {
+	return r.GetConversation(ctx, userID, "", 0, limit)
+}
+
+// GetConversation получает последние сообщения диалога (от старых к новым).
+// Пустой conversationID означает личный диалог пользователя, иначе берется
+// общая история группы. beforeID > 0 ограничивает выборку сообщениями до него.
+func (r *MessageRepositoryImpl) GetConversation(ctx context.Context, userID int64, conversationID string, beforeID int64, limit int) ([]*Message, error) {
+	ctx, span := r.startSpan(ctx, "GetConversation", "SELECT")
+	defer span.End()
+
 	query := `
-		SELECT id, user_id, content, role, created_at
+		SELECT id, user_id, conversation_id, content, role, model, persona, created_at
 		FROM messages
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2
+		WHERE conversation_id = $1
+		AND ($1 <> '' OR user_id = $2)
+		AND ($3 = 0 OR id < $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
 	`
 
-	rows, err := r.db.Query(query, userID, limit)
+	messages, err := r.queryMessages(ctx, query, conversationID, userID, beforeID, limit)
 	if err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
+
+	// Разворачиваем порядок (от старых к новым)
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// GetByID получает сообщение пользователя по идентификатору
+func (r *MessageRepositoryImpl) GetByID(ctx context.Context, userID, messageID int64) (*Message, error) {
+	ctx, span := r.startSpan(ctx, "GetByID", "SELECT")
+	defer span.End()
+
+	query := `
+		SELECT id, user_id, conversation_id, content, role, model, persona, created_at
+		FROM messages
+		WHERE id = $1 AND user_id = $2
+	`
+
+	messages, err := r.queryMessages(ctx, query, messageID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	return messages[0], nil
+}
+
+// UpdateAnswer заменяет текст, модель и персону ответа (используется при перегенерации)
+func (r *MessageRepositoryImpl) UpdateAnswer(ctx context.Context, message *Message) error {
+	ctx, span := r.startSpan(ctx, "UpdateAnswer", "UPDATE")
+	defer span.End()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE messages SET content = $1, model = $2, persona = $3 WHERE id = $4 AND user_id = $5`,
+		message.Content, message.Model, message.Persona, message.ID, message.UserID,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+	if affected == 0 {
+		return ErrMessageNotFound
+	}
+
+	return nil
+}
+
+// SaveRegeneration отмечает повторную генерацию ответа для учета в дневном лимите
+func (r *MessageRepositoryImpl) SaveRegeneration(ctx context.Context, userID, messageID int64) error {
+	ctx, span := r.startSpan(ctx, "SaveRegeneration", "INSERT")
+	defer span.End()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO message_regenerations (user_id, message_id) VALUES ($1, $2)`,
+		userID, messageID,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to save regeneration: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserMessageCount возвращает количество сообщений пользователя за сегодня
+// (во всех диалогах, включая группы) вместе с повторными генерациями ответов
+func (r *MessageRepositoryImpl) GetUserMessageCount(ctx context.Context, userID int64) (int, error) {
+	ctx, span := r.startSpan(ctx, "GetUserMessageCount", "SELECT")
+	defer span.End()
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM messages
+			WHERE user_id = $1 AND role = 'user' AND DATE(created_at) = CURRENT_DATE)
+			+
+			(SELECT COUNT(*) FROM message_regenerations
+			WHERE user_id = $1 AND DATE(created_at) = CURRENT_DATE)
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to get message count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetDailyCounts возвращает число сообщений пользователя по дням за последние days дней
+// (дни без сообщений пропускаются)
+func (r *MessageRepositoryImpl) GetDailyCounts(ctx context.Context, userID int64, days int) ([]*DailyCount, error) {
+	ctx, span := r.startSpan(ctx, "GetDailyCounts", "SELECT")
+	defer span.End()
+
+	query := `
+		SELECT DATE(created_at), COUNT(*)
+		FROM messages
+		WHERE user_id = $1
+		AND role = 'user'
+		AND created_at >= CURRENT_DATE - $2 * INTERVAL '1 day'
+		GROUP BY 1
+		ORDER BY 1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, days-1)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get daily counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*DailyCount
+	for rows.Next() {
+		count := &DailyCount{}
+		if err := rows.Scan(&count.Date, &count.Messages); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan daily count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating daily counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// Search ищет сообщения личного диалога пользователя, содержащие строку (без учета регистра)
+func (r *MessageRepositoryImpl) Search(ctx context.Context, userID int64, query string, limit int) ([]*Message, error) {
+	ctx, span := r.startSpan(ctx, "Search", "SELECT")
+	defer span.End()
+
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+
+	messages, err := r.queryMessages(ctx, `
+		SELECT id, user_id, conversation_id, content, role, model, persona, created_at
+		FROM messages
+		WHERE user_id = $1 AND conversation_id = '' AND content ILIKE $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, userID, pattern, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// queryMessages выполняет запрос и читает сообщения в порядке выборки
+func (r *MessageRepositoryImpl) queryMessages(ctx context.Context, query string, args ...interface{}) ([]*Message, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var messages []*Message
@@ -60,8 +265,11 @@ func (r *MessageRepositoryImpl) GetByUserID(userID int64, limit int) ([]*Message
 		err := rows.Scan(
 			&message.ID,
 			&message.UserID,
+			&message.ConversationID,
 			&message.Content,
 			&message.Role,
+			&message.Model,
+			&message.Persona,
 			&message.CreatedAt,
 		)
 		if err != nil {
@@ -74,29 +282,5 @@ func (r *MessageRepositoryImpl) GetByUserID(userID int64, limit int) ([]*Message
 		return nil, fmt.Errorf("error iterating messages: %w", err)
 	}
 
-	// Разворачиваем порядок (от старых к новым)
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
-	}
-
 	return messages, nil
 }
-
-// GetUserMessageCount возвращает количество сообщений пользователя за сегодня
-func (r *MessageRepositoryImpl) GetUserMessageCount(userID int64) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM messages
-		WHERE user_id = $1 
-		AND role = 'user'
-		AND DATE(created_at) = CURRENT_DATE
-	`
-
-	var count int
-	err := r.db.QueryRow(query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get message count: %w", err)
-	}
-
-	return count, nil
-}
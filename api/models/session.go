@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSessionNotFound возвращается, если сессии нет, она отозвана или истекла
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session представляет сессию пользователя, выданную после проверки initData
+type Session struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      int64      `json:"user_id" db:"user_id"`
+	Username    string     `json:"username" db:"username"`
+	FirstName   string     `json:"first_name" db:"first_name"`
+	LastName    string     `json:"last_name" db:"last_name"`
+	Generation  int        `json:"-" db:"generation"` // номер действующего refresh токена
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RefreshedAt time.Time  `json:"refreshed_at" db:"refreshed_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"` // срок действия refresh токена
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// SessionRequest запрос на создание сессии; initData можно передать
+// и в заголовке X-Telegram-Init-Data
+type SessionRequest struct {
+	InitData string `json:"init_data"`
+}
+
+// RefreshRequest запрос на обновление токенов сессии
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionTokens токены сессии; сроки действия в секундах
+type SessionTokens struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// SessionRepository интерфейс для работы с сессиями
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetActive(ctx context.Context, id string) (*Session, error)
+	Rotate(ctx context.Context, id string, generation int, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string) error
+	RevokeAll(ctx context.Context, userID int64) (int64, error)
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// activeSessionCondition отбирает действующие сессии
+const activeSessionCondition = `revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`
+
+// SessionRepositoryImpl реализует интерфейс SessionRepository
+type SessionRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewSessionRepository создает новый репозиторий сессий
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &SessionRepositoryImpl{db: db}
+}
+
+// Create сохраняет новую сессию
+func (r *SessionRepositoryImpl) Create(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO user_sessions (id, user_id, username, first_name, last_name, generation, user_agent, created_at, refreshed_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.Username,
+		session.FirstName,
+		session.LastName,
+		session.Generation,
+		session.UserAgent,
+		session.CreatedAt,
+		session.RefreshedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// GetActive получает действующую сессию
+func (r *SessionRepositoryImpl) GetActive(ctx context.Context, id string) (*Session, error) {
+	query := `
+		SELECT id, user_id, username, first_name, last_name, generation, user_agent, created_at, refreshed_at, expires_at, revoked_at
+		FROM user_sessions
+		WHERE id = $1 AND ` + activeSessionCondition
+
+	session := &Session{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Username,
+		&session.FirstName,
+		&session.LastName,
+		&session.Generation,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.RefreshedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Rotate переводит сессию на следующий refresh токен и продлевает ее до expiresAt.
+// Если действующий токен уже не generation (его обновил параллельный запрос),
+// возвращает ErrSessionNotFound.
+func (r *SessionRepositoryImpl) Rotate(ctx context.Context, id string, generation int, expiresAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET generation = generation + 1, refreshed_at = CURRENT_TIMESTAMP, expires_at = $3
+		WHERE id = $1 AND generation = $2 AND `+activeSessionCondition,
+		id, generation, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// Revoke отзывает сессию
+func (r *SessionRepositoryImpl) Revoke(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND `+activeSessionCondition,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeAll отзывает все действующие сессии пользователя и возвращает их количество
+func (r *SessionRepositoryImpl) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND `+activeSessionCondition,
+		userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// UserSettings представляет настройки пользователя.
+// Пустые Model и Persona означают значения по умолчанию.
+type UserSettings struct {
+	UserID         int64      `json:"user_id" db:"user_id"`
+	Model          string     `json:"model" db:"model"`
+	Persona        string     `json:"persona" db:"persona"`
+	ContextResetAt *time.Time `json:"context_reset_at,omitempty" db:"context_reset_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SettingsRequest представляет запрос на изменение настроек.
+// Не переданные поля не меняются.
+type SettingsRequest struct {
+	Model   *string `json:"model"`
+	Persona *string `json:"persona"`
+}
+
+// SettingsRepository интерфейс для работы с настройками пользователей
+type SettingsRepository interface {
+	Get(ctx context.Context, userID int64) (*UserSettings, error)
+	Save(ctx context.Context, settings *UserSettings) error
+	ResetContext(ctx context.Context, userID int64, at time.Time) error
+}
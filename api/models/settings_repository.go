@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SettingsRepositoryImpl реализует интерфейс SettingsRepository
+type SettingsRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository создает новый репозиторий настроек
+func NewSettingsRepository(db *sql.DB) SettingsRepository {
+	return &SettingsRepositoryImpl{db: db}
+}
+
+// Get получает настройки пользователя; если их нет, возвращает настройки по умолчанию
+func (r *SettingsRepositoryImpl) Get(ctx context.Context, userID int64) (*UserSettings, error) {
+	query := `
+		SELECT user_id, model, persona, context_reset_at, updated_at
+		FROM user_settings
+		WHERE user_id = $1
+	`
+
+	settings := &UserSettings{}
+	var resetAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.Model,
+		&settings.Persona,
+		&resetAt,
+		&settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &UserSettings{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	if resetAt.Valid {
+		settings.ContextResetAt = &resetAt.Time
+	}
+
+	return settings, nil
+}
+
+// Save сохраняет модель и персону пользователя
+func (r *SettingsRepositoryImpl) Save(ctx context.Context, settings *UserSettings) error {
+	query := `
+		INSERT INTO user_settings (user_id, model, persona, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET model = EXCLUDED.model, persona = EXCLUDED.persona, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, settings.UserID, settings.Model, settings.Persona, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return nil
+}
+
+// ResetContext отмечает начало нового диалога: более ранние сообщения не попадают в контекст
+func (r *SettingsRepositoryImpl) ResetContext(ctx context.Context, userID int64, at time.Time) error {
+	query := `
+		INSERT INTO user_settings (user_id, context_reset_at, updated_at)
+		VALUES ($1, $2, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET context_reset_at = EXCLUDED.context_reset_at, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, at); err != nil {
+		return fmt.Errorf("failed to reset context: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUserNotFound возвращается, если пользователь не запускал бота
+var ErrUserNotFound = errors.New("user not found")
+
+// UserSummary представляет пользователя в админке: профиль из таблицы users
+// (заполняется ботом по /start и обновляется при обращениях), тариф, использование и блокировка
+type UserSummary struct {
+	UserID        int64      `json:"user_id"`
+	Username      string     `json:"username"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	LanguageCode  string     `json:"language_code"`
+	IsPremium     bool       `json:"is_premium"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+	BlockedAt     *time.Time `json:"blocked_at,omitempty"` // пользователь заблокировал бота
+	Plan          string     `json:"plan"`
+	BonusMessages int        `json:"bonus_messages"`
+	TotalMessages int        `json:"total_messages"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	Banned        bool       `json:"banned"`
+}
+
+// UserRepository интерфейс для поиска пользователей администраторами
+type UserRepository interface {
+	List(query string, limit, offset int) ([]*UserSummary, int, error)
+	Get(userID int64) (*UserSummary, error)
+}
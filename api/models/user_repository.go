@@ -0,0 +1,121 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// userSummarySelect выбирает пользователей вместе с тарифом, числом сообщений и блокировкой
+const userSummarySelect = `
+	SELECT
+		u.user_id,
+		COALESCE(u.username, ''),
+		COALESCE(u.first_name, ''),
+		COALESCE(u.last_name, ''),
+		u.language_code,
+		u.is_premium,
+		u.created_at,
+		u.last_seen_at,
+		u.blocked_at,
+		COALESCE(a.plan, '` + DefaultPlan + `'),
+		COALESCE(a.bonus_messages, 0),
+		(SELECT COUNT(*) FROM messages m WHERE m.user_id = u.user_id AND m.role = 'user'),
+		(SELECT MAX(m.created_at) FROM messages m WHERE m.user_id = u.user_id),
+		EXISTS (SELECT 1 FROM user_bans b WHERE b.user_id = u.user_id AND ` + activeBanCondition + `)
+	FROM users u
+	LEFT JOIN user_accounts a ON a.user_id = u.user_id
+`
+
+// UserRepositoryImpl реализует интерфейс UserRepository
+type UserRepositoryImpl struct {
+	db *sql.DB
+}
+
+// NewUserRepository создает новый репозиторий пользователей
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &UserRepositoryImpl{db: db}
+}
+
+// List ищет пользователей по Telegram ID, username или имени (без учета регистра)
+// и возвращает страницу результатов вместе с общим числом найденных
+func (r *UserRepositoryImpl) List(query string, limit, offset int) ([]*UserSummary, int, error) {
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.TrimPrefix(query, "@")) + "%"
+	condition := `
+		WHERE $1 = ''
+		OR CAST(u.user_id AS TEXT) = $1
+		OR u.username ILIKE $2
+		OR CONCAT_WS(' ', u.first_name, u.last_name) ILIKE $2
+	`
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users u`+condition, query, pattern).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	rows, err := r.db.Query(userSummarySelect+condition+`
+		ORDER BY u.created_at DESC
+		LIMIT $3 OFFSET $4
+	`, query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*UserSummary
+	for rows.Next() {
+		user, err := scanUserSummary(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// Get получает пользователя по Telegram ID
+func (r *UserRepositoryImpl) Get(userID int64) (*UserSummary, error) {
+	rows, err := r.db.Query(userSummarySelect+`WHERE u.user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		return nil, ErrUserNotFound
+	}
+
+	return scanUserSummary(rows)
+}
+
+func scanUserSummary(rows *sql.Rows) (*UserSummary, error) {
+	user := &UserSummary{}
+	err := rows.Scan(
+		&user.UserID,
+		&user.Username,
+		&user.FirstName,
+		&user.LastName,
+		&user.LanguageCode,
+		&user.IsPremium,
+		&user.CreatedAt,
+		&user.LastSeenAt,
+		&user.BlockedAt,
+		&user.Plan,
+		&user.BonusMessages,
+		&user.TotalMessages,
+		&user.LastMessageAt,
+		&user.Banned,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// postgresCleanupInterval как часто удалять давно не использованные корзины
+	postgresCleanupInterval = 10 * time.Minute
+	// postgresBucketTTL корзина без запросов дольше этого срока заведомо полна
+	// (самый длинный период лимита — час)
+	postgresBucketTTL = time.Hour
+)
+
+// refill количество токенов в корзине к текущему моменту
+const refill = `LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8)`
+
+// takeQuery пополняет корзину и берет токен одним запросом, поэтому несколько
+// экземпляров API не могут потратить один и тот же токен
+const takeQuery = `
+	INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::float8 - 1, TRUE, NOW())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = ` + refill + ` - CASE WHEN ` + refill + ` >= 1 THEN 1 ELSE 0 END,
+		allowed = ` + refill + ` >= 1,
+		updated_at = NOW()
+	RETURNING tokens, allowed
+`
+
+// PostgresStore хранилище корзин в таблице rate_limit_buckets, общее для всех экземпляров API
+type PostgresStore struct {
+	db          *sql.DB
+	lastCleanup atomic.Int64
+}
+
+// NewPostgresStore создает хранилище в Postgres
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	s := &PostgresStore{db: db}
+	s.lastCleanup.Store(time.Now().Unix())
+	return s
+}
+
+// Take берет токен из корзины
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.maybeCleanup()
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, takeQuery, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return newResult(tokens, allowed, limit), nil
+}
+
+// maybeCleanup в фоне удаляет старые корзины не чаще раза в postgresCleanupInterval
+func (s *PostgresStore) maybeCleanup() {
+	last := s.lastCleanup.Load()
+	now := time.Now().Unix()
+	if now-last < int64(postgresCleanupInterval.Seconds()) || !s.lastCleanup.CompareAndSwap(last, now) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, err := s.db.ExecContext(ctx,
+			`DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1::interval`,
+			fmt.Sprintf("%d seconds", int(postgresBucketTTL.Seconds())),
+		)
+		if err != nil {
+			slog.Error("Failed to clean up rate limit buckets", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,155 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit параметры корзины: Burst запросов подряд, затем Rate запросов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Disabled сообщает, что ограничение выключено
+func (l Limit) Disabled() bool {
+	return l.Burst <= 0 || l.Rate <= 0
+}
+
+// ParseLimit разбирает лимит вида "20/m": не более 20 запросов в минуту с возможностью
+// отправить их подряд. Единицы: s, m, h. "off" или "0" выключают ограничение.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return Limit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q", value)
+	}
+
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}, nil
+}
+
+// ParseRoutes разбирает лимиты маршрутов вида "POST /api/chat=20/m,GET /api/history=60/m".
+// Маршрут записывается как метод и шаблон пути Gin.
+func ParseRoutes(value string) (map[string]Limit, error) {
+	routes := make(map[string]Limit)
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		route, limitValue, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route rate limit %q", part)
+		}
+		limit, err := ParseLimit(limitValue)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return routes, nil
+}
+
+// Result результат попытки взять токен
+type Result struct {
+	Allowed    bool
+	Remaining  int           // сколько запросов еще можно отправить сразу
+	RetryAfter time.Duration // когда появится следующий токен (для отклоненных запросов)
+}
+
+// newResult вычисляет результат по остатку токенов после попытки
+func newResult(tokens float64, allowed bool, limit Limit) Result {
+	result := Result{Allowed: allowed, Remaining: int(math.Max(tokens, 0))}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	return result
+}
+
+// Store хранит корзины токенов. Хранилище в памяти подходит для одного экземпляра API,
+// общее хранилище (Postgres, Redis) — для нескольких.
+type Store interface {
+	// Take берет токен из корзины key, создавая полную корзину при первом обращении
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// memoryCleanupInterval как часто удалять из памяти заполнившиеся корзины
+const memoryCleanupInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore хранилище корзин в памяти процесса
+type MemoryStore struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// NewMemoryStore создает пустое хранилище в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastCleanup: time.Now()}
+}
+
+// Take берет токен из корзины
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastCleanup) >= memoryCleanupInterval {
+		s.cleanup(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	b.limit = limit
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(b.tokens, allowed, limit), nil
+}
+
+// cleanup удаляет корзины, которые уже заполнились: они ничем не отличаются от новых.
+// Вызывается под mu.
+func (s *MemoryStore) cleanup(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastCleanup = now
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+// usageHistoryDays за сколько дней показывать использование в карточке пользователя
+const usageHistoryDays = 30
+
+// ErrUnknownPlan возвращается при выборе несуществующего тарифа
+var ErrUnknownPlan = errors.New("unknown plan")
+
+// UserDetails карточка пользователя для администратора
+type UserDetails struct {
+	User    *models.UserSummary  `json:"user"`
+	Usage   *models.Usage        `json:"usage"`
+	History []*models.DailyCount `json:"history"`
+	Ban     *models.Ban          `json:"ban,omitempty"` // действующая блокировка
+	Bans    []*models.Ban        `json:"bans"`
+}
+
+// AdminService сервис управления пользователями: тарифы, бонусы и блокировки
+type AdminService struct {
+	userRepo    models.UserRepository
+	accountRepo models.AccountRepository
+	banRepo     models.BanRepository
+	messageRepo models.MessageRepository
+	quotaSvc    *QuotaService
+}
+
+// NewAdminService создает новый сервис администрирования
+func NewAdminService(
+	userRepo models.UserRepository,
+	accountRepo models.AccountRepository,
+	banRepo models.BanRepository,
+	messageRepo models.MessageRepository,
+	quotaSvc *QuotaService,
+) *AdminService {
+	return &AdminService{
+		userRepo:    userRepo,
+		accountRepo: accountRepo,
+		banRepo:     banRepo,
+		messageRepo: messageRepo,
+		quotaSvc:    quotaSvc,
+	}
+}
+
+// ListUsers ищет пользователей по Telegram ID, username или имени
+func (s *AdminService) ListUsers(query string, limit, offset int) ([]*models.UserSummary, int, error) {
+	return s.userRepo.List(strings.TrimSpace(query), limit, offset)
+}
+
+// GetUser возвращает карточку пользователя с использованием за последние дни и блокировками
+func (s *AdminService) GetUser(ctx context.Context, userID int64) (*UserDetails, error) {
+	user, err := s.userRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.quotaSvc.Usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.messageRepo.GetDailyCounts(ctx, userID, usageHistoryDays)
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := s.banRepo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &UserDetails{User: user, Usage: usage, History: history, Bans: bans}
+	if ban, err := s.banRepo.GetActive(ctx, userID); err == nil {
+		details.Ban = ban
+	} else if !errors.Is(err, models.ErrBanNotFound) {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// SetPlan меняет тариф пользователя
+func (s *AdminService) SetPlan(ctx context.Context, userID int64, plan string) (*models.Account, error) {
+	if _, ok := FindPlan(plan); !ok {
+		return nil, ErrUnknownPlan
+	}
+	if _, err := s.userRepo.Get(userID); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.SetPlan(ctx, userID, plan)
+}
+
+// AddBonus начисляет пользователю бонусные сообщения сверх дневного лимита
+func (s *AdminService) AddBonus(ctx context.Context, userID int64, messages int) (*models.Account, error) {
+	if _, err := s.userRepo.Get(userID); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.AddBonus(ctx, userID, messages)
+}
+
+// Ban блокирует пользователя; новая блокировка заменяет действующую
+func (s *AdminService) Ban(ctx context.Context, userID, adminID int64, req models.BanRequest) (*models.Ban, error) {
+	if err := s.banRepo.Revoke(ctx, userID, adminID); err != nil && !errors.Is(err, models.ErrBanNotFound) {
+		return nil, err
+	}
+
+	ban := &models.Ban{
+		UserID:    userID,
+		Reason:    strings.TrimSpace(req.Reason),
+		AdminID:   adminID,
+		CreatedAt: time.Now(),
+	}
+	if req.DurationHours > 0 {
+		expiresAt := ban.CreatedAt.Add(time.Duration(req.DurationHours) * time.Hour)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	if err := s.banRepo.Create(ctx, ban); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+// Unban досрочно снимает блокировку пользователя
+func (s *AdminService) Unban(ctx context.Context, userID, adminID int64) error {
+	return s.banRepo.Revoke(ctx, userID, adminID)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+const (
+	// APIKeyPrefix начало всех API ключей: по нему middleware отличает ключ от токена сессии
+	APIKeyPrefix = "tgk_"
+	// apiKeyDisplayLength сколько символов ключа сохраняется для узнавания в списке
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+	// maxAPIKeysPerUser ограничивает число действующих ключей пользователя
+	maxAPIKeysPerUser = 10
+	// apiKeyTouchInterval как часто обновлять время последнего использования ключа
+	apiKeyTouchInterval = time.Minute
+)
+
+// Ошибки сервиса API ключей
+var (
+	ErrAPIKeyLimit   = errors.New("api key limit reached")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// APIKeyService сервис персональных API ключей. Ключи случайные и длинные, поэтому
+// для хранения достаточно SHA-256: подбор по хешу невозможен, а проверка не нагружает API.
+type APIKeyService struct {
+	apiKeyRepo models.APIKeyRepository
+}
+
+// NewAPIKeyService создает новый сервис API ключей
+func NewAPIKeyService(apiKeyRepo models.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// Create создает ключ пользователя; ключ возвращается только здесь
+func (s *APIKeyService) Create(ctx context.Context, userID int64, req models.APIKeyRequest) (*models.APIKeyCreated, error) {
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{models.APIKeyScopeRead, models.APIKeyScopeChat}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	keys, err := s.apiKeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxAPIKeysPerUser {
+		return nil, ErrAPIKeyLimit
+	}
+
+	secret, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    secret[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(secret),
+		Scopes:    normalizeScopes(scopes),
+		CreatedAt: time.Now(),
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreated{APIKey: key, Key: secret}, nil
+}
+
+// List возвращает действующие ключи пользователя
+func (s *APIKeyService) List(ctx context.Context, userID int64) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.ListByUser(ctx, userID)
+}
+
+// Revoke отзывает ключ пользователя
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID int64) error {
+	return s.apiKeyRepo.Revoke(ctx, userID, keyID)
+}
+
+// Authenticate находит действующий ключ и отмечает его использование
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Время использования обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.Touch(ctx, key.ID); err != nil {
+			slog.WarnContext(ctx, "Failed to update api key usage", "key_id", key.ID, "error", err)
+		}
+	}
+
+	return key, nil
+}
+
+// normalizeScopes убирает повторы и упорядочивает области как в models.APIKeyScopes
+func normalizeScopes(scopes []string) []string {
+	var normalized []string
+	for _, scope := range models.APIKeyScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// newAPIKey генерирует ключ: префикс и 32 случайных байта
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+// broadcastListLimit сколько последних рассылок показывать в списке
+const broadcastListLimit = 50
+
+// BroadcastService сервис рассылок. Сообщения отправляет воркер Telegram бота,
+// который берет из очереди доставки рассылок в статусе running.
+type BroadcastService struct {
+	broadcastRepo models.BroadcastRepository
+}
+
+// NewBroadcastService создает новый сервис рассылок
+func NewBroadcastService(broadcastRepo models.BroadcastRepository) *BroadcastService {
+	return &BroadcastService{
+		broadcastRepo: broadcastRepo,
+	}
+}
+
+// Create создает рассылку и ставит ее в очередь отправки. Текст с ошибкой в HTML
+// разметке отклоняется сразу: Telegram не доставил бы его ни одному получателю.
+func (s *BroadcastService) Create(adminID int64, req models.BroadcastRequest) (*models.Broadcast, error) {
+	if req.Segment.Plan != "" {
+		if _, ok := FindPlan(req.Segment.Plan); !ok {
+			return nil, ErrUnknownPlan
+		}
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if err := ValidateTelegramHTML(text); err != nil {
+		return nil, err
+	}
+
+	broadcast := &models.Broadcast{
+		Text:       text,
+		ButtonText: strings.TrimSpace(req.ButtonText),
+		ButtonURL:  strings.TrimSpace(req.ButtonURL),
+		Segment:    req.Segment,
+		AdminID:    adminID,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.broadcastRepo.Create(broadcast); err != nil {
+		return nil, err
+	}
+
+	return broadcast, nil
+}
+
+// Get возвращает рассылку со статистикой доставки
+func (s *BroadcastService) Get(id int64) (*models.Broadcast, error) {
+	return s.broadcastRepo.Get(id)
+}
+
+// List возвращает последние рассылки
+func (s *BroadcastService) List() ([]*models.Broadcast, error) {
+	return s.broadcastRepo.List(broadcastListLimit)
+}
+
+// Pause приостанавливает отправку рассылки
+func (s *BroadcastService) Pause(id int64) (*models.Broadcast, error) {
+	return s.transition(id, models.BroadcastPaused, models.BroadcastRunning)
+}
+
+// Resume продолжает отправку приостановленной рассылки
+func (s *BroadcastService) Resume(id int64) (*models.Broadcast, error) {
+	return s.transition(id, models.BroadcastRunning, models.BroadcastPaused)
+}
+
+// Cancel отменяет рассылку; неотправленные сообщения остаются в статусе pending
+func (s *BroadcastService) Cancel(id int64) (*models.Broadcast, error) {
+	return s.transition(id, models.BroadcastCancelled, models.BroadcastRunning, models.BroadcastPaused)
+}
+
+// transition меняет статус рассылки и возвращает ее актуальное состояние
+func (s *BroadcastService) transition(id int64, status string, from ...string) (*models.Broadcast, error) {
+	if _, err := s.broadcastRepo.Get(id); err != nil {
+		return nil, err
+	}
+	if err := s.broadcastRepo.SetStatus(id, status, from...); err != nil {
+		return nil, err
+	}
+	return s.broadcastRepo.Get(id)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	// Встроенная база часовых поясов: в образе alpine ее нет
+	_ "time/tzdata"
+
+	"telegram-api/models"
+)
+
+const (
+	defaultTimezone    = "Europe/Moscow"
+	historySearchLimit = 10
+)
+
+// RegisterBuiltinTools регистрирует стандартные инструменты ассистента
+func RegisterBuiltinTools(registry *ToolRegistry, messageRepo models.MessageRepository) {
+	registry.MustRegister(Tool{
+		Name:        "current_time",
+		Description: "Returns the current date, time and weekday in the given IANA timezone.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"timezone": {"type": "string", "description": "IANA timezone, e.g. Europe/Moscow. Defaults to Europe/Moscow."}
+			}
+		}`),
+		Handler: currentTimeTool,
+	})
+
+	registry.MustRegister(Tool{
+		Name:        "calculator",
+		Description: "Evaluates an arithmetic expression exactly. Supports + - * / % ^, parentheses, sqrt, abs, sin, cos, tan, ln, log, exp, round, floor, ceil, min, max, pi and e.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"expression": {"type": "string", "description": "Expression to evaluate, e.g. (2 + 3) * sqrt(16)"}
+			},
+			"required": ["expression"]
+		}`),
+		Handler: calculatorTool,
+	})
+
+	registry.MustRegister(Tool{
+		Name:        "search_history",
+		Description: "Searches the user's previous chat messages for a word or phrase and returns matching messages with dates.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"query": {"type": "string", "description": "Word or phrase to search for"},
+				"limit": {"type": "integer", "minimum": 1, "maximum": 10, "description": "Maximum number of messages to return"}
+			},
+			"required": ["query"]
+		}`),
+		Handler:  searchHistoryTool(messageRepo),
+		Personal: true,
+	})
+}
+
+// currentTimeTool возвращает текущее время в часовом поясе
+func currentTimeTool(_ ToolContext, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Timezone == "" {
+		args.Timezone = defaultTimezone
+	}
+
+	location, err := time.LoadLocation(args.Timezone)
+	if err != nil {
+		return "", fmt.Errorf("unknown timezone %q", args.Timezone)
+	}
+
+	now := time.Now().In(location)
+	data, err := json.Marshal(map[string]string{
+		"timezone": args.Timezone,
+		"datetime": now.Format(time.RFC3339),
+		"weekday":  now.Weekday().String(),
+	})
+	return string(data), err
+}
+
+// calculatorTool вычисляет арифметическое выражение
+func calculatorTool(_ ToolContext, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Expression == "" {
+		return "", fmt.Errorf("expression is required")
+	}
+
+	value, err := EvaluateExpression(args.Expression)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"expression": args.Expression,
+		"result":     strconv.FormatFloat(value, 'g', -1, 64),
+	})
+	return string(data), err
+}
+
+// searchHistoryTool ищет по истории сообщений текущего пользователя
+func searchHistoryTool(messageRepo models.MessageRepository) ToolHandler {
+	return func(ctx ToolContext, arguments json.RawMessage) (string, error) {
+		var args struct {
+			Query string `json:"query"`
+			Limit int    `json:"limit"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		if args.Query == "" {
+			return "", fmt.Errorf("query is required")
+		}
+		if args.Limit <= 0 || args.Limit > historySearchLimit {
+			args.Limit = historySearchLimit
+		}
+
+		messages, err := messageRepo.Search(ctx, ctx.UserID, args.Query, args.Limit)
+		if err != nil {
+			return "", fmt.Errorf("history search failed")
+		}
+
+		type result struct {
+			Role      string `json:"role"`
+			Content   string `json:"content"`
+			CreatedAt string `json:"created_at"`
+		}
+		results := make([]result, len(messages))
+		for i, message := range messages {
+			results[i] = result{
+				Role:      message.Role,
+				Content:   message.Content,
+				CreatedAt: message.CreatedAt.Format(time.RFC3339),
+			}
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"query":    args.Query,
+			"messages": results,
+		})
+		return string(data), err
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Состояния предохранителя
+const (
+	CircuitClosed   = "closed"    // запросы проходят
+	CircuitOpen     = "open"      // запросы отклоняются без обращения к сервису
+	CircuitHalfOpen = "half_open" // пропускается один пробный запрос
+)
+
+// CircuitBreaker предохранитель для внешнего сервиса: после threshold сбоев подряд
+// запросы отклоняются в течение cooldown, затем пробный запрос решает,
+// восстановился ли сервис
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker создает закрытый предохранитель
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitClosed}
+}
+
+// Allow сообщает, можно ли выполнить запрос. После разрешенного запроса
+// нужно вызвать Success или Failure.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success отмечает успешный запрос и закрывает предохранитель
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure отмечает сбой сервиса; неудачный пробный запрос снова размыкает предохранитель
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Cancel отмечает запрос, прерванный клиентом: о состоянии сервиса он ничего не говорит
+func (b *CircuitBreaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State возвращает текущее состояние предохранителя
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
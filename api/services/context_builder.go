@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+// groupChatPrompt поясняет модели формат сообщений в группе
+const groupChatPrompt = "Ты участник группового чата в Telegram. Сообщения пользователей начинаются с имени автора. " +
+	"Отвечай тому, кто к тебе обратился, и не выдумывай реплики других участников."
+
+const (
+	historyContextLimit  = 10
+	documentExcerptLimit = 4
+	citationSnippetLen   = 200
+)
+
+// ChatContext сообщения для модели, выбранная пользователем модель
+// и источники, на которые может ссылаться ответ
+type ChatContext struct {
+	Model     string
+	Persona   string
+	Messages  []*models.Message
+	Citations []models.Citation
+}
+
+// ContextBuilder собирает список сообщений, отправляемых модели
+type ContextBuilder struct {
+	messageRepo  models.MessageRepository
+	documentSvc  *DocumentService
+	knowledgeSvc *KnowledgeService
+	memorySvc    *MemoryService
+	settingsSvc  *SettingsService
+}
+
+// NewContextBuilder создает новый сборщик контекста
+func NewContextBuilder(
+	messageRepo models.MessageRepository,
+	documentSvc *DocumentService,
+	knowledgeSvc *KnowledgeService,
+	memorySvc *MemoryService,
+	settingsSvc *SettingsService,
+) *ContextBuilder {
+	return &ContextBuilder{
+		messageRepo:  messageRepo,
+		documentSvc:  documentSvc,
+		knowledgeSvc: knowledgeSvc,
+		memorySvc:    memorySvc,
+		settingsSvc:  settingsSvc,
+	}
+}
+
+// buildOptions что включать в контекст
+type buildOptions struct {
+	documentID int64
+	// beforeMessageID == 0 означает всю историю
+	beforeMessageID int64
+	// historyLimit == 0 — контекст без истории
+	historyLimit int
+	// personal добавить память о пользователе и его документы
+	personal bool
+}
+
+// Build возвращает историю текущего диалога, дополненную промптом персоны,
+// памятью о пользователе, базой знаний и документами пользователя.
+// В группах личные память и документы не используются: ответ видят все участники.
+func (b *ContextBuilder) Build(ctx context.Context, userID int64, conversation models.Conversation, query string, documentID int64) (*ChatContext, error) {
+	return b.build(ctx, userID, conversation, query, buildOptions{
+		documentID:   documentID,
+		historyLimit: historyContextLimit,
+		personal:     !conversation.IsGroup(),
+	})
+}
+
+// BuildBefore собирает контекст так, как он выглядел до сообщения beforeMessageID.
+// Используется для повторной генерации ответа ассистента.
+func (b *ContextBuilder) BuildBefore(ctx context.Context, userID int64, conversation models.Conversation, query string, beforeMessageID int64) (*ChatContext, error) {
+	return b.build(ctx, userID, conversation, query, buildOptions{
+		beforeMessageID: beforeMessageID,
+		historyLimit:    historyContextLimit,
+		personal:        !conversation.IsGroup(),
+	})
+}
+
+// BuildStateless собирает контекст отдельного вопроса (inline режим) без истории,
+// памяти и документов: ответ отправляется в выбранный пользователем чат, где его
+// видят другие люди
+func (b *ContextBuilder) BuildStateless(ctx context.Context, userID int64, query string) (*ChatContext, error) {
+	return b.build(ctx, userID, models.Conversation{}, query, buildOptions{})
+}
+
+// build собирает контекст диалога
+func (b *ContextBuilder) build(
+	ctx context.Context,
+	userID int64,
+	conversation models.Conversation,
+	query string,
+	opts buildOptions,
+) (*ChatContext, error) {
+	settings, err := b.conversationSettings(ctx, userID, conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получаем историю сообщений для контекста (последние 10)
+	var history []*models.Message
+	if opts.historyLimit > 0 {
+		history, err = b.messageRepo.GetConversation(ctx, userID, conversation.ID(), opts.beforeMessageID, opts.historyLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message history: %w", err)
+		}
+	}
+	if settings.ContextResetAt != nil {
+		history = messagesAfter(history, *settings.ContextResetAt)
+	}
+
+	chatContext := &ChatContext{Model: settings.Model, Persona: settings.Persona}
+	var systemMessages []string
+
+	if persona, ok := FindPersona(settings.Persona); ok {
+		systemMessages = append(systemMessages, persona.Prompt)
+	}
+
+	if conversation.IsGroup() {
+		systemMessages = append(systemMessages, groupChatPrompt)
+	}
+	if opts.personal {
+		memories, err := b.memorySvc.List(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user memories: %w", err)
+		}
+		if len(memories) > 0 {
+			systemMessages = append(systemMessages, formatMemoryContext(memories))
+		}
+	}
+
+	// Недоступность провайдера эмбеддингов не должна ломать обычный чат
+	knowledge, err := b.knowledgeSvc.Search(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error searching knowledge base", "error", err)
+	}
+	if len(knowledge) > 0 {
+		systemMessages = append(systemMessages, formatKnowledgeContext(knowledge))
+		chatContext.Citations = buildCitations(knowledge)
+	}
+
+	if opts.personal {
+		excerpts, err := b.documentSvc.RelevantExcerpts(userID, opts.documentID, query, documentExcerptLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get document excerpts: %w", err)
+		}
+		if len(excerpts) > 0 {
+			systemMessages = append(systemMessages, formatDocumentContext(excerpts))
+		}
+	}
+
+	chatContext.Messages = make([]*models.Message, 0, len(systemMessages)+len(history))
+	for _, content := range systemMessages {
+		chatContext.Messages = append(chatContext.Messages, &models.Message{
+			UserID:    userID,
+			Role:      "system",
+			Content:   content,
+			CreatedAt: time.Now(),
+		})
+	}
+	chatContext.Messages = append(chatContext.Messages, history...)
+
+	return chatContext, nil
+}
+
+// conversationSettings возвращает модель, персону и начало диалога: в группе —
+// настройки группы, в личном чате — настройки пользователя
+func (b *ContextBuilder) conversationSettings(ctx context.Context, userID int64, conversation models.Conversation) (*models.UserSettings, error) {
+	if !conversation.IsGroup() {
+		settings, err := b.settingsSvc.Get(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user settings: %w", err)
+		}
+		return settings, nil
+	}
+
+	group, err := b.settingsSvc.GetGroup(conversation.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group settings: %w", err)
+	}
+	return &models.UserSettings{
+		UserID:         userID,
+		Model:          group.Model,
+		Persona:        group.Persona,
+		ContextResetAt: group.ContextResetAt,
+	}, nil
+}
+
+// messagesAfter оставляет сообщения, созданные после начала нового диалога
+func messagesAfter(messages []*models.Message, since time.Time) []*models.Message {
+	var result []*models.Message
+	for _, message := range messages {
+		if message.CreatedAt.After(since) {
+			result = append(result, message)
+		}
+	}
+	return result
+}
+
+// formatKnowledgeContext оформляет фрагменты базы знаний с номерами источников
+func formatKnowledgeContext(excerpts []KnowledgeExcerpt) string {
+	var sb strings.Builder
+	sb.WriteString("Ниже приведены фрагменты базы знаний нашего сервиса. ")
+	sb.WriteString("Если вопрос касается сервиса, отвечай только на их основе и указывай источник в виде [номер]. ")
+	sb.WriteString("Если ответа во фрагментах нет, так и скажи.\n")
+	for i, excerpt := range excerpts {
+		fmt.Fprintf(&sb, "\n[%d] %s\n%s\n", i+1, excerpt.Chunk.DocumentTitle, excerpt.Chunk.Content)
+	}
+	return sb.String()
+}
+
+// buildCitations формирует список источников для ответа API
+func buildCitations(excerpts []KnowledgeExcerpt) []models.Citation {
+	citations := make([]models.Citation, len(excerpts))
+	for i, excerpt := range excerpts {
+		snippet := []rune(excerpt.Chunk.Content)
+		if len(snippet) > citationSnippetLen {
+			snippet = append(snippet[:citationSnippetLen], '…')
+		}
+		citations[i] = models.Citation{
+			Index:      i + 1,
+			DocumentID: excerpt.Chunk.DocumentID,
+			Title:      excerpt.Chunk.DocumentTitle,
+			ChunkIndex: excerpt.Chunk.ChunkIndex,
+			Snippet:    string(snippet),
+		}
+	}
+	return citations
+}
+
+// formatDocumentContext оформляет фрагменты документов для системного сообщения
+func formatDocumentContext(excerpts []DocumentExcerpt) string {
+	var sb strings.Builder
+	sb.WriteString("Пользователь загрузил документы. Ниже приведены фрагменты, относящиеся к вопросу. ")
+	sb.WriteString("Отвечай на их основе и сообщай, если ответа в них нет.\n")
+	for _, excerpt := range excerpts {
+		fmt.Fprintf(&sb, "\n--- %s, фрагмент %d ---\n%s\n", excerpt.Filename, excerpt.ChunkIndex+1, excerpt.Content)
+	}
+	return sb.String()
+}
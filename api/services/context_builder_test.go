@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"telegram-api/models"
+)
+
+// Заглушки репозиториев: встроенный интерфейс оставляет неиспользуемые методы
+// нереализованными, поэтому неожиданный вызов приводит к панике
+
+type stubMessageRepository struct {
+	models.MessageRepository
+	history []*models.Message
+}
+
+func (r *stubMessageRepository) GetConversation(_ context.Context, _ int64, _ string, _ int64, _ int) ([]*models.Message, error) {
+	return r.history, nil
+}
+
+type stubSettingsRepository struct {
+	models.SettingsRepository
+}
+
+func (r *stubSettingsRepository) Get(_ context.Context, userID int64) (*models.UserSettings, error) {
+	return &models.UserSettings{UserID: userID}, nil
+}
+
+type stubMemoryRepository struct {
+	models.MemoryRepository
+	memories []*models.Memory
+}
+
+func (r *stubMemoryRepository) GetByUserID(context.Context, int64) ([]*models.Memory, error) {
+	return r.memories, nil
+}
+
+type stubDocumentRepository struct {
+	models.DocumentRepository
+	documents []*models.Document
+	chunks    []*models.DocumentChunk
+	// searched слова и лимит последнего вызова SearchChunks
+	searchedTerms []string
+	searchedLimit int
+}
+
+func (r *stubDocumentRepository) GetByUserID(int64, int) ([]*models.Document, error) {
+	return r.documents, nil
+}
+
+// SearchChunks повторяет фильтр content ILIKE ANY(...) репозитория
+func (r *stubDocumentRepository) SearchChunks(_ []int64, terms []string, limit int) ([]*models.DocumentChunk, error) {
+	r.searchedTerms, r.searchedLimit = terms, limit
+
+	var chunks []*models.DocumentChunk
+	for _, chunk := range r.chunks {
+		content := strings.ToLower(chunk.Content)
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				chunks = append(chunks, chunk)
+				break
+			}
+		}
+	}
+	return chunks, nil
+}
+
+type stubKnowledgeRepository struct {
+	models.KnowledgeRepository
+}
+
+func (r *stubKnowledgeRepository) GetChunks(string) ([]*models.KnowledgeChunk, error) {
+	return nil, nil
+}
+
+type stubEmbeddings struct {
+	EmbeddingProvider
+}
+
+func (stubEmbeddings) Model() string { return "stub" }
+
+// newTestContextBuilder собирает ContextBuilder с памятью и документом пользователя
+func newTestContextBuilder() *ContextBuilder {
+	messages := &stubMessageRepository{history: []*models.Message{
+		{Role: "user", Content: "Предыдущий вопрос"},
+	}}
+	memories := &stubMemoryRepository{memories: []*models.Memory{
+		{ID: 1, UserID: 42, Content: "Живет в Казани"},
+	}}
+	documents := &stubDocumentRepository{
+		documents: []*models.Document{{ID: 7, UserID: 42, Filename: "salary.pdf"}},
+		chunks:    []*models.DocumentChunk{{ID: 1, DocumentID: 7, Content: "Зарплата за март: 250000"}},
+	}
+
+	return NewContextBuilder(
+		messages,
+		NewDocumentService(documents),
+		NewKnowledgeService(&stubKnowledgeRepository{}, stubEmbeddings{}, 3, 0.5),
+		NewMemoryService(memories),
+		NewSettingsService(&stubSettingsRepository{}, nil, nil, "test-model"),
+	)
+}
+
+// systemContent объединяет системные сообщения контекста
+func systemContent(chatContext *ChatContext) string {
+	var parts []string
+	for _, message := range chatContext.Messages {
+		if message.Role == "system" {
+			parts = append(parts, message.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestBuildIncludesPersonalContext(t *testing.T) {
+	chatContext, err := newTestContextBuilder().Build(context.Background(), 42, models.Conversation{}, "зарплата за март", 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	system := systemContent(chatContext)
+	if !strings.Contains(system, "Живет в Казани") {
+		t.Errorf("private chat context should include memories, got %q", system)
+	}
+	if !strings.Contains(system, "salary.pdf") {
+		t.Errorf("private chat context should include document excerpts, got %q", system)
+	}
+	if len(chatContext.Messages) == 0 || chatContext.Messages[len(chatContext.Messages)-1].Content != "Предыдущий вопрос" {
+		t.Errorf("private chat context should include history")
+	}
+}
+
+func TestBuildStatelessExcludesPersonalContext(t *testing.T) {
+	chatContext, err := newTestContextBuilder().BuildStateless(context.Background(), 42, "зарплата за март")
+	if err != nil {
+		t.Fatalf("BuildStateless() error = %v", err)
+	}
+
+	for _, message := range chatContext.Messages {
+		if message.Role != "system" {
+			t.Errorf("stateless context should have no history, got %s message %q", message.Role, message.Content)
+		}
+	}
+	system := systemContent(chatContext)
+	if strings.Contains(system, "Живет в Казани") {
+		t.Errorf("stateless context must not include memories, got %q", system)
+	}
+	if strings.Contains(system, "salary.pdf") || strings.Contains(system, "250000") {
+		t.Errorf("stateless context must not include document excerpts, got %q", system)
+	}
+	if chatContext.Model != "test-model" {
+		t.Errorf("Model = %q, want user's model", chatContext.Model)
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedDocument возвращается для неподдерживаемых форматов файлов
+var ErrUnsupportedDocument = errors.New("unsupported document format")
+
+// ErrEmptyDocument возвращается, если из файла не удалось извлечь текст
+var ErrEmptyDocument = errors.New("document contains no text")
+
+// ErrDocumentTooLarge возвращается, если распакованное содержимое документа превышает лимит
+var ErrDocumentTooLarge = errors.New("document content is too large")
+
+const (
+	// maxExtractedSize общий лимит распакованных данных одного документа: защищает от
+	// zip/zlib-бомб, которые при небольшом размере файла распаковываются в гигабайты
+	maxExtractedSize = 64 << 20
+	// maxStreamSize лимит одного распакованного потока (word/document.xml, поток PDF)
+	maxStreamSize = 32 << 20
+)
+
+// documentMimeTypes поддерживаемые расширения и их MIME типы
+var documentMimeTypes = map[string]string{
+	".pdf":      "application/pdf",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".txt":      "text/plain",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+}
+
+// DocumentMimeType возвращает MIME тип документа по имени файла
+func DocumentMimeType(filename string) (string, error) {
+	mimeType, ok := documentMimeTypes[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return "", ErrUnsupportedDocument
+	}
+	return mimeType, nil
+}
+
+// ExtractText извлекает текст из документа локально, без внешних сервисов
+func ExtractText(filename string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		text, err = extractPDFText(data)
+	case ".docx":
+		text, err = extractDOCXText(data)
+	case ".txt", ".md", ".markdown":
+		text, err = extractPlainText(data)
+	default:
+		return "", ErrUnsupportedDocument
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract text from %s: %w", filename, err)
+	}
+
+	text = normalizeText(text)
+	if text == "" {
+		return "", ErrEmptyDocument
+	}
+
+	return text, nil
+}
+
+// extractPlainText проверяет кодировку текстового файла
+func extractPlainText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("file is not valid UTF-8")
+	}
+	return string(data), nil
+}
+
+// extractDOCXText читает текст из word/document.xml архива DOCX
+func extractDOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx archive: %w", err)
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open document.xml: %w", err)
+		}
+		defer rc.Close()
+
+		// Размер из заголовка архива задается отправителем, поэтому ограничивается само чтение
+		limited := &io.LimitedReader{R: rc, N: maxStreamSize + 1}
+		text, err := parseDOCXBody(limited)
+		if limited.N <= 0 {
+			return "", ErrDocumentTooLarge
+		}
+		return text, err
+	}
+
+	return "", fmt.Errorf("word/document.xml not found")
+}
+
+// parseDOCXBody собирает текст из элементов w:t, сохраняя абзацы
+func parseDOCXBody(r io.Reader) (string, error) {
+	decoder := xml.NewDecoder(r)
+
+	var sb strings.Builder
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse document.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br", "cr":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n\n")
+			case "tc":
+				sb.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// normalizeText убирает управляющие символы и лишние пустые строки
+func normalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ToValidUTF8(text, "")
+
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.Map(func(r rune) rune {
+			if r == '\t' || r >= 0x20 && r != 0x7F && r != utf8.RuneError {
+				return r
+			}
+			return -1
+		}, line)
+		line = strings.TrimRight(line, " \t")
+
+		if strings.TrimSpace(line) == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+			line = ""
+		} else {
+			blank = 0
+		}
+		result = append(result, line)
+	}
+
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
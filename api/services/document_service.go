@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"telegram-api/models"
+)
+
+const (
+	// MaxDocumentSize максимальный размер загружаемого документа
+	MaxDocumentSize = 10 << 20
+
+	documentChunkSize    = 1200
+	maxDocumentChunks    = 500
+	maxSearchedDocuments = 20
+	maxCandidateChunks   = 200
+)
+
+// DocumentExcerpt фрагмент документа, подобранный для контекста
+type DocumentExcerpt struct {
+	DocumentID int64
+	Filename   string
+	ChunkIndex int
+	Content    string
+}
+
+// DocumentService сервис загрузки документов и поиска по ним
+type DocumentService struct {
+	documentRepo models.DocumentRepository
+}
+
+// NewDocumentService создает новый сервис документов
+func NewDocumentService(documentRepo models.DocumentRepository) *DocumentService {
+	return &DocumentService{
+		documentRepo: documentRepo,
+	}
+}
+
+// Upload извлекает текст из файла, разбивает его на фрагменты и сохраняет
+func (s *DocumentService) Upload(userID int64, filename string, data []byte) (*models.Document, error) {
+	mimeType, err := DocumentMimeType(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := ExtractText(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := ChunkText(text, documentChunkSize)
+	if len(parts) > maxDocumentChunks {
+		parts = parts[:maxDocumentChunks]
+	}
+
+	chunks := make([]*models.DocumentChunk, len(parts))
+	for i, part := range parts {
+		chunks[i] = &models.DocumentChunk{ChunkIndex: i, Content: part}
+	}
+
+	document := &models.Document{
+		UserID:    userID,
+		Filename:  filename,
+		MimeType:  mimeType,
+		SizeBytes: int64(len(data)),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.documentRepo.Save(document, chunks); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// List возвращает документы пользователя
+func (s *DocumentService) List(userID int64) ([]*models.Document, error) {
+	return s.documentRepo.GetByUserID(userID, 100)
+}
+
+// Delete удаляет документ пользователя
+func (s *DocumentService) Delete(userID, documentID int64) error {
+	return s.documentRepo.Delete(userID, documentID)
+}
+
+// RelevantExcerpts подбирает фрагменты документов пользователя, относящиеся к запросу.
+// Если documentID указан, поиск идет только по этому документу, а при отсутствии
+// совпадений возвращается начало документа (например, для просьбы «перескажи»).
+func (s *DocumentService) RelevantExcerpts(userID, documentID int64, query string, limit int) ([]DocumentExcerpt, error) {
+	var documents []*models.Document
+	if documentID != 0 {
+		document, err := s.documentRepo.GetByID(userID, documentID)
+		if err != nil {
+			return nil, err
+		}
+		documents = []*models.Document{document}
+	} else {
+		var err error
+		documents, err = s.documentRepo.GetByUserID(userID, maxSearchedDocuments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	filenames := make(map[int64]string, len(documents))
+	ids := make([]int64, len(documents))
+	for i, document := range documents {
+		filenames[document.ID] = document.Filename
+		ids[i] = document.ID
+	}
+
+	var chunks []*models.DocumentChunk
+	var err error
+	if documentID != 0 {
+		chunks, err = s.documentRepo.GetChunks(ids)
+	} else {
+		// По всем документам ранжируем только фрагменты, где встречаются слова запроса:
+		// иначе на каждый вопрос пришлось бы разбирать до 20×500 фрагментов
+		chunks, err = s.documentRepo.SearchChunks(ids, uniqueStrings(tokenizeText(query)), maxCandidateChunks)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document chunks: %w", err)
+	}
+
+	contents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+	}
+
+	selected := make([]int, 0, limit)
+	for _, scored := range rankChunks(query, contents, limit) {
+		selected = append(selected, scored.index)
+	}
+	if documentID != 0 {
+		for i := 0; i < len(chunks) && len(selected) < limit; i++ {
+			if !containsInt(selected, i) {
+				selected = append(selected, i)
+			}
+		}
+	}
+
+	// Сохраняем порядок фрагментов в документе, чтобы модель видела связный текст
+	sort.Ints(selected)
+
+	excerpts := make([]DocumentExcerpt, 0, len(selected))
+	for _, i := range selected {
+		excerpts = append(excerpts, DocumentExcerpt{
+			DocumentID: chunks[i].DocumentID,
+			Filename:   filenames[chunks[i].DocumentID],
+			ChunkIndex: chunks[i].ChunkIndex,
+			Content:    chunks[i].Content,
+		})
+	}
+
+	return excerpts, nil
+}
+
+// containsInt проверяет наличие значения в срезе
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"telegram-api/models"
+)
+
+func TestRelevantExcerptsRanksOnlyMatchingChunks(t *testing.T) {
+	documents := &stubDocumentRepository{
+		documents: []*models.Document{{ID: 7, UserID: 42, Filename: "report.txt"}},
+		chunks: []*models.DocumentChunk{
+			{ID: 1, DocumentID: 7, ChunkIndex: 0, Content: "Введение и содержание отчета"},
+			{ID: 2, DocumentID: 7, ChunkIndex: 1, Content: "Выручка компании выросла на 12%"},
+			{ID: 3, DocumentID: 7, ChunkIndex: 2, Content: "Список литературы"},
+		},
+	}
+
+	excerpts, err := NewDocumentService(documents).RelevantExcerpts(42, 0, "Какая выручка у компании?", documentExcerptLimit)
+	if err != nil {
+		t.Fatalf("RelevantExcerpts() error = %v", err)
+	}
+
+	if want := []string{"какая", "выручк", "компан"}; !reflect.DeepEqual(documents.searchedTerms, want) {
+		t.Errorf("searched terms = %q, want %q", documents.searchedTerms, want)
+	}
+	if documents.searchedLimit != maxCandidateChunks {
+		t.Errorf("searched limit = %d, want %d", documents.searchedLimit, maxCandidateChunks)
+	}
+	if len(excerpts) != 1 || excerpts[0].ChunkIndex != 1 || excerpts[0].Filename != "report.txt" {
+		t.Errorf("excerpts = %+v, want only the revenue chunk", excerpts)
+	}
+}
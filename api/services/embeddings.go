@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// EmbeddingProvider вычисляет векторные представления текстов
+type EmbeddingProvider interface {
+	// Embed возвращает по одному вектору на каждый текст
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model идентифицирует модель; векторы разных моделей несравнимы
+	Model() string
+}
+
+// HashEmbeddingProvider локальный детерминированный провайдер эмбеддингов.
+// Использует хеширование термов и их пар в вектор фиксированной длины,
+// не требует сети и подходит для тестов и небольших баз знаний.
+type HashEmbeddingProvider struct {
+	dimensions int
+}
+
+// NewHashEmbeddingProvider создает локальный провайдер эмбеддингов
+func NewHashEmbeddingProvider(dimensions int) *HashEmbeddingProvider {
+	return &HashEmbeddingProvider{dimensions: dimensions}
+}
+
+// Model возвращает идентификатор локальной модели
+func (p *HashEmbeddingProvider) Model() string {
+	return fmt.Sprintf("local-hash-%d", p.dimensions)
+}
+
+// Embed вычисляет эмбеддинги текстов
+func (p *HashEmbeddingProvider) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed вычисляет эмбеддинг одного текста
+func (p *HashEmbeddingProvider) embed(text string) []float32 {
+	vector := make([]float32, p.dimensions)
+	terms := tokenizeText(text)
+
+	add := func(feature string, weight float32) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		index := int(sum % uint64(p.dimensions))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[index] += weight
+	}
+
+	for i, term := range terms {
+		add(term, 1)
+		if i > 0 {
+			add(terms[i-1]+" "+term, 0.5)
+		}
+	}
+
+	normalizeVector(vector)
+	return vector
+}
+
+// OpenAIEmbeddingProvider провайдер эмбеддингов с OpenAI-совместимым API (/embeddings)
+type OpenAIEmbeddingProvider struct {
+	apiKey string
+	url    string
+	model  string
+	client *http.Client
+}
+
+// NewOpenAIEmbeddingProvider создает провайдер эмбеддингов OpenAI-совместимого API
+func NewOpenAIEmbeddingProvider(apiKey, url, model string) *OpenAIEmbeddingProvider {
+	return &OpenAIEmbeddingProvider{
+		apiKey: apiKey,
+		url:    url,
+		model:  model,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Model возвращает имя модели эмбеддингов
+func (p *OpenAIEmbeddingProvider) Model() string {
+	return p.model
+}
+
+// Embed запрашивает эмбеддинги текстов у API
+func (p *OpenAIEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.url+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings API returned %d vectors for %d texts", len(response.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings API returned invalid index %d", item.Index)
+		}
+		normalizeVector(item.Embedding)
+		vectors[item.Index] = item.Embedding
+	}
+
+	return vectors, nil
+}
+
+// normalizeVector приводит вектор к единичной длине
+func normalizeVector(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+}
+
+// cosineSimilarity вычисляет сходство нормализованных векторов
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
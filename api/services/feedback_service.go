@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+// Ошибки сервиса оценок
+var (
+	ErrNotAnswer          = errors.New("only assistant messages can be rated")
+	ErrInvalidReportRange = errors.New("invalid report range")
+)
+
+// feedbackPeriods допустимые периоды агрегации отчета
+var feedbackPeriods = map[string]bool{"day": true, "week": true, "month": true}
+
+// FeedbackService сервис оценок ответов ассистента
+type FeedbackService struct {
+	feedbackRepo models.FeedbackRepository
+	messageRepo  models.MessageRepository
+}
+
+// NewFeedbackService создает новый сервис оценок
+func NewFeedbackService(feedbackRepo models.FeedbackRepository, messageRepo models.MessageRepository) *FeedbackService {
+	return &FeedbackService{
+		feedbackRepo: feedbackRepo,
+		messageRepo:  messageRepo,
+	}
+}
+
+// Rate сохраняет оценку ответа ассистента. Оценить можно только ответ на свой вопрос;
+// модель и персона берутся из ответа.
+func (s *FeedbackService) Rate(ctx context.Context, userID, messageID int64, req models.FeedbackRequest) (*models.Feedback, error) {
+	message, err := s.messageRepo.GetByID(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Role != "assistant" {
+		return nil, ErrNotAnswer
+	}
+
+	feedback := &models.Feedback{
+		MessageID: message.ID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Comment:   strings.TrimSpace(req.Comment),
+		Model:     message.Model,
+		Persona:   message.Persona,
+		UpdatedAt: time.Now(),
+	}
+	if err := s.feedbackRepo.Save(feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// Report возвращает удовлетворенность ответами по моделям и персонам за период [from, to)
+func (s *FeedbackService) Report(from, to time.Time, period string) ([]*models.FeedbackReportRow, error) {
+	if !feedbackPeriods[period] || !from.Before(to) {
+		return nil, ErrInvalidReportRange
+	}
+	return s.feedbackRepo.Report(from, to, period)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-api/models"
+)
+
+const (
+	knowledgeChunkSize = 800
+	embeddingBatchSize = 32
+)
+
+// KnowledgeExcerpt фрагмент базы знаний, найденный по запросу
+type KnowledgeExcerpt struct {
+	Chunk *models.KnowledgeChunk
+	Score float64
+}
+
+// KnowledgeService сервис базы знаний с векторным поиском
+type KnowledgeService struct {
+	knowledgeRepo models.KnowledgeRepository
+	embeddings    EmbeddingProvider
+	topK          int
+	minScore      float64
+
+	// Кэш фрагментов; сбрасывается при изменении базы знаний
+	mu     sync.RWMutex
+	chunks []*models.KnowledgeChunk
+	loaded bool
+}
+
+// NewKnowledgeService создает новый сервис базы знаний
+func NewKnowledgeService(
+	knowledgeRepo models.KnowledgeRepository,
+	embeddings EmbeddingProvider,
+	topK int,
+	minScore float64,
+) *KnowledgeService {
+	return &KnowledgeService{
+		knowledgeRepo: knowledgeRepo,
+		embeddings:    embeddings,
+		topK:          topK,
+		minScore:      minScore,
+	}
+}
+
+// Upload извлекает текст документа, вычисляет эмбеддинги фрагментов и сохраняет их
+func (s *KnowledgeService) Upload(ctx context.Context, adminID int64, title, filename string, data []byte) (*models.KnowledgeDocument, error) {
+	if title == "" {
+		title = filename
+	}
+
+	text, err := ExtractText(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := ChunkText(text, knowledgeChunkSize)
+	chunks := make([]*models.KnowledgeChunk, 0, len(parts))
+	for start := 0; start < len(parts); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(parts) {
+			end = len(parts)
+		}
+
+		// Заголовок документа добавляется к фрагменту, чтобы улучшить поиск
+		inputs := make([]string, end-start)
+		for i, part := range parts[start:end] {
+			inputs[i] = title + "\n" + part
+		}
+
+		vectors, err := s.embeddings.Embed(ctx, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+
+		for i, part := range parts[start:end] {
+			chunks = append(chunks, &models.KnowledgeChunk{
+				ChunkIndex:     start + i,
+				Content:        part,
+				Embedding:      vectors[i],
+				EmbeddingModel: s.embeddings.Model(),
+			})
+		}
+	}
+
+	document := &models.KnowledgeDocument{
+		Title:      title,
+		Filename:   filename,
+		UploadedBy: adminID,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.knowledgeRepo.Save(document, chunks); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	return document, nil
+}
+
+// List возвращает документы базы знаний
+func (s *KnowledgeService) List() ([]*models.KnowledgeDocument, error) {
+	return s.knowledgeRepo.List()
+}
+
+// Delete удаляет документ из базы знаний
+func (s *KnowledgeService) Delete(documentID int64) error {
+	if err := s.knowledgeRepo.Delete(documentID); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Search возвращает наиболее похожие на запрос фрагменты базы знаний
+func (s *KnowledgeService) Search(ctx context.Context, query string) ([]KnowledgeExcerpt, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	chunks, err := s.loadChunks()
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := s.embeddings.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	var excerpts []KnowledgeExcerpt
+	for _, chunk := range chunks {
+		score := cosineSimilarity(vectors[0], chunk.Embedding)
+		if score >= s.minScore {
+			excerpts = append(excerpts, KnowledgeExcerpt{Chunk: chunk, Score: score})
+		}
+	}
+
+	sort.Slice(excerpts, func(i, j int) bool {
+		return excerpts[i].Score > excerpts[j].Score
+	})
+	if len(excerpts) > s.topK {
+		excerpts = excerpts[:s.topK]
+	}
+
+	return excerpts, nil
+}
+
+// loadChunks возвращает фрагменты из кэша, загружая их при необходимости
+func (s *KnowledgeService) loadChunks() ([]*models.KnowledgeChunk, error) {
+	s.mu.RLock()
+	if s.loaded {
+		chunks := s.chunks
+		s.mu.RUnlock()
+		return chunks, nil
+	}
+	s.mu.RUnlock()
+
+	chunks, err := s.knowledgeRepo.GetChunks(s.embeddings.Model())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.chunks = chunks
+	s.loaded = true
+	s.mu.Unlock()
+
+	return chunks, nil
+}
+
+// invalidate сбрасывает кэш фрагментов
+func (s *KnowledgeService) invalidate() {
+	s.mu.Lock()
+	s.chunks = nil
+	s.loaded = false
+	s.mu.Unlock()
+}
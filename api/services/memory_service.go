@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-api/models"
+)
+
+const (
+	// maxMemoriesPerUser ограничивает размер памяти, попадающей в каждый запрос к модели
+	maxMemoriesPerUser = 50
+	// maxPendingProposals ограничивает число неподтвержденных предложений запомнить факт
+	maxPendingProposals = 10
+	// memoryProposalTTL сколько предложение ждет подтверждения пользователя
+	memoryProposalTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrMemoryLimit возвращается при превышении числа сохраненных фактов
+	ErrMemoryLimit = errors.New("memory limit reached")
+	// ErrProposalLimit возвращается, если пользователь не рассмотрел предыдущие предложения
+	ErrProposalLimit = errors.New("too many pending memory proposals")
+)
+
+// MemoryService сервис долговременной памяти о пользователях
+type MemoryService struct {
+	memoryRepo models.MemoryRepository
+}
+
+// NewMemoryService создает новый сервис памяти
+func NewMemoryService(memoryRepo models.MemoryRepository) *MemoryService {
+	return &MemoryService{
+		memoryRepo: memoryRepo,
+	}
+}
+
+// Add сохраняет факт о пользователе, пропуская дубликаты
+func (s *MemoryService) Add(ctx context.Context, userID int64, content, source string) (*models.Memory, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("memory content is empty")
+	}
+
+	memories, err := s.memoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, memory := range memories {
+		if strings.EqualFold(memory.Content, content) {
+			return memory, nil
+		}
+	}
+	if len(memories) >= maxMemoriesPerUser {
+		return nil, ErrMemoryLimit
+	}
+
+	memory := &models.Memory{
+		UserID:    userID,
+		Content:   content,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+	if err := s.memoryRepo.Save(ctx, memory); err != nil {
+		return nil, err
+	}
+
+	return memory, nil
+}
+
+// List возвращает все факты о пользователе
+func (s *MemoryService) List(ctx context.Context, userID int64) ([]*models.Memory, error) {
+	return s.memoryRepo.GetByUserID(ctx, userID)
+}
+
+// Delete удаляет факт о пользователе
+func (s *MemoryService) Delete(ctx context.Context, userID, memoryID int64) error {
+	return s.memoryRepo.Delete(ctx, userID, memoryID)
+}
+
+// Clear удаляет все факты о пользователе
+func (s *MemoryService) Clear(ctx context.Context, userID int64) (int64, error) {
+	return s.memoryRepo.DeleteAll(ctx, userID)
+}
+
+// Propose сохраняет предложение запомнить факт до подтверждения пользователем.
+// Если факт уже сохранен, возвращает nil; повторное предложение возвращает существующее.
+func (s *MemoryService) Propose(ctx context.Context, userID int64, content string) (*models.MemoryProposal, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("memory content is empty")
+	}
+
+	memories, err := s.memoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, memory := range memories {
+		if strings.EqualFold(memory.Content, content) {
+			return nil, nil
+		}
+	}
+	if len(memories) >= maxMemoriesPerUser {
+		return nil, ErrMemoryLimit
+	}
+
+	now := time.Now()
+	if err := s.memoryRepo.DeleteProposalsBefore(ctx, userID, now.Add(-memoryProposalTTL)); err != nil {
+		return nil, err
+	}
+	proposals, err := s.memoryRepo.GetProposals(ctx, userID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, proposal := range proposals {
+		if strings.EqualFold(proposal.Content, content) {
+			return proposal, nil
+		}
+	}
+	if len(proposals) >= maxPendingProposals {
+		return nil, ErrProposalLimit
+	}
+
+	proposal := &models.MemoryProposal{
+		UserID:    userID,
+		Content:   content,
+		CreatedAt: now,
+	}
+	if err := s.memoryRepo.SaveProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// Proposals возвращает неподтвержденные предложения, созданные не раньше since
+func (s *MemoryService) Proposals(ctx context.Context, userID int64, since time.Time) ([]*models.MemoryProposal, error) {
+	if expired := time.Now().Add(-memoryProposalTTL); since.Before(expired) {
+		since = expired
+	}
+	return s.memoryRepo.GetProposals(ctx, userID, since)
+}
+
+// Confirm сохраняет предложенный факт в память
+func (s *MemoryService) Confirm(ctx context.Context, userID, proposalID int64) (*models.Memory, error) {
+	proposal, err := s.take(ctx, userID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Add(ctx, userID, proposal.Content, "assistant")
+}
+
+// Reject отклоняет предложение запомнить факт
+func (s *MemoryService) Reject(ctx context.Context, userID, proposalID int64) error {
+	_, err := s.take(ctx, userID, proposalID)
+	return err
+}
+
+// take удаляет предложение и возвращает его, если оно еще не устарело
+func (s *MemoryService) take(ctx context.Context, userID, proposalID int64) (*models.MemoryProposal, error) {
+	proposal, err := s.memoryRepo.TakeProposal(ctx, userID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(proposal.CreatedAt) > memoryProposalTTL {
+		return nil, models.ErrProposalNotFound
+	}
+	return proposal, nil
+}
+
+// RegisterMemoryTools регистрирует инструмент, которым ассистент предлагает запомнить факт
+func RegisterMemoryTools(registry *ToolRegistry, memorySvc *MemoryService) {
+	registry.MustRegister(Tool{
+		Name: "propose_memory",
+		Description: "Proposes to remember a durable fact about the user (name, language, location, profession, preferences) " +
+			"for future conversations. Use only for stable facts the user stated about themselves, never for one-off requests. " +
+			"The fact is saved only after the user confirms it with a button under your answer, " +
+			"so never claim it is already remembered. The user can review saved facts with /memory.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"fact": {"type": "string", "maxLength": 300, "description": "Short fact in third person, e.g. \"Prefers answers in English\""}
+			},
+			"required": ["fact"]
+		}`),
+		Handler: func(ctx ToolContext, arguments json.RawMessage) (string, error) {
+			var args struct {
+				Fact string `json:"fact"`
+			}
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			if len([]rune(args.Fact)) > 300 {
+				return "", fmt.Errorf("fact is too long")
+			}
+
+			proposal, err := memorySvc.Propose(ctx, ctx.UserID, args.Fact)
+			if err != nil {
+				return "", err
+			}
+
+			result := map[string]interface{}{"already_saved": true}
+			if proposal != nil {
+				result = map[string]interface{}{"awaiting_confirmation": true, "proposal_id": proposal.ID}
+			}
+			data, err := json.Marshal(result)
+			return string(data), err
+		},
+		Personal: true,
+	})
+}
+
+// formatMemoryContext оформляет факты о пользователе для системного сообщения
+func formatMemoryContext(memories []*models.Memory) string {
+	var sb strings.Builder
+	sb.WriteString("Что известно о пользователе из прошлых разговоров (учитывай это в ответах):\n")
+	for _, memory := range memories {
+		sb.WriteString("- ")
+		sb.WriteString(memory.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -2,22 +2,38 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"telegram-api/metrics"
 	"telegram-api/models"
+	"telegram-api/tracing"
+)
+
+// ErrCircuitOpen возвращается без обращения к OpenRouter, пока предохранитель разомкнут
+var ErrCircuitOpen = errors.New("openrouter circuit breaker is open")
+
+const (
+	// breakerThreshold число сбоев OpenRouter подряд, после которого запросы временно отклоняются
+	breakerThreshold = 5
+	// breakerCooldown время, в течение которого запросы отклоняются
+	breakerCooldown = 30 * time.Second
 )
 
 // OpenRouterService сервис для работы с OpenRouter API
 type OpenRouterService struct {
-	apiKey string
-	url    string
-	model  string
-	client *http.Client
+	apiKey  string
+	url     string
+	model   string
+	client  *http.Client
+	breaker *CircuitBreaker
 }
 
 // NewOpenRouterService создает новый сервис OpenRouter
@@ -26,8 +42,8 @@ func NewOpenRouterService(apiKey, url, model string) *OpenRouterService {
 		apiKey: apiKey,
 		url:    url,
 		model:  model,
+		// Общего таймаута у клиента нет: срок запроса задает контекст вызывающего
 		client: &http.Client{
-			Timeout: 15 * time.Second,
 			Transport: &http.Transport{
 				DialContext: (&net.Dialer{
 					Timeout:   5 * time.Second,
@@ -38,34 +54,193 @@ func NewOpenRouterService(apiKey, url, model string) *OpenRouterService {
 				TLSHandshakeTimeout: 5 * time.Second,
 			},
 		},
+		breaker: NewCircuitBreaker(breakerThreshold, breakerCooldown),
 	}
 }
 
+// maxToolIterations ограничивает число раундов вызова инструментов в одном ответе
+const maxToolIterations = 5
+
 // SendMessage отправляет сообщение в OpenRouter и получает ответ
-func (s *OpenRouterService) SendMessage(messages []*models.Message) (*models.Message, error) {
-	// Подготавливаем запрос
-	request := models.OpenRouterRequest{
-		Model:       s.model,
-		Messages:    make([]models.Message, len(messages)),
-		MaxTokens:   500,
-		Temperature: 0.7,
-	}
+func (s *OpenRouterService) SendMessage(ctx context.Context, messages []*models.Message) (*models.Message, error) {
+	return s.SendMessageWithTools(ctx, "", messages, nil, ToolContext{})
+}
 
+// SendMessageWithTools отправляет сообщения выбранной модели (пустая строка —
+// модель по умолчанию) с доступными инструментами.
+// Пока модель запрашивает вызовы инструментов, они выполняются, результаты
+// добавляются в диалог и модель опрашивается снова. После maxToolIterations
+// раундов инструменты больше не предлагаются, и модель обязана ответить текстом.
+func (s *OpenRouterService) SendMessageWithTools(
+	ctx context.Context,
+	model string,
+	messages []*models.Message,
+	tools *ToolRegistry,
+	toolCtx ToolContext,
+) (*models.Message, error) {
 	// Копируем сообщения
+	conversation := make([]models.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
-		request.Messages[i] = *msg
+		conversation[i] = models.ChatCompletionMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+	}
+
+	if model == "" {
+		model = s.model
+	}
+
+	for iteration := 0; ; iteration++ {
+		// Подготавливаем запрос
+		request := models.OpenRouterRequest{
+			Model:       model,
+			Messages:    conversation,
+			MaxTokens:   500,
+			Temperature: 0.7,
+		}
+		if tools != nil && iteration < maxToolIterations {
+			request.Tools = tools.Definitions()
+		}
+
+		content, toolCalls, err := s.complete(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(toolCalls) == 0 || request.Tools == nil {
+			// Создаем сообщение-ответ
+			assistantMessage := &models.Message{
+				Content:   content,
+				Role:      "assistant",
+				CreatedAt: time.Now(),
+			}
+
+			return assistantMessage, nil
+		}
+
+		// Выполняем запрошенные инструменты и передаем результаты модели
+		conversation = append(conversation, models.ChatCompletionMessage{
+			Role:      "assistant",
+			Content:   content,
+			ToolCalls: toolCalls,
+		})
+		for _, call := range toolCalls {
+			conversation = append(conversation, models.ChatCompletionMessage{
+				Role:       "tool",
+				Content:    tools.Execute(ctx, toolCtx, call),
+				ToolCallID: call.ID,
+			})
+		}
+	}
+}
+
+// complete выполняет один запрос chat completions и возвращает текст и вызовы инструментов.
+// Длительность, ошибки и расход токенов записываются в метрики по модели и в спан.
+func (s *OpenRouterService) complete(ctx context.Context, request models.OpenRouterRequest) (string, []models.ToolCall, error) {
+	ctx, span := tracing.Start(ctx, "chat "+request.Model, tracing.KindClient,
+		tracing.String("gen_ai.system", "openrouter"),
+		tracing.String("gen_ai.operation.name", "chat"),
+		tracing.String("gen_ai.request.model", request.Model),
+		tracing.Int("gen_ai.request.max_tokens", request.MaxTokens),
+		tracing.Int("gen_ai.request.tools", len(request.Tools)),
+	)
+	defer span.End()
+
+	if !s.breaker.Allow() {
+		metrics.ModelErrors.Inc(request.Model, "circuit_open")
+		span.SetAttributes(tracing.String("error.type", "circuit_open"))
+		span.RecordError(ErrCircuitOpen)
+		return "", nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	response, reason, err := s.send(ctx, request)
+	metrics.ModelRequestDuration.Observe(time.Since(start).Seconds(), request.Model)
+	switch {
+	case reason == "canceled":
+		s.breaker.Cancel()
+	case upstreamFailure(reason):
+		s.breaker.Failure()
+	default:
+		s.breaker.Success()
+	}
+	if err != nil {
+		metrics.ModelErrors.Inc(request.Model, reason)
+		span.SetAttributes(tracing.String("error.type", reason))
+		span.RecordError(err)
+		return "", nil, err
+	}
+
+	if response.Usage != nil {
+		metrics.ModelTokens.Add(float64(response.Usage.PromptTokens), request.Model, "prompt")
+		metrics.ModelTokens.Add(float64(response.Usage.CompletionTokens), request.Model, "completion")
+		span.SetAttributes(
+			tracing.Int("gen_ai.usage.input_tokens", response.Usage.PromptTokens),
+			tracing.Int("gen_ai.usage.output_tokens", response.Usage.CompletionTokens),
+		)
 	}
 
+	span.SetAttributes(tracing.String("gen_ai.response.finish_reason", response.Choices[0].FinishReason))
+	message := response.Choices[0].Message
+	return message.Content, message.ToolCalls, nil
+}
+
+// upstreamFailure сообщает, что причина ошибки — сбой или медленный ответ самого
+// OpenRouter, а не ошибка запроса
+func upstreamFailure(reason string) bool {
+	if reason == "network" || reason == "timeout" {
+		return true
+	}
+	status, err := strconv.Atoi(reason)
+	return err == nil && status >= http.StatusInternalServerError
+}
+
+// CheckCircuit проверка готовности: предохранитель не разомкнут
+func (s *OpenRouterService) CheckCircuit(context.Context) error {
+	if s.breaker.State() == CircuitOpen {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// Ping проверяет, что OpenRouter доступен и принимает API ключ
+func (s *OpenRouterService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url+"/key", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach openrouter: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("openrouter rejected the API key: %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("openrouter is unavailable: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// send отправляет запрос chat completions. При ошибке возвращает ее причину для метрик:
+// HTTP статус ответа или тип ошибки.
+func (s *OpenRouterService) send(ctx context.Context, request models.OpenRouterRequest) (*models.OpenRouterResponse, string, error) {
 	// Сериализуем в JSON
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, "request", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Создаем HTTP запрос
-	req, err := http.NewRequest("POST", s.url+"/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "request", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Устанавливаем заголовки
@@ -77,43 +252,49 @@ func (s *OpenRouterService) SendMessage(messages []*models.Message) (*models.Mes
 	// Отправляем запрос
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, networkReason(ctx), fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Читаем ответ
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, networkReason(ctx), fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Проверяем статус код
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("openrouter API error: %d - %s", resp.StatusCode, string(body))
+		return nil, strconv.Itoa(resp.StatusCode), fmt.Errorf("openrouter API error: %d - %s", resp.StatusCode, string(body))
 	}
 
 	// Парсим ответ
 	var response models.OpenRouterResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, "decode", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	// Проверяем на ошибки
 	if response.Error != nil {
-		return nil, fmt.Errorf("openrouter error: %s", response.Error.Message)
+		return nil, "api", fmt.Errorf("openrouter error: %s", response.Error.Message)
 	}
 
 	// Проверяем наличие ответа
 	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openrouter")
+		return nil, "empty", fmt.Errorf("no response from openrouter")
 	}
 
-	// Создаем сообщение-ответ
-	assistantMessage := &models.Message{
-		Content:   response.Choices[0].Message.Content,
-		Role:      "assistant",
-		CreatedAt: time.Now(),
-	}
+	return &response, "", nil
+}
 
-	return assistantMessage, nil
+// networkReason причина сетевой ошибки для метрик: клиент отменил запрос,
+// истек срок этапа или соединение с OpenRouter прервалось
+func networkReason(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.Canceled:
+		return "canceled"
+	case context.DeadlineExceeded:
+		return "timeout"
+	default:
+		return "network"
+	}
 }
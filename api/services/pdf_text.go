@@ -0,0 +1,653 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Извлечение текста из PDF без внешних зависимостей.
+// Поддерживаются несжатые и FlateDecode потоки, потоки объектов (PDF 1.5+)
+// и шрифты с таблицами ToUnicode. Отсканированные PDF (только изображения)
+// не содержат текста и возвращают пустую строку.
+
+var (
+	pdfObjectPattern    = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfRefPattern       = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	pdfToUnicodePattern = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R`)
+	pdfFontDictPattern  = regexp.MustCompile(`/Font\s*(?:<<([^<>]*)>>|(\d+)\s+\d+\s+R)`)
+	pdfContentsPattern  = regexp.MustCompile(`/Contents\s*(?:\[([^\]]*)\]|(\d+)\s+\d+\s+R)`)
+	pdfIntRefPattern    = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	pdfPagePattern      = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfIntFieldPattern  = regexp.MustCompile(`/(N|First)\s+(\d+)`)
+)
+
+// maxCMapEntries общий лимит записей таблиц ToUnicode одного документа. Обычному
+// шрифту хватает нескольких тысяч записей, а сжатый поток из тысяч bfrange
+// иначе заставит сделать миллиарды вставок в карту.
+const maxCMapEntries = 1 << 20
+
+// pdfObject представляет объект PDF со словарем и декодированным потоком
+type pdfObject struct {
+	dict   []byte
+	stream []byte
+}
+
+// pdfCMap таблица перевода кодов символов шрифта в Unicode
+type pdfCMap struct {
+	width int
+	codes map[uint32]string
+}
+
+// pdfExtractor хранит разобранные объекты документа
+type pdfExtractor struct {
+	objects map[int]*pdfObject
+	fonts   map[string]*pdfCMap
+	// remaining сколько байт еще можно распаковать из сжатых потоков
+	remaining int64
+	// cmapEntries сколько записей еще можно добавить в таблицы ToUnicode
+	cmapEntries int
+}
+
+// extractPDFText извлекает текст из PDF документа
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	e := &pdfExtractor{
+		objects:     make(map[int]*pdfObject),
+		fonts:       make(map[string]*pdfCMap),
+		remaining:   maxExtractedSize,
+		cmapEntries: maxCMapEntries,
+	}
+	if err := e.parseObjects(data); err != nil {
+		return "", err
+	}
+	e.parseObjectStreams()
+	if err := e.parseFonts(); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for _, content := range e.contentStreams() {
+		e.extractContentText(content, &out)
+		out.WriteString("\n")
+	}
+
+	return out.String(), nil
+}
+
+// parseObjects находит все объекты верхнего уровня
+func (e *pdfExtractor) parseObjects(data []byte) error {
+	matches := pdfObjectPattern.FindAllSubmatchIndex(data, -1)
+	for i, m := range matches {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		body := data[m[1]:end]
+
+		obj := &pdfObject{dict: body}
+		if idx := bytes.Index(body, []byte("stream")); idx >= 0 {
+			dict := body[:idx]
+			raw := body[idx+len("stream"):]
+			raw = bytes.TrimPrefix(raw, []byte("\r"))
+			raw = bytes.TrimPrefix(raw, []byte("\n"))
+			if endIdx := bytes.LastIndex(raw, []byte("endstream")); endIdx >= 0 {
+				raw = raw[:endIdx]
+			}
+			stream, err := e.decodeStream(dict, raw)
+			if err != nil {
+				return err
+			}
+			obj.dict = dict
+			obj.stream = stream
+		}
+		e.objects[num] = obj
+	}
+
+	return nil
+}
+
+// parseObjectStreams разворачивает сжатые потоки объектов (/Type /ObjStm)
+func (e *pdfExtractor) parseObjectStreams() {
+	for _, obj := range e.objects {
+		if obj.stream == nil || !bytes.Contains(obj.dict, []byte("/ObjStm")) {
+			continue
+		}
+
+		var n, first int
+		for _, m := range pdfIntFieldPattern.FindAllSubmatch(obj.dict, -1) {
+			value, _ := strconv.Atoi(string(m[2]))
+			if string(m[1]) == "N" {
+				n = value
+			} else {
+				first = value
+			}
+		}
+		if n == 0 || first <= 0 || first > len(obj.stream) {
+			continue
+		}
+
+		header := strings.Fields(string(obj.stream[:first]))
+		for i := 0; i+1 < len(header) && i/2 < n; i += 2 {
+			num, err1 := strconv.Atoi(header[i])
+			offset, err2 := strconv.Atoi(header[i+1])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+
+			start := first + offset
+			end := len(obj.stream)
+			if i+3 < len(header) {
+				if next, err := strconv.Atoi(header[i+3]); err == nil {
+					end = first + next
+				}
+			}
+			if start >= end || end > len(obj.stream) {
+				continue
+			}
+			if _, exists := e.objects[num]; !exists {
+				e.objects[num] = &pdfObject{dict: obj.stream[start:end]}
+			}
+		}
+	}
+}
+
+// parseFonts сопоставляет имена шрифтов из ресурсов с их таблицами ToUnicode
+func (e *pdfExtractor) parseFonts() error {
+	cmaps := make(map[int]*pdfCMap)
+	for num, obj := range e.objects {
+		m := pdfToUnicodePattern.FindSubmatch(obj.dict)
+		if m == nil {
+			continue
+		}
+		ref, _ := strconv.Atoi(string(m[1]))
+		if target, ok := e.objects[ref]; ok && target.stream != nil {
+			cmap, err := e.parseCMap(target.stream)
+			if err != nil {
+				return err
+			}
+			cmaps[num] = cmap
+		}
+	}
+
+	for _, obj := range e.objects {
+		for _, m := range pdfFontDictPattern.FindAllSubmatch(obj.dict, -1) {
+			fontDict := m[1]
+			if m[2] != nil {
+				ref, _ := strconv.Atoi(string(m[2]))
+				if target, ok := e.objects[ref]; ok {
+					fontDict = target.dict
+				}
+			}
+			for _, ref := range pdfRefPattern.FindAllSubmatch(fontDict, -1) {
+				num, _ := strconv.Atoi(string(ref[2]))
+				if cmap, ok := cmaps[num]; ok {
+					e.fonts[string(ref[1])] = cmap
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// contentStreams возвращает потоки содержимого страниц в порядке объектов
+func (e *pdfExtractor) contentStreams() [][]byte {
+	nums := make([]int, 0, len(e.objects))
+	for num := range e.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var streams [][]byte
+	for _, num := range nums {
+		obj := e.objects[num]
+		if !pdfPagePattern.Match(obj.dict) {
+			continue
+		}
+		m := pdfContentsPattern.FindSubmatch(obj.dict)
+		if m == nil {
+			continue
+		}
+		refs := m[1]
+		if m[2] != nil {
+			refs = m[0]
+		}
+		for _, ref := range pdfIntRefPattern.FindAllSubmatch(refs, -1) {
+			refNum, _ := strconv.Atoi(string(ref[1]))
+			if content, ok := e.objects[refNum]; ok && content.stream != nil {
+				streams = append(streams, content.stream)
+			}
+		}
+	}
+
+	// Если дерево страниц не найдено, используем все потоки с текстовыми операторами
+	if len(streams) == 0 {
+		for _, num := range nums {
+			obj := e.objects[num]
+			if obj.stream != nil && bytes.Contains(obj.stream, []byte("BT")) && !bytes.Contains(obj.stream, []byte("begincmap")) {
+				streams = append(streams, obj.stream)
+			}
+		}
+	}
+
+	return streams
+}
+
+// decodeStream распаковывает поток, если фильтр поддерживается. Распаковка ограничена
+// maxStreamSize на поток и остатком общего лимита документа; при превышении
+// возвращается ErrDocumentTooLarge.
+func (e *pdfExtractor) decodeStream(dict, raw []byte) ([]byte, error) {
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return raw, nil
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DCTDecode")) {
+		return nil, nil
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, nil
+	}
+	defer r.Close()
+
+	limit := min(int64(maxStreamSize), e.remaining)
+	decoded, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(decoded)) > limit {
+		return nil, ErrDocumentTooLarge
+	}
+	e.remaining -= int64(len(decoded))
+
+	switch {
+	case err == nil:
+		return decoded, nil
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, zlib.ErrChecksum):
+		// Обрезанный поток или поток с неверной контрольной суммой все равно
+		// может содержать полезный текст
+		return decoded, nil
+	default:
+		// Поврежденный поток пропускаем: остальные страницы документа могут быть читаемы
+		return nil, nil
+	}
+}
+
+// parseCMap разбирает секции bfchar и bfrange таблицы ToUnicode. Один bfrange
+// задает до 65536 записей, поэтому их общее число в документе ограничено
+// maxCMapEntries; при превышении возвращается ErrDocumentTooLarge.
+func (e *pdfExtractor) parseCMap(data []byte) (*pdfCMap, error) {
+	cmap := &pdfCMap{width: 1, codes: make(map[uint32]string)}
+
+	var operands []pdfToken
+	section := ""
+	tooLarge := false
+	add := func(code uint32, text string) bool {
+		if e.cmapEntries <= 0 {
+			tooLarge = true
+			return false
+		}
+		e.cmapEntries--
+		cmap.codes[code] = text
+		return true
+	}
+	tokenizePDF(data, func(tok pdfToken) {
+		if tooLarge {
+			return
+		}
+		if tok.kind != pdfTokenOperator {
+			operands = append(operands, tok)
+			return
+		}
+
+		switch tok.text {
+		case "beginbfchar", "beginbfrange":
+			section = tok.text
+			operands = nil
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				code, width := pdfCode(operands[i].data)
+				cmap.setWidth(width)
+				if !add(code, decodeUTF16BE(operands[i+1].data)) {
+					return
+				}
+			}
+			section, operands = "", nil
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); {
+				lo, width := pdfCode(operands[i].data)
+				hi, _ := pdfCode(operands[i+1].data)
+				cmap.setWidth(width)
+				if operands[i+2].kind == pdfTokenArrayStart {
+					j := i + 3
+					for code := lo; j < len(operands) && operands[j].kind != pdfTokenArrayEnd; code, j = code+1, j+1 {
+						if !add(code, decodeUTF16BE(operands[j].data)) {
+							return
+						}
+					}
+					i = j + 1
+					continue
+				}
+				dst := []rune(decodeUTF16BE(operands[i+2].data))
+				for code := lo; code <= hi && code-lo < 65536 && len(dst) > 0; code++ {
+					r := dst[len(dst)-1] + rune(code-lo)
+					if !add(code, string(dst[:len(dst)-1])+string(r)) {
+						return
+					}
+				}
+				i += 3
+			}
+			section, operands = "", nil
+		default:
+			if section == "" {
+				operands = nil
+			}
+		}
+	})
+
+	if tooLarge {
+		return nil, ErrDocumentTooLarge
+	}
+	return cmap, nil
+}
+
+// setWidth запоминает ширину кода символа в байтах
+func (c *pdfCMap) setWidth(width int) {
+	if width > c.width {
+		c.width = width
+	}
+}
+
+// decode переводит байты строки в текст по таблице
+func (c *pdfCMap) decode(data []byte) string {
+	var sb strings.Builder
+	for i := 0; i+c.width <= len(data); i += c.width {
+		var code uint32
+		for _, b := range data[i : i+c.width] {
+			code = code<<8 | uint32(b)
+		}
+		sb.WriteString(c.codes[code])
+	}
+	return sb.String()
+}
+
+// pdfCode возвращает числовой код и его ширину в байтах
+func pdfCode(data []byte) (uint32, int) {
+	var code uint32
+	for _, b := range data {
+		code = code<<8 | uint32(b)
+	}
+	if len(data) == 0 {
+		return 0, 1
+	}
+	return code, len(data)
+}
+
+// decodeUTF16BE декодирует строку в кодировке UTF-16BE
+func decodeUTF16BE(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeString переводит строку PDF в текст с учетом текущего шрифта
+func (e *pdfExtractor) decodeString(data []byte, cmap *pdfCMap) string {
+	if cmap != nil {
+		return cmap.decode(data)
+	}
+	if bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return decodeUTF16BE(data[2:])
+	}
+
+	// Без таблицы ToUnicode считаем кодировку однобайтовой (Latin-1)
+	var sb strings.Builder
+	for _, b := range data {
+		if b >= 0x20 || b == '\t' {
+			sb.WriteRune(rune(b))
+		}
+	}
+	return sb.String()
+}
+
+// extractContentText выполняет текстовые операторы потока содержимого
+func (e *pdfExtractor) extractContentText(content []byte, out *strings.Builder) {
+	var operands []pdfToken
+	var cmap *pdfCMap
+
+	tokenizePDF(content, func(tok pdfToken) {
+		if tok.kind != pdfTokenOperator {
+			operands = append(operands, tok)
+			return
+		}
+
+		switch tok.text {
+		case "Tf":
+			if len(operands) >= 2 && operands[len(operands)-2].kind == pdfTokenName {
+				cmap = e.fonts[operands[len(operands)-2].text]
+			}
+		case "Tj":
+			if n := len(operands); n > 0 && operands[n-1].kind == pdfTokenString {
+				out.WriteString(e.decodeString(operands[n-1].data, cmap))
+			}
+		case "'", "\"":
+			out.WriteString("\n")
+			if n := len(operands); n > 0 && operands[n-1].kind == pdfTokenString {
+				out.WriteString(e.decodeString(operands[n-1].data, cmap))
+			}
+		case "TJ":
+			for _, op := range operands {
+				switch op.kind {
+				case pdfTokenString:
+					out.WriteString(e.decodeString(op.data, cmap))
+				case pdfTokenNumber:
+					// Большой отрицательный сдвиг обычно означает пробел между словами
+					if value, err := strconv.ParseFloat(op.text, 64); err == nil && value < -200 {
+						out.WriteString(" ")
+					}
+				}
+			}
+		case "Td", "TD":
+			if n := len(operands); n >= 2 {
+				if ty, err := strconv.ParseFloat(operands[n-1].text, 64); err == nil && ty != 0 {
+					out.WriteString("\n")
+				} else {
+					out.WriteString(" ")
+				}
+			}
+		case "T*", "ET":
+			out.WriteString("\n")
+		}
+		operands = nil
+	})
+}
+
+// pdfTokenKind тип лексемы PDF
+type pdfTokenKind int
+
+const (
+	pdfTokenNumber pdfTokenKind = iota
+	pdfTokenString
+	pdfTokenName
+	pdfTokenOperator
+	pdfTokenArrayStart
+	pdfTokenArrayEnd
+	pdfTokenDict
+)
+
+// pdfToken лексема потока содержимого PDF
+type pdfToken struct {
+	kind pdfTokenKind
+	text string
+	data []byte
+}
+
+// tokenizePDF разбивает поток содержимого на лексемы
+func tokenizePDF(data []byte, emit func(pdfToken)) {
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			str, next := readPDFLiteral(data, i+1)
+			emit(pdfToken{kind: pdfTokenString, data: str})
+			i = next
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			emit(pdfToken{kind: pdfTokenDict, text: "<<"})
+			i += 2
+		case c == '>' && i+1 < len(data) && data[i+1] == '>':
+			emit(pdfToken{kind: pdfTokenDict, text: ">>"})
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return
+			}
+			emit(pdfToken{kind: pdfTokenString, data: decodePDFHex(data[i+1 : i+end])})
+			i += end + 1
+		case c == '[':
+			emit(pdfToken{kind: pdfTokenArrayStart})
+			i++
+		case c == ']':
+			emit(pdfToken{kind: pdfTokenArrayEnd})
+			i++
+		case c == '{' || c == '}' || c == ')' || c == '>':
+			i++
+		case c == '/':
+			start := i + 1
+			i++
+			for i < len(data) && !isPDFWhitespace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+			emit(pdfToken{kind: pdfTokenName, text: string(data[start:i])})
+		default:
+			start := i
+			for i < len(data) && !isPDFWhitespace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+			word := string(data[start:i])
+			if _, err := strconv.ParseFloat(word, 64); err == nil {
+				emit(pdfToken{kind: pdfTokenNumber, text: word})
+				continue
+			}
+
+			// Встроенные изображения содержат двоичные данные до оператора EI
+			if word == "ID" {
+				end := bytes.Index(data[i:], []byte("EI"))
+				if end < 0 {
+					return
+				}
+				i += end + 2
+				continue
+			}
+			emit(pdfToken{kind: pdfTokenOperator, text: word})
+		}
+	}
+}
+
+// readPDFLiteral читает литеральную строку с учетом вложенных скобок и экранирования
+func readPDFLiteral(data []byte, i int) ([]byte, int) {
+	var out []byte
+	depth := 1
+	for i < len(data) {
+		c := data[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(data) {
+				return out, i
+			}
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					j := 0
+					for ; j < 3 && i+j < len(data) && data[i+j] >= '0' && data[i+j] <= '7'; j++ {
+						value = value*8 + int(data[i+j]-'0')
+					}
+					out = append(out, byte(value))
+					i += j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+			i++
+		case '(':
+			depth++
+			out = append(out, c)
+			i++
+		case ')':
+			depth--
+			i++
+			if depth == 0 {
+				return out, i
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out, i
+}
+
+// decodePDFHex декодирует шестнадцатеричную строку PDF
+func decodePDFHex(data []byte) []byte {
+	clean := make([]byte, 0, len(data))
+	for _, c := range data {
+		if !isPDFWhitespace(c) {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	decoded, err := hex.DecodeString(string(clean))
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
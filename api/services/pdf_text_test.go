@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testPDF собирает PDF со страницей, шрифтом F1 и его таблицей ToUnicode, сжатой FlateDecode
+func testPDF(t *testing.T, cmap, content string) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	if _, err := w.Write([]byte(cmap)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Page /Resources << /Font << /F1 2 0 R >> >> /Contents 4 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Font /Subtype /Type0 /ToUnicode 3 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "3 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n", len(content), content)
+	return pdf.Bytes()
+}
+
+func TestExtractPDFTextToUnicode(t *testing.T) {
+	cmap := "begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfchar <0001> <041F> endbfchar\n" +
+		"1 beginbfrange <0002> <0003> <0440> endbfrange\n" +
+		"endcmap"
+	data := testPDF(t, cmap, "BT /F1 12 Tf <000100020003> Tj ET")
+
+	text, err := extractPDFText(data)
+	if err != nil {
+		t.Fatalf("extractPDFText() error = %v", err)
+	}
+	if got := strings.TrimSpace(text); got != "Прс" {
+		t.Errorf("extractPDFText() = %q, want %q", got, "Прс")
+	}
+}
+
+func TestExtractPDFTextRejectsOversizedBfrange(t *testing.T) {
+	// Каждый диапазон добавляет 65536 записей; поток сжимается до пары килобайт
+	ranges := maxCMapEntries/65536 + 1
+	var cmap strings.Builder
+	cmap.WriteString("begincmap\n")
+	fmt.Fprintf(&cmap, "%d beginbfrange\n", ranges)
+	for i := 0; i < ranges; i++ {
+		cmap.WriteString("<0000> <FFFF> <0041>\n")
+	}
+	cmap.WriteString("endbfrange\nendcmap")
+	data := testPDF(t, cmap.String(), "BT /F1 12 Tf <0001> Tj ET")
+
+	if len(data) > 16<<10 {
+		t.Fatalf("test PDF is %d bytes, expected a small file", len(data))
+	}
+	if _, err := extractPDFText(data); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("extractPDFText() error = %v, want ErrDocumentTooLarge", err)
+	}
+}
+
+func TestExtractPDFTextCMapLimitCountsAllSections(t *testing.T) {
+	// Секции, которые по отдельности укладываются в лимит, учитываются вместе
+	entries := 0
+	var cmap strings.Builder
+	cmap.WriteString("begincmap\n")
+	for entries <= maxCMapEntries {
+		cmap.WriteString("1 beginbfrange <0000> <7FFF> <0041> endbfrange\n")
+		entries += 0x8000
+	}
+	cmap.WriteString("endcmap")
+	data := testPDF(t, cmap.String(), "BT /F1 12 Tf <0001> Tj ET")
+
+	if _, err := extractPDFText(data); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("extractPDFText() error = %v, want ErrDocumentTooLarge", err)
+	}
+}
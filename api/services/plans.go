@@ -0,0 +1,31 @@
+package services
+
+import "telegram-api/models"
+
+// Plan тариф пользователя с дневным лимитом сообщений
+type Plan struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	DailyLimit int    `json:"daily_limit"`
+}
+
+// plans доступные тарифы; тариф по умолчанию — models.DefaultPlan
+var plans = []Plan{
+	{ID: models.DefaultPlan, Name: "Бесплатный", DailyLimit: 50},
+	{ID: "pro", Name: "Pro", DailyLimit: 500},
+}
+
+// Plans возвращает доступные тарифы
+func Plans() []Plan {
+	return plans
+}
+
+// FindPlan ищет тариф по идентификатору
+func FindPlan(id string) (Plan, bool) {
+	for _, plan := range plans {
+		if plan.ID == id {
+			return plan, true
+		}
+	}
+	return Plan{}, false
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"telegram-api/models"
+)
+
+// ErrQuotaExceeded возвращается, если дневной лимит и бонусные сообщения исчерпаны
+var ErrQuotaExceeded = errors.New("daily message limit reached")
+
+// QuotaService считает дневной лимит сообщений по тарифу пользователя.
+// После исчерпания лимита расходуются бонусные сообщения.
+type QuotaService struct {
+	accountRepo models.AccountRepository
+	messageRepo models.MessageRepository
+}
+
+// NewQuotaService создает новый сервис лимитов
+func NewQuotaService(accountRepo models.AccountRepository, messageRepo models.MessageRepository) *QuotaService {
+	return &QuotaService{
+		accountRepo: accountRepo,
+		messageRepo: messageRepo,
+	}
+}
+
+// Usage возвращает использование сообщений пользователем за сегодня
+func (s *QuotaService) Usage(ctx context.Context, userID int64) (*models.Usage, error) {
+	account, err := s.accountRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, ok := FindPlan(account.Plan)
+	if !ok {
+		plan, _ = FindPlan(models.DefaultPlan)
+	}
+
+	messageCount, err := s.messageRepo.GetUserMessageCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Usage{
+		Plan:          plan.ID,
+		DailyMessages: messageCount,
+		DailyLimit:    plan.DailyLimit,
+		BonusMessages: account.BonusMessages,
+		Remaining:     max(plan.DailyLimit-messageCount, 0) + account.BonusMessages,
+	}, nil
+}
+
+// Check проверяет, что пользователь может отправить сообщение: в дневном лимите
+// тарифа или в бонусных сообщениях еще есть место. Ничего не списывает.
+func (s *QuotaService) Check(ctx context.Context, userID int64) (*models.Usage, error) {
+	usage, err := s.Usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if usage.Remaining <= 0 {
+		return usage, ErrQuotaExceeded
+	}
+
+	return usage, nil
+}
+
+// Consume учитывает успешно отвеченное сообщение. usage — использование, полученное
+// от Check до сохранения сообщения: если дневной лимит тарифа уже был исчерпан,
+// списывается бонусное сообщение. Сообщения в пределах лимита учитываются
+// самой историей, поэтому для них ничего не делается.
+func (s *QuotaService) Consume(ctx context.Context, userID int64, usage *models.Usage) error {
+	if usage.DailyMessages < usage.DailyLimit {
+		return nil
+	}
+
+	if err := s.accountRepo.UseBonus(ctx, userID); err != nil {
+		return err
+	}
+
+	usage.BonusMessages--
+	usage.Remaining--
+	return nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"telegram-api/models"
+)
+
+// SessionService выдает короткоживущие токены сессии в обмен на проверенные initData.
+// Access токен проверяется по подписи и состоянию сессии в БД, поэтому отзыв сессии
+// действует сразу; refresh токен одноразовый и меняется при каждом обновлении.
+type SessionService struct {
+	sessionRepo models.SessionRepository
+	keys        []SigningKey // первым ключом подписываются новые токены
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+// NewSessionService создает новый сервис сессий
+func NewSessionService(sessionRepo models.SessionRepository, keys []SigningKey, accessTTL, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		keys:        keys,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// Create открывает сессию пользователя, подтвержденного initData
+func (s *SessionService) Create(ctx context.Context, user *models.TelegramWebAppData, userAgent string) (*models.SessionTokens, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:          id,
+		UserID:      user.UserID,
+		Username:    user.Username,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		UserAgent:   userAgent,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(session, now)
+}
+
+// Refresh обменивает refresh токен на новую пару токенов. Повторно предъявленный
+// старый refresh токен означает, что его могли украсть, поэтому сессия отзывается.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*models.SessionTokens, error) {
+	now := time.Now()
+	claims, err := parseToken(s.keys, refreshToken, now)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Use != tokenUseRefresh {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.sessionRepo.GetActive(ctx, claims.SessionID)
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Generation != claims.Generation {
+		slog.WarnContext(ctx, "Refresh token reused, revoking session", "user_id", session.UserID, "session_id", session.ID)
+		if err := s.sessionRepo.Revoke(ctx, session.ID); err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+
+	expiresAt := now.Add(s.refreshTTL)
+	err = s.sessionRepo.Rotate(ctx, session.ID, session.Generation, expiresAt)
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.Generation++
+	session.ExpiresAt = expiresAt
+
+	return s.issue(session, now)
+}
+
+// Authenticate проверяет access токен и возвращает действующую сессию
+func (s *SessionService) Authenticate(ctx context.Context, accessToken string) (*models.Session, error) {
+	claims, err := parseToken(s.keys, accessToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Use != tokenUseAccess {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.sessionRepo.GetActive(ctx, claims.SessionID)
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != claims.Subject {
+		return nil, ErrInvalidToken
+	}
+
+	return session, nil
+}
+
+// Revoke отзывает сессию
+func (s *SessionService) Revoke(ctx context.Context, sessionID string) error {
+	return s.sessionRepo.Revoke(ctx, sessionID)
+}
+
+// RevokeAll отзывает все сессии пользователя и возвращает их количество
+func (s *SessionService) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	return s.sessionRepo.RevokeAll(ctx, userID)
+}
+
+// issue подписывает access и refresh токены сессии
+func (s *SessionService) issue(session *models.Session, now time.Time) (*models.SessionTokens, error) {
+	accessExpiresAt := now.Add(s.accessTTL)
+	if accessExpiresAt.After(session.ExpiresAt) {
+		accessExpiresAt = session.ExpiresAt
+	}
+
+	accessToken, err := signToken(s.keys[0], sessionClaims{
+		Subject:   session.UserID,
+		SessionID: session.ID,
+		Use:       tokenUseAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: accessExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := signToken(s.keys[0], sessionClaims{
+		Subject:    session.UserID,
+		SessionID:  session.ID,
+		Use:        tokenUseRefresh,
+		Generation: session.Generation,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SessionTokens{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(accessExpiresAt.Sub(now).Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(session.ExpiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+// newSessionID генерирует случайный идентификатор сессии
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
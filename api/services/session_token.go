@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minSigningKeyLength минимальная длина ключа подписи HMAC-SHA256
+const minSigningKeyLength = 32
+
+// Ошибки проверки токенов сессии
+var (
+	ErrInvalidToken = errors.New("invalid session token")
+	ErrTokenExpired = errors.New("session token expired")
+)
+
+// Назначение токена сессии
+const (
+	tokenUseAccess  = "access"
+	tokenUseRefresh = "refresh"
+)
+
+// SigningKey ключ подписи токенов сессии; ID попадает в заголовок токена (kid),
+// чтобы после смены ключа старые токены проверялись прежним ключом
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// ParseSigningKeys разбирает ключи в формате "id:secret"
+func ParseSigningKeys(values []string) ([]SigningKey, error) {
+	keys := make([]SigningKey, 0, len(values))
+	for _, value := range values {
+		id, secret, ok := strings.Cut(value, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("signing key must be in id:secret format")
+		}
+		if len(secret) < minSigningKeyLength {
+			return nil, fmt.Errorf("signing key %q must be at least %d bytes", id, minSigningKeyLength)
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: []byte(secret)})
+	}
+	return keys, nil
+}
+
+// tokenHeader заголовок токена в формате JWT
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// sessionClaims содержимое токена сессии
+type sessionClaims struct {
+	Subject    int64  `json:"sub"` // Telegram ID пользователя
+	SessionID  string `json:"sid"`
+	Use        string `json:"use"`           // access или refresh
+	Generation int    `json:"gen,omitempty"` // номер refresh токена в сессии
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+var tokenEncoding = base64.RawURLEncoding
+
+// signToken подписывает claims ключом key (HS256)
+func signToken(key SigningKey, claims sessionClaims) (string, error) {
+	header, err := json.Marshal(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token claims: %w", err)
+	}
+
+	unsigned := tokenEncoding.EncodeToString(header) + "." + tokenEncoding.EncodeToString(payload)
+	return unsigned + "." + tokenEncoding.EncodeToString(tokenMAC(key.Secret, unsigned)), nil
+}
+
+// parseToken проверяет подпись и срок действия токена. Подпись проверяется ключом
+// из заголовка токена, поэтому токены, подписанные выведенным из ротации ключом, не принимаются.
+func parseToken(keys []SigningKey, token string, now time.Time) (*sessionClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeTokenPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := tokenEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, ok := findSigningKey(keys, header.Kid)
+	if !ok || !hmac.Equal(signature, tokenMAC(key.Secret, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims sessionClaims
+	if err := decodeTokenPart(parts[1], &claims); err != nil || claims.SessionID == "" {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+func findSigningKey(keys []SigningKey, id string) (SigningKey, bool) {
+	for _, key := range keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func decodeTokenPart(part string, v any) error {
+	data, err := tokenEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func tokenMAC(secret []byte, unsigned string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(unsigned))
+	return h.Sum(nil)
+}
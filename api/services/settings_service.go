@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"telegram-api/models"
+)
+
+var (
+	// ErrUnknownModel возвращается при выборе модели не из списка доступных
+	ErrUnknownModel = errors.New("unknown model")
+	// ErrUnknownPersona возвращается при выборе несуществующей персоны
+	ErrUnknownPersona = errors.New("unknown persona")
+)
+
+// SettingsService сервис настроек пользователей и групп: модель, персона и сброс диалога
+type SettingsService struct {
+	settingsRepo    models.SettingsRepository
+	groupRepo       models.GroupRepository
+	availableModels []string
+	defaultModel    string
+}
+
+// NewSettingsService создает новый сервис настроек.
+// Модель по умолчанию всегда входит в список доступных.
+func NewSettingsService(
+	settingsRepo models.SettingsRepository,
+	groupRepo models.GroupRepository,
+	availableModels []string,
+	defaultModel string,
+) *SettingsService {
+	available := []string{defaultModel}
+	for _, model := range availableModels {
+		if model != defaultModel {
+			available = append(available, model)
+		}
+	}
+
+	return &SettingsService{
+		settingsRepo:    settingsRepo,
+		groupRepo:       groupRepo,
+		availableModels: available,
+		defaultModel:    defaultModel,
+	}
+}
+
+// AvailableModels возвращает модели, которые может выбрать пользователь
+func (s *SettingsService) AvailableModels() []string {
+	return s.availableModels
+}
+
+// Get возвращает настройки пользователя с подставленными значениями по умолчанию.
+// Модель, исключенная из AVAILABLE_MODELS после выбора, заменяется моделью по умолчанию.
+func (s *SettingsService) Get(ctx context.Context, userID int64) (*models.UserSettings, error) {
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isAvailable(settings.Model) {
+		settings.Model = s.defaultModel
+	}
+	if _, ok := FindPersona(settings.Persona); !ok {
+		settings.Persona = DefaultPersonaID
+	}
+
+	return settings, nil
+}
+
+// Update меняет модель и/или персону пользователя
+func (s *SettingsService) Update(ctx context.Context, userID int64, req models.SettingsRequest) (*models.UserSettings, error) {
+	settings, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Model != nil {
+		if !s.isAvailable(*req.Model) {
+			return nil, ErrUnknownModel
+		}
+		settings.Model = *req.Model
+	}
+	if req.Persona != nil {
+		if _, ok := FindPersona(*req.Persona); !ok {
+			return nil, ErrUnknownPersona
+		}
+		settings.Persona = *req.Persona
+	}
+
+	settings.UpdatedAt = time.Now()
+	if err := s.settingsRepo.Save(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// ResetContext начинает новый диалог: история до этого момента не передается модели
+func (s *SettingsService) ResetContext(ctx context.Context, userID int64) error {
+	return s.settingsRepo.ResetContext(ctx, userID, time.Now())
+}
+
+// GetGroup возвращает настройки бота в группе с подставленными значениями по умолчанию
+func (s *SettingsService) GetGroup(chatID int64) (*models.GroupSettings, error) {
+	settings, err := s.groupRepo.Get(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isAvailable(settings.Model) {
+		settings.Model = s.defaultModel
+	}
+	if _, ok := FindPersona(settings.Persona); !ok {
+		settings.Persona = DefaultPersonaID
+	}
+
+	return settings, nil
+}
+
+// UpdateGroup меняет настройки бота в группе
+func (s *SettingsService) UpdateGroup(chatID int64, req models.GroupSettingsRequest) (*models.GroupSettings, error) {
+	settings, err := s.GetGroup(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Model != nil {
+		if !s.isAvailable(*req.Model) {
+			return nil, ErrUnknownModel
+		}
+		settings.Model = *req.Model
+	}
+	if req.Persona != nil {
+		if _, ok := FindPersona(*req.Persona); !ok {
+			return nil, ErrUnknownPersona
+		}
+		settings.Persona = *req.Persona
+	}
+
+	settings.UpdatedAt = time.Now()
+	if err := s.groupRepo.Save(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// ResetGroupContext начинает новый диалог в группе
+func (s *SettingsService) ResetGroupContext(chatID int64) error {
+	return s.groupRepo.ResetContext(chatID, time.Now())
+}
+
+// isAvailable проверяет, входит ли модель в список доступных
+func (s *SettingsService) isAvailable(model string) bool {
+	for _, available := range s.availableModels {
+		if available == model {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// MaxAudioSize максимальный размер аудио (ограничение Telegram на скачивание ботом)
+const MaxAudioSize = 20 << 20
+
+// Transcriber распознает речь в аудиофайле
+type Transcriber interface {
+	Transcribe(ctx context.Context, filename string, audio []byte) (string, error)
+}
+
+// OpenAITranscriber распознает речь через OpenAI-совместимый endpoint /audio/transcriptions.
+// Подходит для OpenAI, Groq и локальных серверов с тем же API (faster-whisper-server и др.).
+type OpenAITranscriber struct {
+	apiKey   string
+	url      string
+	model    string
+	language string
+	client   *http.Client
+}
+
+// NewOpenAITranscriber создает распознаватель с OpenAI-совместимым API
+func NewOpenAITranscriber(apiKey, url, model, language string) *OpenAITranscriber {
+	return &OpenAITranscriber{
+		apiKey:   apiKey,
+		url:      url,
+		model:    model,
+		language: language,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Transcribe отправляет аудио на распознавание
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, filename string, audio []byte) (string, error) {
+	fields := map[string]string{
+		"model":           t.model,
+		"response_format": "json",
+	}
+	if t.language != "" {
+		fields["language"] = t.language
+	}
+
+	headers := map[string]string{}
+	if t.apiKey != "" {
+		headers["Authorization"] = "Bearer " + t.apiKey
+	}
+
+	return postTranscription(ctx, t.client, t.url+"/audio/transcriptions", headers, fields, filename, audio)
+}
+
+// WhisperServerTranscriber распознает речь через локальный whisper.cpp server (endpoint /inference)
+type WhisperServerTranscriber struct {
+	url      string
+	language string
+	client   *http.Client
+}
+
+// NewWhisperServerTranscriber создает распознаватель для локального whisper.cpp server
+func NewWhisperServerTranscriber(url, language string) *WhisperServerTranscriber {
+	return &WhisperServerTranscriber{
+		url:      url,
+		language: language,
+		client:   &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// Transcribe отправляет аудио на распознавание
+func (t *WhisperServerTranscriber) Transcribe(ctx context.Context, filename string, audio []byte) (string, error) {
+	fields := map[string]string{
+		"response_format": "json",
+		"temperature":     "0.0",
+	}
+	if t.language != "" {
+		fields["language"] = t.language
+	}
+
+	return postTranscription(ctx, t.client, t.url+"/inference", nil, fields, filename, audio)
+}
+
+// postTranscription отправляет multipart запрос и читает поле text из ответа
+func postTranscription(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	headers, fields map[string]string,
+	filename string,
+	audio []byte,
+) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return "", fmt.Errorf("failed to write form field: %w", err)
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("failed to write audio: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription API error: %d - %s", resp.StatusCode, string(data))
+	}
+
+	var response struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return response.Text, nil
+}
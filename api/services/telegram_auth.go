@@ -1,13 +1,15 @@
 package services
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,19 +18,46 @@ import (
 	"telegram-api/models"
 )
 
-// TelegramAuthService сервис для аутентификации через Telegram WebApp
+// Публичные ключи Telegram для проверки поля signature в initData
+// (https://core.telegram.org/bots/webapps#validating-data-for-third-party-use)
+const (
+	telegramPublicKey     = "e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"
+	telegramTestPublicKey = "40055058a4ee38156a06562e52eece92a771bcd8346a8c4615cb7376eddf72ec"
+)
+
+// authDateSkew допустимое расхождение часов с Telegram для auth_date из будущего
+const authDateSkew = time.Minute
+
+// TelegramAuthService сервис для аутентификации через Telegram WebApp и Login Widget
 type TelegramAuthService struct {
-	botToken string
+	botToken  string
+	botID     string
+	maxAge    time.Duration
+	publicKey ed25519.PublicKey
 }
 
-// NewTelegramAuthService создает новый сервис аутентификации
-func NewTelegramAuthService(botToken string) *TelegramAuthService {
+// NewTelegramAuthService создает новый сервис аутентификации. initData старше maxAge
+// не принимаются; testEnvironment выбирает ключ тестового окружения Telegram для
+// проверки поля signature.
+func NewTelegramAuthService(botToken string, maxAge time.Duration, testEnvironment bool) *TelegramAuthService {
+	publicKey := telegramPublicKey
+	if testEnvironment {
+		publicKey = telegramTestPublicKey
+	}
+	key, _ := hex.DecodeString(publicKey)
+
+	botID, _, _ := strings.Cut(botToken, ":")
 	return &TelegramAuthService{
-		botToken: botToken,
+		botToken:  botToken,
+		botID:     botID,
+		maxAge:    maxAge,
+		publicKey: ed25519.PublicKey(key),
 	}
 }
 
-// ValidateWebAppData проверяет подлинность данных от Telegram WebApp
+// ValidateWebAppData проверяет подлинность данных от Telegram WebApp. Данные подписываются
+// Telegram двумя способами: hash (HMAC с ключом из токена бота) и signature (Ed25519,
+// проверяется публичным ключом Telegram без токена). Проверяется hash, а если его нет — signature.
 func (s *TelegramAuthService) ValidateWebAppData(initData string) (*models.TelegramWebAppData, error) {
 	// Парсим данные
 	params, err := url.ParseQuery(initData)
@@ -36,75 +65,131 @@ func (s *TelegramAuthService) ValidateWebAppData(initData string) (*models.Teleg
 		return nil, fmt.Errorf("failed to parse init data: %w", err)
 	}
 
-	// Извлекаем hash
+	// Повторяющиеся поля не допускаются: подпись проверяется по первому значению,
+	// и второе значение не должно попасть в обработку непроверенным
+	for key, values := range params {
+		if len(values) > 1 {
+			return nil, fmt.Errorf("duplicate field %q in init data", key)
+		}
+	}
+
+	// Проверяем подпись
 	hash := params.Get("hash")
-	if hash == "" {
-		return nil, fmt.Errorf("hash not found in init data")
+	switch {
+	case hash != "":
+		if !s.checkHMAC(params, hash, s.getSecretKey()) {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	case params.Get("signature") != "":
+		if !s.checkEd25519Signature(params) {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	default:
+		return nil, fmt.Errorf("hash or signature not found in init data")
 	}
 
-	// Удаляем hash из параметров для проверки
-	params.Del("hash")
+	// Проверяем время: данные не старше maxAge и не из будущего
+	authDate, err := s.checkAuthDate(params.Get("auth_date"))
+	if err != nil {
+		return nil, err
+	}
 
-	// Создаем строку для проверки
-	dataCheckString := s.createDataCheckString(params)
+	// Парсим данные пользователя из JSON
+	userDataStr := params.Get("user")
+	if userDataStr == "" {
+		return nil, fmt.Errorf("user not found in init data")
+	}
 
-	// Вычисляем секретный ключ
-	secretKey := s.getSecretKey()
+	var userData struct {
+		ID        int64  `json:"id"`
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	if err := json.Unmarshal([]byte(userDataStr), &userData); err != nil {
+		return nil, fmt.Errorf("invalid user data: %w", err)
+	}
+	if userData.ID <= 0 {
+		return nil, fmt.Errorf("invalid user id")
+	}
 
-	// Проверяем подпись
-	if !s.checkSignature(dataCheckString, hash, secretKey) {
-		return nil, fmt.Errorf("invalid signature")
+	return &models.TelegramWebAppData{
+		UserID:    userData.ID,
+		Username:  userData.Username,
+		FirstName: userData.FirstName,
+		LastName:  userData.LastName,
+		AuthDate:  authDate,
+		Hash:      hash,
+	}, nil
+}
+
+// ValidateLoginWidget проверяет данные Telegram Login Widget (вход на сайте вне Telegram).
+// fields — все поля, переданные виджетом, включая hash; подпись — HMAC с ключом SHA256(bot_token).
+func (s *TelegramAuthService) ValidateLoginWidget(fields map[string]string) (*models.TelegramWebAppData, error) {
+	params := make(url.Values, len(fields))
+	for key, value := range fields {
+		params.Set(key, value)
 	}
 
-	// Проверяем время (данные не старше 24 часов)
-	authDateStr := params.Get("auth_date")
-	if authDateStr == "" {
-		return nil, fmt.Errorf("auth_date not found")
+	hash := params.Get("hash")
+	if hash == "" {
+		return nil, fmt.Errorf("hash not found in login data")
+	}
+	secretKey := sha256.Sum256([]byte(s.botToken))
+	if !s.checkHMAC(params, hash, secretKey[:]) {
+		return nil, fmt.Errorf("invalid signature")
 	}
 
-	authDate, err := strconv.ParseInt(authDateStr, 10, 64)
+	authDate, err := s.checkAuthDate(params.Get("auth_date"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid auth_date: %w", err)
+		return nil, err
 	}
 
-	if time.Now().Unix()-authDate > 86400 { // 24 часа
-		return nil, fmt.Errorf("data is too old")
+	userID, err := strconv.ParseInt(params.Get("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		return nil, fmt.Errorf("invalid user id")
 	}
 
-	// Создаем структуру данных
-	webAppData := &models.TelegramWebAppData{
-		Hash: hash,
+	return &models.TelegramWebAppData{
+		UserID:    userID,
+		Username:  params.Get("username"),
+		FirstName: params.Get("first_name"),
+		LastName:  params.Get("last_name"),
+		AuthDate:  authDate,
+		Hash:      hash,
+	}, nil
+}
+
+// checkAuthDate проверяет время авторизации и возвращает его в секундах Unix
+func (s *TelegramAuthService) checkAuthDate(value string) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("auth_date not found")
 	}
 
-	// Парсим данные пользователя из JSON
-	if userDataStr := params.Get("user"); userDataStr != "" {
-		var userData struct {
-			ID        int64  `json:"id"`
-			Username  string `json:"username"`
-			FirstName string `json:"first_name"`
-			LastName  string `json:"last_name"`
-		}
+	authDate, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid auth_date: %w", err)
+	}
 
-		if err := json.Unmarshal([]byte(userDataStr), &userData); err == nil {
-			webAppData.UserID = userData.ID
-			webAppData.Username = userData.Username
-			webAppData.FirstName = userData.FirstName
-			webAppData.LastName = userData.LastName
-			log.Printf("Telegram auth: extracted UserID=%d, Username=%s", userData.ID, userData.Username)
-		} else {
-			log.Printf("Telegram auth: failed to parse user data: %v", err)
-		}
+	age := time.Since(time.Unix(authDate, 0))
+	if age < -authDateSkew {
+		return 0, fmt.Errorf("auth_date is in the future")
+	}
+	if age > s.maxAge {
+		return 0, fmt.Errorf("data is too old")
 	}
-	webAppData.AuthDate = authDate
 
-	return webAppData, nil
+	return authDate, nil
 }
 
-// createDataCheckString создает строку для проверки подписи
-func (s *TelegramAuthService) createDataCheckString(params url.Values) string {
+// createDataCheckString создает строку для проверки подписи из всех полей,
+// кроме exclude, отсортированных по имени
+func (s *TelegramAuthService) createDataCheckString(params url.Values, exclude ...string) string {
 	var keys []string
 	for key := range params {
-		keys = append(keys, key)
+		if !slices.Contains(exclude, key) {
+			keys = append(keys, key)
+		}
 	}
 	sort.Strings(keys)
 
@@ -123,10 +208,27 @@ func (s *TelegramAuthService) getSecretKey() []byte {
 	return h.Sum(nil)
 }
 
-// checkSignature проверяет подпись данных
-func (s *TelegramAuthService) checkSignature(dataCheckString, hash string, secretKey []byte) bool {
+// checkHMAC проверяет HMAC подпись всех полей, кроме hash, ключом secretKey.
+// В initData поле signature входит в подписанные данные.
+func (s *TelegramAuthService) checkHMAC(params url.Values, hash string, secretKey []byte) bool {
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+
 	h := hmac.New(sha256.New, secretKey)
-	h.Write([]byte(dataCheckString))
-	expectedHash := hex.EncodeToString(h.Sum(nil))
-	return hash == expectedHash
+	h.Write([]byte(s.createDataCheckString(params, "hash")))
+	return hmac.Equal(h.Sum(nil), expected)
+}
+
+// checkEd25519Signature проверяет подпись Telegram над строкой
+// "<bot_id>:WebAppData\n" и полями без hash и signature
+func (s *TelegramAuthService) checkEd25519Signature(params url.Values) bool {
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(params.Get("signature"), "="))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+
+	message := s.botID + ":WebAppData\n" + s.createDataCheckString(params, "hash", "signature")
+	return ed25519.Verify(s.publicKey, []byte(message), signature)
 }
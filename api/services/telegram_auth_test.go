@@ -0,0 +1,338 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testBotToken = "7342037359:AAHI25ES9xCOMPWRh7-c0jMGlRN8B3zHhbs"
+	testUser     = `{"id":279058397,"first_name":"Vladislav","last_name":"Kibenko","username":"vdkfrost","language_code":"ru","is_premium":true,"allows_write_to_pm":true}`
+
+	// hmacVector initData, подписанная hash и signature; hash проверен независимой
+	// реализацией на Python (hmac/hashlib) по алгоритму из документации Telegram
+	hmacVector = "auth_date=1700000000" +
+		"&hash=543bfd5a94023aabd3deea1466cff23887d19ca0c32947413479781981c643ed" +
+		"&query_id=AAHdF6IQAAAAAN0XohDhrOrc" +
+		"&signature=59ZnCVtDMS37T2BKsebkuLyQeNSqbDB0dC3uEKJ3dnkdJ2g4E8cyUW555d1mYJz-3gg1GS-hhjlp9Md27WhtCQ" +
+		"&user=%7B%22id%22%3A279058397%2C%22first_name%22%3A%22Vladislav%22%2C%22last_name%22%3A%22Kibenko%22" +
+		"%2C%22username%22%3A%22vdkfrost%22%2C%22language_code%22%3A%22ru%22%2C%22is_premium%22%3Atrue" +
+		"%2C%22allows_write_to_pm%22%3Atrue%7D"
+	hmacVectorAuthDate = 1700000000
+
+	// loginWidgetVectorHash подпись полей из loginWidgetVector, также проверенная на Python
+	loginWidgetVectorHash = "a31b5c457dbf498f401a05282150bfcf9303d3888207686ef1886cd4df73d64c"
+)
+
+// loginWidgetVector поля Telegram Login Widget без hash
+var loginWidgetVector = map[string]string{
+	"id":         "279058397",
+	"first_name": "Vladislav",
+	"username":   "vdkfrost",
+	"photo_url":  "https://t.me/i/userpic/320/vdkfrost.jpg",
+	"auth_date":  "1700000000",
+}
+
+// testSigningKey ключ из RFC 8032 (TEST 1, нулевой seed) вместо закрытого ключа Telegram
+var testSigningKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+// vectorAge maxAge, при котором фиксированные векторы 2023 года еще действительны
+const vectorAge = 100 * 365 * 24 * time.Hour
+
+// newTestAuthService создает сервис, проверяющий signature ключом testSigningKey
+func newTestAuthService(maxAge time.Duration) *TelegramAuthService {
+	s := NewTelegramAuthService(testBotToken, maxAge, false)
+	s.publicKey = testSigningKey.Public().(ed25519.PublicKey)
+	return s
+}
+
+// testDataCheckString строит строку проверки независимо от кода сервиса
+func testDataCheckString(params url.Values, exclude ...string) string {
+	var lines []string
+	for key := range params {
+		skip := false
+		for _, excluded := range exclude {
+			skip = skip || key == excluded
+		}
+		if !skip {
+			lines = append(lines, key+"="+params.Get(key))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// signHMAC подписывает initData полем hash так же, как Telegram
+func signHMAC(params url.Values) url.Values {
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(testBotToken))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(testDataCheckString(params, "hash")))
+	params.Set("hash", hex.EncodeToString(h.Sum(nil)))
+	return params
+}
+
+// signEd25519 подписывает initData полем signature ключом testSigningKey
+func signEd25519(params url.Values) url.Values {
+	botID, _, _ := strings.Cut(testBotToken, ":")
+	message := botID + ":WebAppData\n" + testDataCheckString(params, "hash", "signature")
+	params.Set("signature", base64.RawURLEncoding.EncodeToString(ed25519.Sign(testSigningKey, []byte(message))))
+	return params
+}
+
+// freshParams поля initData с текущим временем авторизации
+func freshParams(authDate time.Time) url.Values {
+	return url.Values{
+		"auth_date": {strconv.FormatInt(authDate.Unix(), 10)},
+		"query_id":  {"AAHdF6IQAAAAAN0XohDhrOrc"},
+		"user":      {testUser},
+	}
+}
+
+func TestValidateWebAppDataHMACVector(t *testing.T) {
+	data, err := newTestAuthService(vectorAge).ValidateWebAppData(hmacVector)
+	if err != nil {
+		t.Fatalf("valid initData rejected: %v", err)
+	}
+
+	if data.UserID != 279058397 || data.Username != "vdkfrost" || data.FirstName != "Vladislav" || data.LastName != "Kibenko" {
+		t.Errorf("unexpected user: %+v", data)
+	}
+	if data.AuthDate != hmacVectorAuthDate {
+		t.Errorf("AuthDate = %d, want %d", data.AuthDate, hmacVectorAuthDate)
+	}
+}
+
+func TestValidateWebAppDataEd25519Vector(t *testing.T) {
+	// Третья сторона получает initData без hash и проверяет только signature
+	params, _ := url.ParseQuery(hmacVector)
+	params.Del("hash")
+	initData := params.Encode()
+
+	data, err := newTestAuthService(vectorAge).ValidateWebAppData(initData)
+	if err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if data.UserID != 279058397 {
+		t.Errorf("UserID = %d, want 279058397", data.UserID)
+	}
+
+	// Подпись тестового ключа не подходит к ключам Telegram
+	for _, testEnvironment := range []bool{false, true} {
+		s := NewTelegramAuthService(testBotToken, vectorAge, testEnvironment)
+		if _, err := s.ValidateWebAppData(initData); err == nil {
+			t.Errorf("signature accepted with Telegram key (test environment %v)", testEnvironment)
+		}
+	}
+
+	// Подпись привязана к боту: у другого бота те же данные не проходят проверку
+	other := NewTelegramAuthService("1234567890:"+strings.SplitN(testBotToken, ":", 2)[1], vectorAge, false)
+	other.publicKey = testSigningKey.Public().(ed25519.PublicKey)
+	if _, err := other.ValidateWebAppData(initData); err == nil {
+		t.Error("signature accepted for a different bot id")
+	}
+}
+
+func TestValidateWebAppDataFresh(t *testing.T) {
+	s := newTestAuthService(time.Hour)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		initData string
+	}{
+		{"hmac", signHMAC(freshParams(now)).Encode()},
+		{"ed25519", signEd25519(freshParams(now)).Encode()},
+		{"hmac over signature", signHMAC(signEd25519(freshParams(now))).Encode()},
+		{"future within skew", signHMAC(freshParams(now.Add(authDateSkew / 2))).Encode()},
+		{"almost expired", signHMAC(freshParams(now.Add(-time.Hour + time.Minute))).Encode()},
+		{"padded signature", func() string {
+			params := signEd25519(freshParams(now))
+			params.Set("signature", params.Get("signature")+"==")
+			return params.Encode()
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.ValidateWebAppData(tt.initData); err != nil {
+				t.Errorf("valid initData rejected: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateWebAppDataRejects(t *testing.T) {
+	s := newTestAuthService(time.Hour)
+	now := time.Now()
+
+	// modify меняет уже подписанные поля
+	modify := func(params url.Values, change func(url.Values)) string {
+		change(params)
+		return params.Encode()
+	}
+	withUser := func(user string) url.Values {
+		params := freshParams(now)
+		params.Set("user", user)
+		return params
+	}
+	without := func(key string) url.Values {
+		params := freshParams(now)
+		params.Del(key)
+		return params
+	}
+
+	tests := []struct {
+		name     string
+		initData string
+	}{
+		{"empty", ""},
+		{"invalid encoding", "auth_date=%zz"},
+		{"no hash or signature", freshParams(now).Encode()},
+
+		{"hmac tampered user", modify(signHMAC(freshParams(now)), func(p url.Values) {
+			p.Set("user", strings.Replace(testUser, "279058397", "1", 1))
+		})},
+		{"hmac tampered auth_date", modify(signHMAC(freshParams(now)), func(p url.Values) {
+			p.Set("auth_date", strconv.FormatInt(now.Unix()+1, 10))
+		})},
+		{"hmac added field", modify(signHMAC(freshParams(now)), func(p url.Values) { p.Set("start_param", "x") })},
+		{"hmac removed field", modify(signHMAC(freshParams(now)), func(p url.Values) { p.Del("query_id") })},
+		{"hmac tampered signature field", modify(signHMAC(signEd25519(freshParams(now))), func(p url.Values) {
+			p.Set("signature", strings.Repeat("A", 86))
+		})},
+		{"hash not hex", modify(signHMAC(freshParams(now)), func(p url.Values) {
+			p.Set("hash", "zz"+p.Get("hash")[2:])
+		})},
+		{"hash truncated", modify(signHMAC(freshParams(now)), func(p url.Values) { p.Set("hash", p.Get("hash")[:62]) })},
+		{"hash odd length", modify(signHMAC(freshParams(now)), func(p url.Values) { p.Set("hash", p.Get("hash")[:63]) })},
+		{"hash of other token", modify(freshParams(now), func(p url.Values) {
+			h := hmac.New(sha256.New, []byte("WebAppData"))
+			h.Write([]byte(testDataCheckString(p)))
+			p.Set("hash", hex.EncodeToString(h.Sum(nil)))
+		})},
+		{"invalid hash with valid signature", modify(signEd25519(freshParams(now)), func(p url.Values) {
+			p.Set("hash", strings.Repeat("0", 64))
+		})},
+
+		{"signature tampered user", modify(signEd25519(freshParams(now)), func(p url.Values) {
+			p.Set("user", strings.Replace(testUser, "vdkfrost", "admin", 1))
+		})},
+		{"signature not base64", modify(signEd25519(freshParams(now)), func(p url.Values) { p.Set("signature", "!!!not base64!!!") })},
+		{"signature standard base64", modify(signEd25519(freshParams(now)), func(p url.Values) {
+			sig, _ := base64.RawURLEncoding.DecodeString(p.Get("signature"))
+			p.Set("signature", base64.StdEncoding.EncodeToString(append(sig[:63], 0xff)))
+		})},
+		{"signature truncated", modify(signEd25519(freshParams(now)), func(p url.Values) { p.Set("signature", p.Get("signature")[:80]) })},
+		{"signature flipped bit", modify(signEd25519(freshParams(now)), func(p url.Values) {
+			sig, _ := base64.RawURLEncoding.DecodeString(p.Get("signature"))
+			sig[0] ^= 1
+			p.Set("signature", base64.RawURLEncoding.EncodeToString(sig))
+		})},
+		{"signature of other key", modify(freshParams(now), func(p url.Values) {
+			other := ed25519.NewKeyFromSeed([]byte(strings.Repeat("k", ed25519.SeedSize)))
+			message := "7342037359:WebAppData\n" + testDataCheckString(p)
+			p.Set("signature", base64.RawURLEncoding.EncodeToString(ed25519.Sign(other, []byte(message))))
+		})},
+
+		{"missing user hmac", signHMAC(without("user")).Encode()},
+		{"missing user ed25519", signEd25519(without("user")).Encode()},
+		{"user id zero", signHMAC(withUser(`{"id":0,"first_name":"Zero"}`)).Encode()},
+		{"user id negative", signHMAC(withUser(`{"id":-5,"first_name":"Chat"}`)).Encode()},
+		{"user id missing", signHMAC(withUser(`{"first_name":"Nobody"}`)).Encode()},
+		{"user id string", signHMAC(withUser(`{"id":"279058397"}`)).Encode()},
+		{"user not json", signHMAC(withUser(`id=279058397`)).Encode()},
+
+		{"missing auth_date", signHMAC(without("auth_date")).Encode()},
+		{"auth_date not a number", modify(freshParams(now), func(p url.Values) {
+			p.Set("auth_date", "yesterday")
+			signHMAC(p)
+		})},
+		{"auth_date in future", signHMAC(freshParams(now.Add(authDateSkew + time.Minute))).Encode()},
+		{"auth_date far in future", signEd25519(freshParams(now.Add(365 * 24 * time.Hour))).Encode()},
+		{"auth_date expired", signHMAC(freshParams(now.Add(-time.Hour - time.Minute))).Encode()},
+		{"auth_date expired ed25519", signEd25519(freshParams(now.Add(-2 * time.Hour))).Encode()},
+		{"fixed vector expired", hmacVector},
+
+		{"duplicate user", signHMAC(freshParams(now)).Encode() + "&user=" + url.QueryEscape(`{"id":1}`)},
+		{"duplicate auth_date", signHMAC(freshParams(now)).Encode() + "&auth_date=" + strconv.FormatInt(now.Unix(), 10)},
+		{"duplicate hash", signHMAC(freshParams(now)).Encode() + "&hash=" + strings.Repeat("0", 64)},
+		{"duplicate signature", signEd25519(freshParams(now)).Encode() + "&signature=AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := s.ValidateWebAppData(tt.initData); err == nil {
+				t.Errorf("invalid initData accepted: %+v", data)
+			}
+		})
+	}
+}
+
+func TestValidateLoginWidget(t *testing.T) {
+	s := newTestAuthService(vectorAge)
+
+	fields := func(change func(map[string]string)) map[string]string {
+		result := map[string]string{"hash": loginWidgetVectorHash}
+		for key, value := range loginWidgetVector {
+			result[key] = value
+		}
+		if change != nil {
+			change(result)
+		}
+		return result
+	}
+
+	data, err := s.ValidateLoginWidget(fields(nil))
+	if err != nil {
+		t.Fatalf("valid login data rejected: %v", err)
+	}
+	if data.UserID != 279058397 || data.Username != "vdkfrost" || data.AuthDate != 1700000000 {
+		t.Errorf("unexpected user: %+v", data)
+	}
+
+	// Подпись Login Widget отличается от initData: ключ SHA256(token), а не HMAC("WebAppData")
+	params, _ := url.ParseQuery(hmacVector)
+	webApp := make(map[string]string)
+	for key := range params {
+		webApp[key] = params.Get(key)
+	}
+	if _, err := s.ValidateLoginWidget(webApp); err == nil {
+		t.Error("initData accepted as Login Widget data")
+	}
+
+	tests := []struct {
+		name   string
+		change func(map[string]string)
+	}{
+		{"tampered id", func(f map[string]string) { f["id"] = "1" }},
+		{"tampered username", func(f map[string]string) { f["username"] = "admin" }},
+		{"added field", func(f map[string]string) { f["last_name"] = "X" }},
+		{"removed field", func(f map[string]string) { delete(f, "photo_url") }},
+		{"missing hash", func(f map[string]string) { delete(f, "hash") }},
+		{"hash not hex", func(f map[string]string) { f["hash"] = "xyz" }},
+		{"empty hash", func(f map[string]string) { f["hash"] = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := s.ValidateLoginWidget(fields(tt.change)); err == nil {
+				t.Errorf("invalid login data accepted: %+v", data)
+			}
+		})
+	}
+
+	expired := newTestAuthService(time.Hour)
+	if _, err := expired.ValidateLoginWidget(fields(nil)); err == nil {
+		t.Error("expired login data accepted")
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidHTML возвращается, если текст нельзя отправить с parse_mode HTML:
+// Telegram отклоняет такое сообщение целиком
+var ErrInvalidHTML = errors.New("invalid Telegram HTML")
+
+var (
+	htmlAttributePattern = regexp.MustCompile(`^([a-z-]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'))?`)
+	htmlEntityPattern    = regexp.MustCompile(`^&(?:lt|gt|amp|quot|#[0-9]{1,7}|#x[0-9a-fA-F]{1,6});`)
+)
+
+// telegramTags теги Telegram HTML и допустимые атрибуты
+// (https://core.telegram.org/bots/api#html-style)
+var telegramTags = map[string][]string{
+	"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "ins": nil,
+	"s": nil, "strike": nil, "del": nil, "tg-spoiler": nil, "pre": nil,
+	"span":       {"class"},
+	"a":          {"href"},
+	"code":       {"class"},
+	"tg-emoji":   {"emoji-id"},
+	"blockquote": {"expandable"},
+}
+
+// ValidateTelegramHTML проверяет текст так же строго, как Telegram при parse_mode HTML:
+// только поддерживаемые теги и атрибуты, правильная вложенность, экранированные
+// символы < и & вне тегов и сущностей, непустой текст без тегов.
+func ValidateTelegramHTML(text string) error {
+	var stack []string
+	visible := false
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 {
+				return fmt.Errorf("%w: unescaped \"<\" at position %d, use &lt;", ErrInvalidHTML, i)
+			}
+			tag := text[i+1 : i+end]
+			i += end + 1
+
+			if name, ok := strings.CutPrefix(tag, "/"); ok {
+				name = strings.TrimSpace(name)
+				if len(stack) == 0 || stack[len(stack)-1] != name {
+					return fmt.Errorf("%w: unexpected closing tag </%s>", ErrInvalidHTML, name)
+				}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			name, err := checkTelegramTag(tag)
+			if err != nil {
+				return err
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				if parent == "code" || (parent == "pre" && name != "code") {
+					return fmt.Errorf("%w: <%s> cannot be nested in <%s>", ErrInvalidHTML, name, parent)
+				}
+			}
+			stack = append(stack, name)
+		case '&':
+			entity := htmlEntityPattern.FindString(text[i:])
+			if entity == "" {
+				return fmt.Errorf("%w: unescaped \"&\" at position %d, use &amp;", ErrInvalidHTML, i)
+			}
+			i += len(entity)
+			visible = true
+		default:
+			visible = visible || !strings.ContainsRune(" \t\r\n", rune(text[i]))
+			i++
+		}
+	}
+
+	if len(stack) > 0 {
+		return fmt.Errorf("%w: unclosed tag <%s>", ErrInvalidHTML, stack[len(stack)-1])
+	}
+	if !visible {
+		return fmt.Errorf("%w: message has no text outside tags", ErrInvalidHTML)
+	}
+	return nil
+}
+
+// checkTelegramTag проверяет открывающий тег и возвращает его имя
+func checkTelegramTag(tag string) (string, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(tag), " ")
+	allowed, ok := telegramTags[name]
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported tag <%s>", ErrInvalidHTML, name)
+	}
+
+	attributes := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		m := htmlAttributePattern.FindStringSubmatch(rest)
+		if m == nil {
+			return "", fmt.Errorf("%w: malformed attributes in <%s>", ErrInvalidHTML, name)
+		}
+		attribute := m[1]
+		found := false
+		for _, a := range allowed {
+			found = found || a == attribute
+		}
+		if !found {
+			return "", fmt.Errorf("%w: unsupported attribute %q in <%s>", ErrInvalidHTML, attribute, name)
+		}
+		attributes[attribute] = m[2] + m[3]
+		rest = rest[len(m[0]):]
+	}
+
+	switch name {
+	case "a":
+		if attributes["href"] == "" {
+			return "", fmt.Errorf("%w: <a> requires href", ErrInvalidHTML)
+		}
+	case "code":
+		if class, ok := attributes["class"]; ok && !strings.HasPrefix(class, "language-") {
+			return "", fmt.Errorf("%w: <code> class must be language-<name>", ErrInvalidHTML)
+		}
+	case "span":
+		if attributes["class"] != "tg-spoiler" {
+			return "", fmt.Errorf("%w: <span> is supported only with class=\"tg-spoiler\"", ErrInvalidHTML)
+		}
+	case "tg-emoji":
+		if attributes["emoji-id"] == "" {
+			return "", fmt.Errorf("%w: <tg-emoji> requires emoji-id", ErrInvalidHTML)
+		}
+	}
+	return name, nil
+}
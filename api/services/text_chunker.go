@@ -0,0 +1,158 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stemLength длина префикса слова, используемого вместо стемминга.
+// Грубо, но для русского и английского окончания обычно отсекаются.
+const stemLength = 6
+
+// ChunkText разбивает текст на фрагменты не длиннее size символов по границам абзацев
+func ChunkText(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		for _, part := range splitLongText(paragraph, size) {
+			if current.Len() > 0 && len([]rune(current.String()))+len([]rune(part))+2 > size {
+				flush()
+			}
+			if current.Len() > 0 {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(part)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitLongText делит слишком длинный абзац по пробелам
+func splitLongText(text string, size int) []string {
+	runes := []rune(text)
+	var parts []string
+	for len(runes) > size {
+		cut := size
+		for i := size; i > size/2; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// tokenizeText разбивает текст на нормализованные термы для поиска
+func tokenizeText(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) < 3 {
+			continue
+		}
+		if len(runes) > stemLength {
+			runes = runes[:stemLength]
+		}
+		terms = append(terms, string(runes))
+	}
+	return terms
+}
+
+// scoredChunk фрагмент с оценкой релевантности запросу
+type scoredChunk struct {
+	index int
+	score float64
+}
+
+// rankChunks оценивает фрагменты по запросу (упрощенный BM25) и возвращает лучшие
+func rankChunks(query string, chunks []string, limit int) []scoredChunk {
+	queryTerms := uniqueStrings(tokenizeText(query))
+	if len(queryTerms) == 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	const k1, b = 1.2, 0.75
+
+	frequencies := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	documentFrequency := make(map[string]int)
+	totalLength := 0
+	for i, chunk := range chunks {
+		terms := tokenizeText(chunk)
+		frequencies[i] = make(map[string]int)
+		for _, term := range terms {
+			frequencies[i][term]++
+		}
+		for term := range frequencies[i] {
+			documentFrequency[term]++
+		}
+		lengths[i] = len(terms)
+		totalLength += len(terms)
+	}
+	avgLength := math.Max(float64(totalLength)/float64(len(chunks)), 1)
+
+	var scored []scoredChunk
+	for i := range chunks {
+		score := 0.0
+		for _, term := range queryTerms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (float64(len(chunks))-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[i])/avgLength))
+		}
+		if score > 0 {
+			scored = append(scored, scoredChunk{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+// uniqueStrings возвращает значения без повторов, сохраняя порядок
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
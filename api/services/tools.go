@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"telegram-api/models"
+	"telegram-api/tracing"
+)
+
+// toolNamePattern допустимые имена функций в chat completions API
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolContext данные запроса, доступные инструменту при выполнении.
+// Контекст запроса заполняется в Execute.
+type ToolContext struct {
+	context.Context
+	UserID int64
+}
+
+// ToolHandler выполняет инструмент с аргументами в формате JSON и возвращает результат для модели
+type ToolHandler func(ctx ToolContext, arguments json.RawMessage) (string, error)
+
+// Tool описывает Go функцию, которую может вызвать модель
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage // JSON схема аргументов
+	Handler     ToolHandler
+	// Personal инструмент читает или меняет личные данные пользователя (история, память)
+	// и недоступен в группах, где ответ видят все участники
+	Personal bool
+}
+
+// ToolRegistry реестр инструментов, доступных ассистенту
+type ToolRegistry struct {
+	tools  []*Tool
+	byName map[string]*Tool
+}
+
+// NewToolRegistry создает пустой реестр инструментов
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		byName: make(map[string]*Tool),
+	}
+}
+
+// Register добавляет инструмент в реестр
+func (r *ToolRegistry) Register(tool Tool) error {
+	if !toolNamePattern.MatchString(tool.Name) {
+		return fmt.Errorf("invalid tool name %q", tool.Name)
+	}
+	if _, exists := r.byName[tool.Name]; exists {
+		return fmt.Errorf("tool %q already registered", tool.Name)
+	}
+	if tool.Handler == nil {
+		return fmt.Errorf("tool %q has no handler", tool.Name)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(tool.Parameters, &schema); err != nil {
+		return fmt.Errorf("tool %q has invalid parameters schema: %w", tool.Name, err)
+	}
+	if schema["type"] != "object" {
+		return fmt.Errorf("tool %q parameters schema must be an object", tool.Name)
+	}
+
+	r.tools = append(r.tools, &tool)
+	r.byName[tool.Name] = &tool
+	return nil
+}
+
+// MustRegister добавляет инструмент и паникует при ошибке; для встроенных инструментов
+func (r *ToolRegistry) MustRegister(tool Tool) {
+	if err := r.Register(tool); err != nil {
+		panic(err)
+	}
+}
+
+// Shared возвращает реестр без личных инструментов для групповых диалогов.
+// Для пустого реестра возвращает nil.
+func (r *ToolRegistry) Shared() *ToolRegistry {
+	if r == nil {
+		return nil
+	}
+
+	shared := NewToolRegistry()
+	for _, tool := range r.tools {
+		if !tool.Personal {
+			shared.tools = append(shared.tools, tool)
+			shared.byName[tool.Name] = tool
+		}
+	}
+	if len(shared.tools) == 0 {
+		return nil
+	}
+	return shared
+}
+
+// Definitions возвращает описания инструментов для запроса к модели
+func (r *ToolRegistry) Definitions() []models.ToolDefinition {
+	definitions := make([]models.ToolDefinition, len(r.tools))
+	for i, tool := range r.tools {
+		definitions[i] = models.ToolDefinition{
+			Type: "function",
+			Function: models.ToolFunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		}
+	}
+	return definitions
+}
+
+// Execute выполняет вызов инструмента. Ошибки возвращаются модели как результат,
+// чтобы она могла исправить аргументы или ответить без инструмента.
+func (r *ToolRegistry) Execute(ctx context.Context, toolCtx ToolContext, call models.ToolCall) string {
+	ctx, span := tracing.Start(ctx, "execute_tool "+call.Function.Name, tracing.KindInternal,
+		tracing.String("gen_ai.operation.name", "execute_tool"),
+		tracing.String("gen_ai.tool.name", call.Function.Name),
+	)
+	defer span.End()
+	toolCtx.Context = ctx
+
+	tool, ok := r.byName[call.Function.Name]
+	if !ok {
+		span.SetStatus(tracing.StatusError, "unknown tool")
+		return toolError(fmt.Errorf("unknown tool %q", call.Function.Name))
+	}
+
+	arguments := json.RawMessage(call.Function.Arguments)
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	if !json.Valid(arguments) {
+		span.SetStatus(tracing.StatusError, "invalid arguments")
+		return toolError(fmt.Errorf("arguments must be a JSON object"))
+	}
+
+	result, err := tool.Handler(toolCtx, arguments)
+	if err != nil {
+		span.RecordError(err)
+		slog.WarnContext(ctx, "Tool failed", "tool", tool.Name, "user_id", toolCtx.UserID, "error", err)
+		return toolError(err)
+	}
+
+	slog.DebugContext(ctx, "Tool executed", "tool", tool.Name, "user_id", toolCtx.UserID)
+	return result
+}
+
+// toolError оформляет ошибку инструмента для модели
+func toolError(err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
@@ -0,0 +1,413 @@
+// Package tracing реализует трассировку запросов в модели OpenTelemetry:
+// спаны с идентификаторами W3C Trace Context, которые экспортируются по OTLP/HTTP.
+// Без настроенного экспортера спаны не записываются и почти ничего не стоят.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID идентификатор трассы
+type TraceID [16]byte
+
+// IsValid сообщает, что идентификатор не нулевой
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID идентификатор спана
+type SpanID [8]byte
+
+// IsValid сообщает, что идентификатор не нулевой
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext идентифицирует спан внутри трассы и передается между сервисами
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // спаны трассы записываются и экспортируются
+}
+
+// IsValid сообщает, что оба идентификатора заданы
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind роль спана; значения совпадают с OTLP
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1 // операция внутри сервиса
+	KindServer   SpanKind = 2 // обработка входящего запроса
+	KindClient   SpanKind = 3 // исходящий запрос (БД, HTTP)
+)
+
+// StatusCode статус завершения спана; значения совпадают с OTLP
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute атрибут спана. Значение — string, bool, int64 или float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String создает строковый атрибут
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int создает целочисленный атрибут
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Int64 создает целочисленный атрибут
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool создает логический атрибут
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Float64 создает дробный атрибут
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Event событие внутри спана (например, ошибка)
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanData завершенный спан, который получает экспортер
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID // нулевой у корневого спана
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    []Attribute
+	Events        []Event
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span выполняемая операция. Методы безопасны для конкурентного вызова;
+// у незаписываемых спанов они ничего не делают.
+type Span struct {
+	provider *Provider
+	sc       SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext возвращает идентификаторы спана
+func (s *Span) SpanContext() SpanContext { return s.sc }
+
+// IsRecording сообщает, что спан будет экспортирован
+func (s *Span) IsRecording() bool { return s.provider != nil }
+
+// SetAttributes добавляет атрибуты спана
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetStatus задает статус завершения спана
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+}
+
+// RecordError отмечает спан ошибочным и добавляет событие exception; nil игнорируется
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Events = append(s.data.Events, Event{
+		Name:       "exception",
+		Time:       time.Now(),
+		Attributes: []Attribute{String("exception.message", err.Error())},
+	})
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End завершает спан и передает его экспортеру; повторные вызовы игнорируются
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.provider.export(data)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan сохраняет спан в контексте как текущий
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext возвращает текущий спан или nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent сохраняет родительский спан другого сервиса
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// parentFromContext возвращает родителя нового спана: текущий спан или удаленный родитель
+func parentFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Provider создает спаны и передает завершенные экспортеру
+type Provider struct {
+	exporter    Exporter
+	sampleRatio float64
+
+	// Очередь пакетной отправки; nil — спаны экспортируются синхронно в End
+	queue chan SpanData
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+const (
+	batchQueueSize     = 2048
+	batchMaxSize       = 512
+	batchFlushInterval = 5 * time.Second
+	exportTimeout      = 10 * time.Second
+)
+
+// NewProvider создает провайдер, который экспортирует каждый спан сразу при завершении.
+// Подходит для тестов с InMemoryExporter.
+func NewProvider(exporter Exporter, sampleRatio float64) *Provider {
+	return &Provider{exporter: exporter, sampleRatio: sampleRatio}
+}
+
+// NewBatchProvider создает провайдер, который копит спаны и отправляет их пакетами
+// в фоне, чтобы экспорт не задерживал запросы. При переполнении очереди спаны теряются.
+func NewBatchProvider(exporter Exporter, sampleRatio float64) *Provider {
+	p := &Provider{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		queue:       make(chan SpanData, batchQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Start создает дочерний спан текущего спана из ctx (или корневой) и возвращает
+// контекст, в котором он текущий. Спан нужно завершить вызовом End.
+func (p *Provider) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	parent := parentFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = p.sampled(sc.TraceID)
+	}
+
+	span := &Span{sc: sc}
+	if sc.Sampled && p.exporter != nil {
+		span.provider = p
+		span.data = SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Parent:      parent.SpanID,
+			StartTime:   time.Now(),
+			Attributes:  attrs,
+		}
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// sampled решает, записывать ли новую трассу. Решение детерминировано по идентификатору,
+// поэтому одинаково во всех сервисах с тем же коэффициентом.
+func (p *Provider) sampled(id TraceID) bool {
+	switch {
+	case p.exporter == nil || p.sampleRatio <= 0:
+		return false
+	case p.sampleRatio >= 1:
+		return true
+	default:
+		return binary.BigEndian.Uint64(id[8:])>>1 < uint64(p.sampleRatio*(1<<63))
+	}
+}
+
+func (p *Provider) export(data SpanData) {
+	if p.queue == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := p.exporter.Export(ctx, []SpanData{data}); err != nil {
+			slog.Warn("Error exporting spans", "error", err)
+		}
+		return
+	}
+
+	select {
+	case p.queue <- data:
+	default:
+		// Экспортер не успевает: теряем спан, а не задерживаем запрос
+	}
+}
+
+// run отправляет спаны из очереди пакетами по размеру или по таймеру
+func (p *Provider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(batchFlushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := p.exporter.Export(ctx, batch); err != nil {
+			slog.Warn("Error exporting spans", "error", err, "spans", len(batch))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case data := <-p.queue:
+			batch = append(batch, data)
+			if len(batch) >= batchMaxSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case data := <-p.queue:
+					batch = append(batch, data)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown отправляет накопленные спаны и останавливает экспортер
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.exporter == nil {
+		return nil
+	}
+	if p.queue != nil {
+		close(p.stop)
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.exporter.Shutdown(ctx)
+}
+
+// global провайдер, через который создают спаны Start и middleware
+var global atomic.Pointer[Provider]
+
+func init() {
+	SetProvider(NewProvider(nil, 0))
+}
+
+// SetProvider задает глобальный провайдер
+func SetProvider(p *Provider) {
+	global.Store(p)
+}
+
+// Start создает спан через глобальный провайдер
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	return global.Load().Start(ctx, name, kind, attrs...)
+}
+
+// Config настройки экспорта трасс
+type Config struct {
+	Endpoint    string            // адрес OTLP/HTTP коллектора; пусто — трассировка отключена
+	Headers     map[string]string // дополнительные заголовки (например, авторизация коллектора)
+	ServiceName string
+	SampleRatio float64 // доля записываемых трасс от 0 до 1
+}
+
+// Setup настраивает глобальный провайдер и возвращает функцию его остановки
+func Setup(cfg Config) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		provider := NewProvider(nil, 0)
+		SetProvider(provider)
+		return provider.Shutdown, nil
+	}
+
+	exporter, err := NewOTLPExporter(cfg.Endpoint, cfg.Headers, cfg.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewBatchProvider(exporter, cfg.SampleRatio)
+	SetProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
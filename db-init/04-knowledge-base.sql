@@ -0,0 +1,26 @@
+-- База знаний, которую наполняют администраторы
+CREATE TABLE IF NOT EXISTS kb_documents (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    uploaded_by BIGINT NOT NULL,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Фрагменты документов с эмбеддингами.
+-- Векторы хранятся в обычном массиве REAL[], сходство считается в API,
+-- поэтому расширение pgvector не требуется.
+CREATE TABLE IF NOT EXISTS kb_chunks (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES kb_documents(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    embedding REAL[] NOT NULL,
+    embedding_model VARCHAR(255) NOT NULL,
+    UNIQUE (document_id, chunk_index)
+);
+
+-- Создание индексов для оптимизации
+CREATE INDEX IF NOT EXISTS idx_kb_chunks_document_id ON kb_chunks(document_id);
+CREATE INDEX IF NOT EXISTS idx_kb_chunks_embedding_model ON kb_chunks(embedding_model);
@@ -0,0 +1,11 @@
+-- Долговременная память о пользователе (имя, язык, предпочтения)
+CREATE TABLE IF NOT EXISTS user_memories (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('user', 'assistant')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание индексов для оптимизации
+CREATE INDEX IF NOT EXISTS idx_user_memories_user_id ON user_memories(user_id);
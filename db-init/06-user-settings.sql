@@ -0,0 +1,8 @@
+-- Настройки пользователя: выбранная модель, персона и начало текущего диалога
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id BIGINT PRIMARY KEY,
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    persona VARCHAR(50) NOT NULL DEFAULT '',
+    context_reset_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,15 @@
+-- Диалоги в группах: история группы (или темы форума) общая для всех участников.
+-- Пустой conversation_id означает личный диалог пользователя с ботом.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS conversation_id VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id, created_at);
+
+-- Настройки бота в группах, которые меняют администраторы группы
+CREATE TABLE IF NOT EXISTS group_settings (
+    chat_id BIGINT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    persona VARCHAR(50) NOT NULL DEFAULT '',
+    context_reset_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
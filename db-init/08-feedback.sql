@@ -0,0 +1,20 @@
+-- Модель и персона, которыми сгенерирован ответ ассистента
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS model VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS persona VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Оценки ответов ассистента. Модель и персона копируются из ответа,
+-- чтобы отчет не зависел от последующих перегенераций.
+CREATE TABLE IF NOT EXISTS message_feedback (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating IN (-1, 1)),
+    comment TEXT NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    persona VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (message_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_feedback_created_at ON message_feedback(created_at);
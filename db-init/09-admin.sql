@@ -0,0 +1,23 @@
+-- Тариф и бонусные сообщения пользователя. Пользователи без записи
+-- работают на бесплатном тарифе.
+CREATE TABLE IF NOT EXISTS user_accounts (
+    user_id BIGINT PRIMARY KEY,
+    plan VARCHAR(20) NOT NULL DEFAULT 'free',
+    bonus_messages INTEGER NOT NULL DEFAULT 0 CHECK (bonus_messages >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Блокировки пользователей. Пустой expires_at — бессрочная блокировка,
+-- revoked_at заполняется при досрочной разблокировке.
+CREATE TABLE IF NOT EXISTS user_bans (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    admin_id BIGINT NOT NULL,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_bans_user_id ON user_bans(user_id, created_at);
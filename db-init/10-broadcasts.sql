@@ -0,0 +1,29 @@
+-- Пользователь заблокировал бота: рассылки ему больше не отправляются
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMP;
+
+-- Рассылки администраторов. Получатели выбираются при создании рассылки
+-- по фильтру segment и сохраняются в broadcast_deliveries.
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id SERIAL PRIMARY KEY,
+    text TEXT NOT NULL,
+    button_text VARCHAR(64) NOT NULL DEFAULT '',
+    button_url TEXT NOT NULL DEFAULT '',
+    segment JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'running'
+        CHECK (status IN ('running', 'paused', 'completed', 'cancelled')),
+    admin_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+    broadcast_id INTEGER NOT NULL REFERENCES broadcasts(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sent', 'failed', 'blocked')),
+    error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP,
+    PRIMARY KEY (broadcast_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcast_deliveries_pending ON broadcast_deliveries(broadcast_id) WHERE status = 'pending';
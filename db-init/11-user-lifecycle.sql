@@ -0,0 +1,14 @@
+-- Профиль и активность пользователя: обновляются ботом при каждом обращении
+-- (не чаще раза в несколько минут), blocked_at — по обновлениям my_chat_member
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language_code VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+
+-- Для существующих пользователей последней активностью считается последнее сообщение
+UPDATE users u SET last_seen_at = COALESCE(
+    (SELECT MAX(m.created_at) FROM messages m WHERE m.user_id = u.user_id),
+    u.created_at
+)
+WHERE u.last_seen_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_last_seen_at ON users(last_seen_at);
@@ -0,0 +1,10 @@
+-- Корзины ограничения частоты запросов (RATE_LIMIT_BACKEND=postgres).
+-- Таблица UNLOGGED: после сбоя БД корзины начинаются заново, зато запись не нагружает WAL.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,16 @@
+-- Персональные API ключи пользователей. Хранится только SHA-256 ключа и его начало
+-- для узнавания в списке; сам ключ показывается один раз при создании.
+-- scopes: read (чтение), chat (сообщения ассистенту), write (изменение данных).
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
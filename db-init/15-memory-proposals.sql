@@ -0,0 +1,10 @@
+-- Факты, которые ассистент предложил запомнить. В user_memories факт попадает
+-- только после подтверждения пользователем; отклоненные предложения удаляются.
+CREATE TABLE IF NOT EXISTS memory_proposals (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_memory_proposals_user_id ON memory_proposals(user_id);
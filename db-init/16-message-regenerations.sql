@@ -0,0 +1,10 @@
+-- Повторные генерации ответов. Новый ответ заменяет старый в messages, а запись
+-- здесь учитывает вызов модели в дневном лимите пользователя.
+CREATE TABLE IF NOT EXISTS message_regenerations (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_regenerations_user_id ON message_regenerations(user_id);
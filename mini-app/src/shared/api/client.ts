@@ -6,8 +6,20 @@ import {
   UserStats,
 } from "../../entities/message/types";
 
+interface SessionTokens {
+  access_token: string;
+  refresh_token: string;
+  expires_in: number;
+}
+
 class ApiClient {
   private client: AxiosInstance;
+  // Отдельный клиент без перехватчиков для выдачи и обновления токенов сессии
+  private authClient: AxiosInstance;
+  // Токены хранятся только в памяти: initData передается один раз при открытии приложения
+  private accessToken: string | null = null;
+  private refreshToken: string | null = null;
+  private pendingSession: Promise<string | null> | null = null;
 
   constructor() {
     this.client = axios.create({
@@ -17,17 +29,29 @@ class ApiClient {
         "Content-Type": "application/json",
       },
     });
+    this.authClient = axios.create({
+      baseURL: "/api/auth",
+      timeout: 10000,
+      headers: {
+        "Content-Type": "application/json",
+      },
+    });
 
     this.setupInterceptors();
   }
 
   private setupInterceptors() {
-    // Request interceptor для добавления Telegram WebApp данных
+    // Request interceptor: токен сессии, а если его получить не удалось — Telegram WebApp данные
     this.client.interceptors.request.use(
-      (config) => {
-        const tg = (window as any).Telegram?.WebApp;
-        if (tg?.initData) {
-          config.headers["X-Telegram-Init-Data"] = tg.initData;
+      async (config) => {
+        const token = await this.getAccessToken();
+        if (token) {
+          config.headers["Authorization"] = `Bearer ${token}`;
+        } else {
+          const initData = this.initData();
+          if (initData) {
+            config.headers["X-Telegram-Init-Data"] = initData;
+          }
         }
         return config;
       },
@@ -37,7 +61,18 @@ class ApiClient {
     // Response interceptor для обработки ошибок
     this.client.interceptors.response.use(
       (response) => response,
-      (error) => {
+      async (error) => {
+        const config = error.config;
+        if (error.response?.status === 401 && config && !config._retried) {
+          // Истекший или отозванный токен: обновляем сессию и повторяем запрос один раз
+          config._retried = true;
+          this.accessToken = null;
+          const token = await this.renewSession();
+          if (token) {
+            config.headers["Authorization"] = `Bearer ${token}`;
+            return this.client.request(config);
+          }
+        }
         if (error.response?.status === 401) {
           // Обработка ошибки аутентификации
           console.error("Authentication error:", error.response.data);
@@ -47,6 +82,57 @@ class ApiClient {
     );
   }
 
+  private initData(): string | undefined {
+    return (window as any).Telegram?.WebApp?.initData;
+  }
+
+  private async getAccessToken(): Promise<string | null> {
+    if (this.accessToken) {
+      return this.accessToken;
+    }
+    return this.renewSession();
+  }
+
+  // renewSession обновляет токены по refresh токену, а если это не удалось —
+  // создает новую сессию по initData. Параллельные запросы ждут одно обновление.
+  private renewSession(): Promise<string | null> {
+    if (!this.pendingSession) {
+      this.pendingSession = this.requestTokens().finally(() => {
+        this.pendingSession = null;
+      });
+    }
+    return this.pendingSession;
+  }
+
+  private async requestTokens(): Promise<string | null> {
+    try {
+      let response;
+      if (this.refreshToken) {
+        response = await this.authClient
+          .post<SessionTokens>("/refresh", { refresh_token: this.refreshToken })
+          .catch(() => null);
+      }
+      if (!response) {
+        const initData = this.initData();
+        if (!initData) {
+          return null;
+        }
+        response = await this.authClient.post<SessionTokens>("/session", {
+          init_data: initData,
+        });
+      }
+
+      this.accessToken = response.data.access_token;
+      this.refreshToken = response.data.refresh_token;
+      return this.accessToken;
+    } catch (error) {
+      console.error("Failed to create session:", error);
+      this.accessToken = null;
+      this.refreshToken = null;
+      return null;
+    }
+  }
+
   // Chat API
   async sendMessage(data: ChatRequest): Promise<ChatResponse> {
     const response = await this.client.post<ChatResponse>("/chat", data);
@@ -1,20 +1,44 @@
 package bot
 
 import (
-	"log"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
 
 	"telegram-bot/config"
 	"telegram-bot/database"
 	"telegram-bot/handlers"
+	"telegram-bot/health"
+	"telegram-bot/logging"
+	"telegram-bot/metrics"
+	"telegram-bot/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// apiCheckInterval как долго /readyz использует результат проверки API сервиса
+const apiCheckInterval = 15 * time.Second
+
 // Bot представляет Telegram бота
 type Bot struct {
-	api        *tgbotapi.BotAPI
-	dbConn     *database.Connection
-	cmdHandler *handlers.CommandHandler
+	api           *tgbotapi.BotAPI
+	apiClient     *services.APIClient
+	dbConn        *database.Connection
+	cmdHandler    *handlers.CommandHandler
+	msgHandler    *handlers.MessageHandler
+	inlineHandler *handlers.InlineHandler
+	callbacks     *handlers.CallbackDispatcher
+	banGuard      *handlers.BanGuard
+	userTracker   *handlers.UserTracker
+	broadcaster   *services.Broadcaster
+	metricsPort   string
+
+	// lastPoll время последнего успешного getUpdates (UnixNano) для проверки готовности
+	lastPoll atomic.Int64
 }
 
 // New создает новый экземпляр бота
@@ -25,8 +49,10 @@ func New(cfg *config.Config) (*Bot, error) {
 		return nil, err
 	}
 
-	botAPI.Debug = true
-	log.Printf("Authorized on account %s", botAPI.Self.UserName)
+	// Отладочный режим tgbotapi пишет в лог запросы и ответы Telegram целиком,
+	// поэтому включается только при LOG_LEVEL=debug без скрытия персональных данных
+	botAPI.Debug = cfg.LogLevel == "debug" && !cfg.LogRedactContent && !cfg.LogRedactUsernames
+	slog.Info("Authorized on account", "bot", botAPI.Self.UserName)
 
 	// Инициализация базы данных
 	dbConn, err := database.NewConnection(
@@ -45,36 +71,182 @@ func New(cfg *config.Config) (*Bot, error) {
 		return nil, err
 	}
 
+	metrics.RegisterDBStats(metrics.Default, dbConn.GetDB())
+
 	// Инициализация репозитория и обработчика
 	userRepo := database.NewUserRepository(dbConn.GetDB())
-	cmdHandler := handlers.NewCommandHandler(userRepo)
+
+	// Клиент API для запросов к ИИ
+	apiClient := services.NewAPIClient(cfg.APIURL, cfg.APIServiceToken)
+	callbacks := handlers.NewCallbackDispatcher(callbackSecret(cfg))
+	cmdHandler := handlers.NewCommandHandler(userRepo, apiClient, callbacks)
+	msgHandler := handlers.NewMessageHandler(apiClient, callbacks)
+	inlineHandler := handlers.NewInlineHandler(apiClient)
+
+	// Меню команд не критично для работы бота
+	if err := cmdHandler.PublishCommands(botAPI); err != nil {
+		slog.Error("Error publishing bot commands", "error", err)
+	}
 
 	return &Bot{
-		api:        botAPI,
-		dbConn:     dbConn,
-		cmdHandler: cmdHandler,
+		api:           botAPI,
+		apiClient:     apiClient,
+		dbConn:        dbConn,
+		cmdHandler:    cmdHandler,
+		msgHandler:    msgHandler,
+		inlineHandler: inlineHandler,
+		callbacks:     callbacks,
+		banGuard:      handlers.NewBanGuard(database.NewBanRepository(dbConn.GetDB())),
+		userTracker:   handlers.NewUserTracker(userRepo),
+		broadcaster:   services.NewBroadcaster(botAPI, database.NewBroadcastRepository(dbConn.GetDB()), userRepo),
+		metricsPort:   cfg.MetricsPort,
 	}, nil
 }
 
+// callbackSecret возвращает ключ подписи inline кнопок. Без CALLBACK_SECRET ключ
+// выводится из токена бота, чтобы кнопки оставались рабочими после перезапуска.
+func callbackSecret(cfg *config.Config) []byte {
+	if cfg.CallbackSecret != "" {
+		return []byte(cfg.CallbackSecret)
+	}
+	sum := sha256.Sum256([]byte("callback-data:" + cfg.BotToken))
+	return sum[:]
+}
+
 // Start запускает бота
 func (b *Bot) Start() {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := b.api.GetUpdatesChan(u)
+	updates := make(chan update, 100)
+	go b.pollUpdates(updates)
+	go b.broadcaster.Run()
+	go b.banGuard.Run()
+	go b.userTracker.Run()
+	go b.serveMetrics()
 
-	log.Println("Bot started, waiting for updates...")
+	slog.Info("Bot started, waiting for updates...")
 	for update := range updates {
-		if update.Message != nil {
-			b.handleMessage(update.Message)
+		kind := updateType(update.Update)
+		metrics.Updates.Inc(kind)
+
+		// Идентификатор обновления передается в API и связывает записи логов обоих сервисов
+		ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+		logUpdate(ctx, kind, update.Update)
+
+		if update.MyChatMember != nil {
+			b.userTracker.HandleMyChatMember(ctx, update.MyChatMember)
+			continue
+		}
+
+		b.userTracker.Track(update.SentFrom(), update.isPremium())
+		if b.banGuard.Reject(ctx, b.api, update.Update) {
+			continue
+		}
+
+		switch {
+		case update.Message != nil:
+			b.handleMessage(ctx, update.Message, update.threadID())
+		case update.CallbackQuery != nil:
+			go b.observe(ctx, "callback", func() { b.callbacks.Dispatch(ctx, b.api, update.CallbackQuery) })
+		case update.InlineQuery != nil:
+			go b.observe(ctx, "inline", func() { b.inlineHandler.HandleInlineQuery(ctx, b.api, update.InlineQuery) })
 		}
 	}
 }
 
-// handleMessage обрабатывает входящие сообщения
-func (b *Bot) handleMessage(message *tgbotapi.Message) {
+// logUpdate пишет полученное обновление в отладочный лог; текст и имя автора
+// скрываются согласно настройкам логирования
+func logUpdate(ctx context.Context, kind string, update tgbotapi.Update) {
+	attrs := []any{"update_id", update.UpdateID, "type", kind}
+	if user := update.SentFrom(); user != nil {
+		attrs = append(attrs, "user_id", user.ID, logging.KeyUsername, user.UserName)
+	}
+	if message := update.Message; message != nil {
+		attrs = append(attrs, "chat_id", message.Chat.ID, logging.KeyContent, message.Text)
+	}
+	slog.DebugContext(ctx, "Update received", attrs...)
+}
+
+// handleMessage обрабатывает входящие сообщения. Команды, как и остальные сообщения,
+// обращаются к API, поэтому выполняются в отдельной горутине и не задерживают
+// обработку обновлений других пользователей.
+func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message, threadID int64) {
 	if message.IsCommand() {
-		b.cmdHandler.HandleCommand(b.api, message)
+		go b.observe(ctx, "command", func() { b.cmdHandler.HandleCommand(ctx, b.api, message) })
+		return
+	}
+
+	if message.From == nil {
+		return
+	}
+
+	// В группах бот отвечает только на упоминания и ответы на свои сообщения
+	if !message.Chat.IsPrivate() {
+		if message.Text != "" {
+			go b.observe(ctx, "group", func() { b.msgHandler.HandleGroupText(ctx, b.api, message, threadID) })
+		}
+		return
+	}
+
+	switch {
+	case message.Document != nil:
+		go b.observe(ctx, "document", func() { b.msgHandler.HandleDocument(ctx, b.api, message) })
+	case message.Voice != nil || message.Audio != nil:
+		go b.observe(ctx, "voice", func() { b.msgHandler.HandleVoice(ctx, b.api, message) })
+	case message.Text != "":
+		go b.observe(ctx, "text", func() { b.msgHandler.HandleText(ctx, b.api, message) })
+	}
+}
+
+// observe выполняет обработчик и записывает его длительность. Паника в обработчике
+// считается ошибкой и не останавливает бота.
+func (b *Bot) observe(ctx context.Context, handler string, handle func()) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Panic in handler", "handler", handler, "error", r, "stack", string(debug.Stack()))
+			metrics.HandlerErrors.Inc(handler)
+		}
+		metrics.HandlerDuration.Observe(time.Since(start).Seconds(), handler)
+	}()
+
+	handle()
+}
+
+// serveMetrics запускает HTTP сервер с метриками Prometheus и проверками
+// живости (/livez) и готовности (/readyz) для docker
+func (b *Bot) serveMetrics() {
+	readiness := health.NewChecker()
+	readiness.Add("database", 2*time.Second, health.Ping(b.dbConn.GetDB()))
+	readiness.Add("api", 5*time.Second, health.Cached(apiCheckInterval, b.apiClient.Ping))
+	readiness.Add("telegram", time.Second, b.checkPolling)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	mux.Handle("/livez", health.Live())
+	mux.Handle("/readyz", readiness.Handler())
+
+	slog.Info("Starting metrics server", "port", b.metricsPort)
+	if err := http.ListenAndServe(":"+b.metricsPort, mux); err != nil {
+		slog.Error("Metrics server stopped", "error", err)
+	}
+}
+
+// updateType тип обновления Telegram для метрик
+func updateType(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.InlineQuery != nil:
+		return "inline_query"
+	case update.ChosenInlineResult != nil:
+		return "chosen_inline_result"
+	case update.MyChatMember != nil:
+		return "my_chat_member"
+	default:
+		return "other"
 	}
 }
 
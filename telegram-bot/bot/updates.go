@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// updatesTimeout время long polling запроса getUpdates, секунды
+	updatesTimeout = 60
+	// updatesRetryDelay пауза перед повтором после ошибки getUpdates
+	updatesRetryDelay = 3 * time.Second
+	// pollStaleAfter через сколько после последнего успешного getUpdates бот
+	// считается неготовым: связь с Telegram потеряна или обработка обновлений встала
+	pollStaleAfter = 2 * updatesTimeout * time.Second
+)
+
+// update обновление Telegram вместе с полями, которых нет в tgbotapi v5.5.1
+type update struct {
+	tgbotapi.Update
+	extras updateExtras
+}
+
+// updateExtras поля обновления, которые разбираются из исходного JSON
+type updateExtras struct {
+	Message *struct {
+		extrasSender
+		// MessageThreadID тема форума, в которой отправлено сообщение
+		MessageThreadID int64 `json:"message_thread_id"`
+		// IsTopicMessage сообщение отправлено в тему форума (а не просто является ответом)
+		IsTopicMessage bool `json:"is_topic_message"`
+	} `json:"message"`
+	EditedMessage *extrasSender `json:"edited_message"`
+	InlineQuery   *extrasSender `json:"inline_query"`
+	CallbackQuery *extrasSender `json:"callback_query"`
+}
+
+// extrasSender автор обновления
+type extrasSender struct {
+	From *struct {
+		IsPremium bool `json:"is_premium"`
+	} `json:"from"`
+}
+
+// isPremium сообщает, есть ли у автора обновления Telegram Premium.
+// Автор выбирается так же, как в tgbotapi.Update.SentFrom.
+func (u update) isPremium() bool {
+	var sender *extrasSender
+	switch {
+	case u.extras.Message != nil:
+		sender = &u.extras.Message.extrasSender
+	case u.extras.EditedMessage != nil:
+		sender = u.extras.EditedMessage
+	case u.extras.InlineQuery != nil:
+		sender = u.extras.InlineQuery
+	case u.extras.CallbackQuery != nil:
+		sender = u.extras.CallbackQuery
+	}
+	return sender != nil && sender.From != nil && sender.From.IsPremium
+}
+
+// threadID возвращает тему форума сообщения или 0, если сообщение не из темы
+func (u update) threadID() int64 {
+	if u.extras.Message == nil || !u.extras.Message.IsTopicMessage {
+		return 0
+	}
+	return u.extras.Message.MessageThreadID
+}
+
+// pollUpdates получает обновления через getUpdates и отправляет их в канал.
+// Библиотечный GetUpdatesChan не подходит: он отбрасывает поля, которых нет
+// в структурах tgbotapi, например тему форума.
+func (b *Bot) pollUpdates(out chan<- update) {
+	offset := 0
+	for {
+		params := tgbotapi.Params{}
+		params.AddNonZero("offset", offset)
+		params.AddNonZero("timeout", updatesTimeout)
+
+		resp, err := b.api.MakeRequest("getUpdates", params)
+		if err != nil {
+			slog.Error("Error getting updates", "error", err)
+			time.Sleep(updatesRetryDelay)
+			continue
+		}
+		b.lastPoll.Store(time.Now().UnixNano())
+
+		var raws []json.RawMessage
+		if err := json.Unmarshal(resp.Result, &raws); err != nil {
+			slog.Error("Error decoding updates", "error", err)
+			time.Sleep(updatesRetryDelay)
+			continue
+		}
+
+		for _, raw := range raws {
+			// update_id разбирается отдельно: даже обновление, которое не удалось
+			// разобрать целиком, нужно подтвердить, иначе getUpdates будет
+			// возвращать его снова
+			var id struct {
+				UpdateID int `json:"update_id"`
+			}
+			if err := json.Unmarshal(raw, &id); err != nil {
+				slog.Error("Error decoding update id", "error", err)
+				continue
+			}
+			offset = max(offset, id.UpdateID+1)
+
+			var u update
+			if err := json.Unmarshal(raw, &u.Update); err != nil {
+				slog.Error("Dropping undecodable update", "update_id", id.UpdateID, "error", err)
+				continue
+			}
+			if err := json.Unmarshal(raw, &u.extras); err != nil {
+				slog.Error("Error decoding update extras", "update_id", u.UpdateID, "error", err)
+			}
+
+			out <- u
+		}
+	}
+}
+
+// checkPolling проверка готовности: getUpdates недавно выполнялся успешно
+func (b *Bot) checkPolling(context.Context) error {
+	last := b.lastPoll.Load()
+	if last == 0 {
+		return errors.New("updates have not been polled yet")
+	}
+	if since := time.Since(time.Unix(0, last)); since > pollStaleAfter {
+		return fmt.Errorf("no successful getUpdates for %s", since.Round(time.Second))
+	}
+	return nil
+}
@@ -12,6 +12,23 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+
+	// API сервис, через который бот обращается к ИИ
+	APIURL          string
+	APIServiceToken string
+
+	// CallbackSecret ключ подписи данных inline кнопок (по умолчанию выводится из токена бота)
+	CallbackSecret string
+
+	// MetricsPort порт HTTP сервера с метриками Prometheus (/metrics)
+	MetricsPort string
+
+	// Logging: уровень (debug, info, warn, error), формат (json или text)
+	// и скрытие текстов сообщений и имен пользователей
+	LogLevel           string
+	LogFormat          string
+	LogRedactContent   bool
+	LogRedactUsernames bool
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -23,6 +40,18 @@ func Load() *Config {
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "password"),
 		DBName:     getEnv("DB_NAME", "telegram_bot"),
+
+		APIURL:          getEnv("API_URL", "http://api:8080"),
+		APIServiceToken: getEnv("API_SERVICE_TOKEN", ""),
+
+		CallbackSecret: getEnv("CALLBACK_SECRET", ""),
+
+		MetricsPort: getEnv("METRICS_PORT", "9090"),
+
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		LogFormat:          getEnv("LOG_FORMAT", "json"),
+		LogRedactContent:   getEnv("LOG_REDACT_CONTENT", "true") == "true",
+		LogRedactUsernames: getEnv("LOG_REDACT_USERNAMES", "true") == "true",
 	}
 }
 
@@ -39,6 +68,9 @@ func (c *Config) Validate() error {
 	if c.BotToken == "" {
 		return &ConfigError{Field: "TELEGRAM_BOT_TOKEN", Message: "Bot token is required"}
 	}
+	if c.APIServiceToken == "" {
+		return &ConfigError{Field: "API_SERVICE_TOKEN", Message: "API service token is required"}
+	}
 	return nil
 }
 
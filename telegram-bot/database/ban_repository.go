@@ -0,0 +1,50 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"telegram-bot/models"
+)
+
+// BanRepository реализует интерфейс models.BanRepository.
+// Блокировки создаются через админский API, бот их только читает.
+type BanRepository struct {
+	db *sql.DB
+}
+
+// NewBanRepository создает новый репозиторий блокировок
+func NewBanRepository(db *sql.DB) *BanRepository {
+	return &BanRepository{db: db}
+}
+
+// ListActive возвращает последнюю действующую блокировку каждого заблокированного пользователя
+func (r *BanRepository) ListActive() ([]models.Ban, error) {
+	query := `
+		SELECT DISTINCT ON (user_id) user_id, reason, expires_at
+		FROM user_bans
+		WHERE revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		ORDER BY user_id, created_at DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []models.Ban
+	for rows.Next() {
+		var ban models.Ban
+		if err := rows.Scan(&ban.UserID, &ban.Reason, &ban.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		bans = append(bans, ban)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+
+	return bans, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"telegram-bot/models"
+)
+
+// BroadcastRepository реализует интерфейс models.BroadcastRepository
+type BroadcastRepository struct {
+	db *sql.DB
+}
+
+// NewBroadcastRepository создает новый репозиторий рассылок
+func NewBroadcastRepository(db *sql.DB) *BroadcastRepository {
+	return &BroadcastRepository{db: db}
+}
+
+// NextBatch возвращает неотправленные сообщения активных рассылок (старые рассылки первыми)
+func (r *BroadcastRepository) NextBatch(limit int) ([]*models.BroadcastDelivery, error) {
+	query := `
+		SELECT d.broadcast_id, d.user_id, b.text, b.button_text, b.button_url
+		FROM broadcast_deliveries d
+		JOIN broadcasts b ON b.id = d.broadcast_id
+		WHERE b.status = 'running' AND d.status = 'pending'
+		ORDER BY d.broadcast_id, d.user_id
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.BroadcastDelivery
+	for rows.Next() {
+		delivery := &models.BroadcastDelivery{}
+		err := rows.Scan(
+			&delivery.BroadcastID,
+			&delivery.UserID,
+			&delivery.Text,
+			&delivery.ButtonText,
+			&delivery.ButtonURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan broadcast delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating broadcast deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkDelivery сохраняет результат отправки сообщения рассылки
+func (r *BroadcastRepository) MarkDelivery(delivery *models.BroadcastDelivery, status, errorText string) error {
+	_, err := r.db.Exec(`
+		UPDATE broadcast_deliveries
+		SET status = $1, error = $2, sent_at = CURRENT_TIMESTAMP
+		WHERE broadcast_id = $3 AND user_id = $4
+	`, status, errorText, delivery.BroadcastID, delivery.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast delivery: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteFinished завершает активные рассылки, в которых не осталось неотправленных сообщений
+func (r *BroadcastRepository) CompleteFinished() error {
+	_, err := r.db.Exec(`
+		UPDATE broadcasts b
+		SET status = 'completed', finished_at = CURRENT_TIMESTAMP
+		WHERE b.status = 'running'
+		AND NOT EXISTS (
+			SELECT 1 FROM broadcast_deliveries d
+			WHERE d.broadcast_id = b.id AND d.status = 'pending'
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to complete broadcasts: %w", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"telegram-bot/models"
@@ -20,12 +21,15 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Save сохраняет пользователя в базе данных
 func (r *UserRepository) Save(user *models.User) error {
 	query := `
-		INSERT INTO users (user_id, username, first_name, last_name, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (user_id, username, first_name, last_name, language_code, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
 		ON CONFLICT (user_id) DO UPDATE SET
 			username = EXCLUDED.username,
 			first_name = EXCLUDED.first_name,
-			last_name = EXCLUDED.last_name
+			last_name = EXCLUDED.last_name,
+			language_code = EXCLUDED.language_code,
+			last_seen_at = EXCLUDED.last_seen_at,
+			blocked_at = NULL
 		RETURNING id
 	`
 
@@ -35,6 +39,7 @@ func (r *UserRepository) Save(user *models.User) error {
 		user.Username,
 		user.FirstName,
 		user.LastName,
+		user.LanguageCode,
 		user.CreatedAt,
 	).Scan(&user.ID)
 
@@ -44,3 +49,84 @@ func (r *UserRepository) Save(user *models.User) error {
 
 	return nil
 }
+
+// Touch обновляет профиль и время последней активности пользователя, который уже
+// запускал бота. Возвращает false, если пользователя еще нет в базе.
+func (r *UserRepository) Touch(user *models.User) (bool, error) {
+	query := `
+		UPDATE users SET
+			username = $2,
+			first_name = $3,
+			last_name = $4,
+			language_code = $5,
+			is_premium = $6,
+			last_seen_at = $7
+		WHERE user_id = $1
+	`
+
+	result, err := r.db.Exec(
+		query,
+		user.UserID,
+		user.Username,
+		user.FirstName,
+		user.LastName,
+		user.LanguageCode,
+		user.IsPremium,
+		user.LastSeenAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update user activity: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update user activity: %w", err)
+	}
+
+	return updated > 0, nil
+}
+
+// FindByUsername находит пользователя по username (без учета регистра) или возвращает nil
+func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
+	query := `
+		SELECT id, user_id, COALESCE(username, ''), COALESCE(first_name, ''), COALESCE(last_name, ''),
+			language_code, is_premium, created_at, last_seen_at
+		FROM users
+		WHERE LOWER(username) = LOWER($1)
+	`
+
+	user := &models.User{}
+	err := r.db.QueryRow(query, username).Scan(
+		&user.ID,
+		&user.UserID,
+		&user.Username,
+		&user.FirstName,
+		&user.LastName,
+		&user.LanguageCode,
+		&user.IsPremium,
+		&user.CreatedAt,
+		&user.LastSeenAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return user, nil
+}
+
+// SetBlocked отмечает, что пользователь заблокировал бота (или снова его запустил)
+func (r *UserRepository) SetBlocked(userID int64, blocked bool) error {
+	query := `UPDATE users SET blocked_at = NULL WHERE user_id = $1`
+	if blocked {
+		query = `UPDATE users SET blocked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND blocked_at IS NULL`
+	}
+
+	if _, err := r.db.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to update user blocked status: %w", err)
+	}
+
+	return nil
+}
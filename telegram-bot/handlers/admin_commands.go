@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxBanDurationHours наибольший срок блокировки, который принимает API (10 лет)
+const maxBanDurationHours = 87600
+
+// banUsage справка по командам блокировки
+const banUsage = "Использование:\n" +
+	"/ban <ID или @username> [срок] [причина] — заблокировать пользователя\n" +
+	"/unban <ID или @username> — снять блокировку\n\n" +
+	"Срок задается как 12h или 7d (не больше 3650d), без срока блокировка бессрочная. " +
+	"Вместо ID можно ответить командой на сообщение пользователя."
+
+// handleBanCommand обрабатывает команду /ban. Права проверяет API: команда
+// доступна только пользователям из ADMIN_TELEGRAM_IDS.
+func (h *CommandHandler) handleBanCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	userID, args, ok := h.commandTarget(ctx, message)
+	if !ok {
+		h.replyCommand(bot, message, banUsage)
+		return
+	}
+
+	hours := 0
+	if len(args) > 0 {
+		if parsed, ok := parseBanDuration(args[0]); ok {
+			hours = parsed
+			args = args[1:]
+		}
+	}
+	reason := strings.Join(args, " ")
+
+	ban, err := h.apiClient.BanUser(ctx, message.From.ID, userID, reason, hours)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error banning user", "error", err)
+		h.replyCommand(bot, message, adminErrorText(err))
+		return
+	}
+
+	text := fmt.Sprintf("⛔️ Пользователь %d заблокирован", userID)
+	if ban.ExpiresAt != nil {
+		text += " до " + ban.ExpiresAt.Format("02.01.2006 15:04") + " (UTC)"
+	} else {
+		text += " бессрочно"
+	}
+	if ban.Reason != "" {
+		text += ".\nПричина: " + ban.Reason
+	}
+	h.replyCommand(bot, message, text)
+}
+
+// handleUnbanCommand обрабатывает команду /unban
+func (h *CommandHandler) handleUnbanCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	userID, _, ok := h.commandTarget(ctx, message)
+	if !ok {
+		h.replyCommand(bot, message, banUsage)
+		return
+	}
+
+	err := h.apiClient.UnbanUser(ctx, message.From.ID, userID)
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		h.replyCommand(bot, message, "Пользователь не заблокирован.")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error unbanning user", "error", err)
+		h.replyCommand(bot, message, adminErrorText(err))
+		return
+	}
+
+	h.replyCommand(bot, message, fmt.Sprintf("✅ Блокировка пользователя %d снята.", userID))
+}
+
+// commandTarget определяет пользователя, к которому относится админская команда:
+// автора сообщения, на которое ответили командой, или первый аргумент (ID или @username).
+// Возвращает оставшиеся аргументы команды.
+func (h *CommandHandler) commandTarget(ctx context.Context, message *tgbotapi.Message) (int64, []string, bool) {
+	args := strings.Fields(message.CommandArguments())
+
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		return reply.From.ID, args, true
+	}
+	if len(args) == 0 {
+		return 0, nil, false
+	}
+
+	if userID, err := strconv.ParseInt(args[0], 10, 64); err == nil && userID > 0 {
+		return userID, args[1:], true
+	}
+
+	if !strings.HasPrefix(args[0], "@") {
+		return 0, nil, false
+	}
+	user, err := h.userRepo.FindByUsername(strings.TrimPrefix(args[0], "@"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "error", err)
+		return 0, nil, false
+	}
+	if user == nil {
+		return 0, nil, false
+	}
+	return user.UserID, args[1:], true
+}
+
+// parseBanDuration разбирает срок блокировки вида 12h или 7d в часы
+func parseBanDuration(value string) (int, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || number <= 0 || number > maxBanDurationHours {
+		return 0, false
+	}
+
+	hours := number
+	switch value[len(value)-1] {
+	case 'h':
+	case 'd':
+		hours = number * 24
+	default:
+		return 0, false
+	}
+	if hours > maxBanDurationHours {
+		return 0, false
+	}
+	return hours, true
+}
+
+// adminErrorText описание ошибки админского API
+func adminErrorText(err error) string {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		return "Команда доступна только администраторам бота."
+	}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return "Некорректный запрос: " + apiErr.Message
+	}
+	return "Не удалось выполнить команду, попробуйте позже."
+}
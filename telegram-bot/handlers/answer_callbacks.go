@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок под ответами ИИ
+const (
+	callbackRegenerate = "rg"
+	callbackContinue   = "ct"
+	callbackRate       = "rt"
+	callbackComment    = "cm"
+)
+
+// feedbackCommentTTL сколько ждать комментарий к оценке после нажатия кнопки
+const feedbackCommentTTL = 10 * time.Minute
+
+// pendingComment ожидаемый комментарий к оценке ответа
+type pendingComment struct {
+	chatID    int64
+	promptID  int // сообщение с просьбой написать комментарий
+	messageID int64
+	rating    int
+	expiresAt time.Time
+}
+
+// registerAnswerCallbacks регистрирует обработчики кнопок под ответами
+func (h *MessageHandler) registerAnswerCallbacks() {
+	h.callbacks.Register(callbackRegenerate, h.handleRegenerateCallback)
+	h.callbacks.Register(callbackContinue, h.handleContinueCallback)
+	h.callbacks.Register(callbackRate, h.handleRateCallback)
+	h.callbacks.Register(callbackComment, h.handleCommentCallback)
+}
+
+// answerKeyboard кнопки под ответом ассистента; rating отмечает выбранную оценку (1, -1 или 0)
+func (h *MessageHandler) answerKeyboard(messageID int64, rating int) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(messageID, 10)
+
+	like, dislike := "👍", "👎"
+	switch rating {
+	case 1:
+		like = "✅ 👍"
+	case -1:
+		dislike = "✅ 👎"
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button("🔄 Ещё раз", callbackRegenerate, id),
+			h.callbacks.Button("▶️ Продолжить", callbackContinue, id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button(like, callbackRate, id, "1"),
+			h.callbacks.Button(dislike, callbackRate, id, "-1"),
+		),
+	}
+	if rating != 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button("💬 Комментарий", callbackComment, id, strconv.Itoa(rating)),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleRegenerateCallback заново генерирует ответ и отправляет его новым сообщением
+func (h *MessageHandler) handleRegenerateCallback(ctx *CallbackContext) {
+	messageID, err := strconv.ParseInt(ctx.Arg(0), 10, 64)
+	if err != nil || ctx.Query.Message == nil {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	// Генерация занимает больше времени, чем Telegram ждет ответа на нажатие
+	ctx.Answer("⏳ Генерирую новый ответ…")
+	chatID := ctx.Query.Message.Chat.ID
+	ctx.Bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+
+	response, err := h.apiClient.Regenerate(ctx, ctx.UserID(), messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error regenerating answer", "error", err)
+		reportError("regenerate", err)
+		h.send(ctx.Bot, chatID, answerCallbackErrorText(err))
+		return
+	}
+
+	h.replyAnswer(ctx.Bot, chatID, callbackReplyTo(ctx), response)
+}
+
+// handleContinueCallback просит модель продолжить оборвавшийся ответ
+func (h *MessageHandler) handleContinueCallback(ctx *CallbackContext) {
+	messageID, err := strconv.ParseInt(ctx.Arg(0), 10, 64)
+	if err != nil || ctx.Query.Message == nil {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	ctx.Answer("⏳ Продолжаю…")
+	chatID := ctx.Query.Message.Chat.ID
+	ctx.Bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+
+	response, err := h.apiClient.Continue(ctx, ctx.UserID(), messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error continuing answer", "error", err)
+		reportError("continue", err)
+		h.send(ctx.Bot, chatID, answerCallbackErrorText(err))
+		return
+	}
+
+	h.replyAnswer(ctx.Bot, chatID, callbackReplyTo(ctx), response)
+}
+
+// callbackReplyTo возвращает сообщение, на которое отвечать новым ответом: в группах
+// это сообщение с кнопкой, чтобы ответ остался в той же теме форума
+func callbackReplyTo(ctx *CallbackContext) int {
+	if ctx.Query.Message.Chat.IsPrivate() {
+		return 0
+	}
+	return ctx.Query.Message.MessageID
+}
+
+// answerCallbackErrorText описание ошибки для кнопок под ответом. Ответы хранятся
+// у автора вопроса, поэтому для остальных участников группы ответ "не найден".
+func answerCallbackErrorText(err error) string {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return "Этот ответ недоступен: кнопки работают только у автора вопроса."
+	}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		return "Бот выключен в этом чате."
+	}
+	return apiErrorText(err)
+}
+
+// handleRateCallback сохраняет оценку ответа и отмечает ее на кнопках
+func (h *MessageHandler) handleRateCallback(ctx *CallbackContext) {
+	messageID, rating, ok := ratingArgs(ctx)
+	if !ok {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	if err := h.apiClient.SendFeedback(ctx, ctx.UserID(), messageID, rating, ""); err != nil {
+		slog.ErrorContext(ctx, "Error sending feedback", "error", err)
+		reportError("feedback", err)
+		ctx.Answer(feedbackErrorText(err))
+		return
+	}
+
+	ctx.Answer("Спасибо за отзыв!")
+	ctx.EditKeyboard(h.answerKeyboard(messageID, rating))
+}
+
+// handleCommentCallback просит написать комментарий к оценке ответом на сообщение бота
+func (h *MessageHandler) handleCommentCallback(ctx *CallbackContext) {
+	messageID, rating, ok := ratingArgs(ctx)
+	if !ok || ctx.Query.Message == nil {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	ctx.Answer("")
+	chatID := ctx.Query.Message.Chat.ID
+	msg := tgbotapi.NewMessage(chatID, "💬 Напишите комментарий к ответу в ответ на это сообщение.")
+	msg.ReplyToMessageID = callbackReplyTo(ctx)
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, InputFieldPlaceholder: "Что не так с ответом?"}
+	prompt, err := ctx.Bot.Send(msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	h.pendingComments[ctx.UserID()] = pendingComment{
+		chatID:    chatID,
+		promptID:  prompt.MessageID,
+		messageID: messageID,
+		rating:    rating,
+		expiresAt: time.Now().Add(feedbackCommentTTL),
+	}
+	h.mu.Unlock()
+}
+
+// takeFeedbackComment сохраняет сообщение как комментарий к оценке, если оно
+// отвечает на просьбу бота написать комментарий
+func (h *MessageHandler) takeFeedbackComment(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	if message.ReplyToMessage == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	pending, ok := h.pendingComments[message.From.ID]
+	expired := ok && time.Now().After(pending.expiresAt)
+	matches := ok && pending.chatID == message.Chat.ID && pending.promptID == message.ReplyToMessage.MessageID
+	if expired || matches {
+		delete(h.pendingComments, message.From.ID)
+	}
+	h.mu.Unlock()
+	if !matches || expired {
+		return false
+	}
+
+	comment := truncateRunes(message.Text, maxFeedbackComment)
+	if err := h.apiClient.SendFeedback(ctx, message.From.ID, pending.messageID, pending.rating, comment); err != nil {
+		slog.ErrorContext(ctx, "Error sending feedback comment", "error", err)
+		reportError("feedback", err)
+		h.reply(bot, message, feedbackErrorText(err))
+		return true
+	}
+
+	h.reply(bot, message, "Спасибо, комментарий сохранён!")
+	return true
+}
+
+// ratingArgs разбирает идентификатор ответа и оценку из аргументов кнопки
+func ratingArgs(ctx *CallbackContext) (int64, int, bool) {
+	messageID, err := strconv.ParseInt(ctx.Arg(0), 10, 64)
+	rating, ratingErr := strconv.Atoi(ctx.Arg(1))
+	if err != nil || ratingErr != nil || (rating != 1 && rating != -1) {
+		return 0, 0, false
+	}
+	return messageID, rating, true
+}
+
+// feedbackErrorText описание ошибки сохранения оценки
+func feedbackErrorText(err error) string {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return "Оценить ответ может только автор вопроса."
+	}
+	return "Не удалось сохранить отзыв, попробуйте позже."
+}
+
+// send отправляет текстовое сообщение в чат
+func (h *MessageHandler) send(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		slog.Error("Error sending message", "error", err)
+	}
+}
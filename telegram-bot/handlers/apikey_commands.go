@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// apiKeyScopes области действия API ключей, которые понимает API
+var apiKeyScopes = []string{"read", "chat", "write"}
+
+// apiKeyUsage справка по команде /apikey
+const apiKeyUsage = "Использование:\n" +
+	"/apikey — ваши API ключи\n" +
+	"/apikey new <название> [области] — создать ключ\n" +
+	"/apikey revoke <ID> — отозвать ключ\n\n" +
+	"Области через запятую: read — чтение истории и настроек, chat — сообщения ассистенту, " +
+	"write — изменение настроек, памяти и документов. По умолчанию read,chat.\n" +
+	"Ключ передается в заголовке Authorization: Bearer <ключ>, запросы по ключу расходуют те же лимиты, что и сообщения в боте."
+
+// handleAPIKeyCommand обрабатывает команду /apikey. Ключи выдаются только в личном
+// чате, чтобы их не увидели участники группы.
+func (h *CommandHandler) handleAPIKeyCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if !message.Chat.IsPrivate() {
+		h.replyCommand(bot, message, "Команда /apikey работает только в личном чате с ботом.")
+		return
+	}
+
+	userID := message.From.ID
+	args := strings.Fields(message.CommandArguments())
+
+	var text string
+	switch {
+	case len(args) == 0:
+		text = h.apiKeyListText(ctx, userID)
+	case args[0] == "new" && len(args) > 1:
+		text = h.createAPIKey(ctx, userID, args[1:])
+	case args[0] == "revoke" && len(args) == 2:
+		text = h.revokeAPIKey(ctx, userID, args[1])
+	default:
+		text = apiKeyUsage
+	}
+
+	h.send(bot, message.Chat.ID, text)
+}
+
+// apiKeyListText формирует список действующих ключей пользователя
+func (h *CommandHandler) apiKeyListText(ctx context.Context, userID int64) string {
+	keys, err := h.apiClient.ListAPIKeys(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing api keys", "error", err)
+		return apiErrorText(err)
+	}
+	if len(keys) == 0 {
+		return "У вас нет API ключей.\n\n" + apiKeyUsage
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🔑 Ваши API ключи:\n\n")
+	for _, key := range keys {
+		lastUsed := "не использовался"
+		if key.LastUsedAt != nil {
+			lastUsed = "использован " + key.LastUsedAt.Format("02.01.2006 15:04") + " (UTC)"
+		}
+		fmt.Fprintf(&sb, "ID %d · %s · %s…\nобласти: %s, %s\n\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","), lastUsed)
+	}
+	sb.WriteString("Отозвать ключ: /apikey revoke <ID>")
+	return sb.String()
+}
+
+// createAPIKey создает ключ; последний аргумент считается списком областей,
+// если состоит только из известных областей
+func (h *CommandHandler) createAPIKey(ctx context.Context, userID int64, args []string) string {
+	var scopes []string
+	if len(args) > 1 {
+		if parsed, ok := parseAPIKeyScopes(args[len(args)-1]); ok {
+			scopes = parsed
+			args = args[:len(args)-1]
+		}
+	}
+	name := strings.Join(args, " ")
+
+	key, err := h.apiClient.CreateAPIKey(ctx, userID, name, scopes)
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		return "У вас слишком много ключей. Отзовите ненужные: /apikey revoke <ID>."
+	}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return "Некорректный запрос: " + apiErr.Message
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating api key", "error", err)
+		return apiErrorText(err)
+	}
+
+	return fmt.Sprintf(
+		"🔑 Ключ «%s» создан (ID %d, области: %s):\n\n%s\n\n"+
+			"Сохраните его сейчас: ключ больше не будет показан. Удалите это сообщение после копирования.",
+		key.Name, key.ID, strings.Join(key.Scopes, ","), key.Key,
+	)
+}
+
+// revokeAPIKey отзывает ключ по ID из списка /apikey
+func (h *CommandHandler) revokeAPIKey(ctx context.Context, userID int64, arg string) string {
+	keyID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || keyID <= 0 {
+		return "Укажите ID ключа из списка /apikey."
+	}
+
+	err = h.apiClient.RevokeAPIKey(ctx, userID, keyID)
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return "Ключа с таким ID нет."
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error revoking api key", "error", err)
+		return apiErrorText(err)
+	}
+	return fmt.Sprintf("🗑 Ключ %d отозван.", keyID)
+}
+
+// parseAPIKeyScopes разбирает области вида read,chat
+func parseAPIKeyScopes(value string) ([]string, bool) {
+	scopes := strings.Split(value, ",")
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, false
+		}
+	}
+	return scopes, true
+}
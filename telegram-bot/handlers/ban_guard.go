@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"telegram-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// banRefreshInterval как часто бот перечитывает список действующих блокировок
+	banRefreshInterval = time.Minute
+	// banNoticeInterval как часто напоминать заблокированному пользователю о блокировке
+	banNoticeInterval = 10 * time.Minute
+)
+
+// BanGuard отсекает обновления от заблокированных пользователей до их обработки.
+// Действующие блокировки держатся в памяти и обновляются в фоне, поэтому проверка
+// не обращается к БД при обработке обновления, а новая блокировка начинает действовать
+// в боте в течение минуты; запросы к ИИ API отклоняет сразу.
+type BanGuard struct {
+	banRepo models.BanRepository
+
+	mu       sync.Mutex
+	bans     map[int64]models.Ban
+	notified map[int64]time.Time
+}
+
+// NewBanGuard создает новую проверку блокировок
+func NewBanGuard(banRepo models.BanRepository) *BanGuard {
+	return &BanGuard{
+		banRepo:  banRepo,
+		bans:     make(map[int64]models.Ban),
+		notified: make(map[int64]time.Time),
+	}
+}
+
+// Run загружает действующие блокировки и обновляет их раз в banRefreshInterval;
+// запускается в отдельной горутине
+func (g *BanGuard) Run() {
+	ticker := time.NewTicker(banRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		g.refresh()
+		<-ticker.C
+	}
+}
+
+// refresh заменяет список блокировок в памяти. При ошибке БД остается прежний
+// список: API все равно проверит блокировку.
+func (g *BanGuard) refresh() {
+	bans, err := g.banRepo.ListActive()
+	if err != nil {
+		slog.Error("Error loading bans", "error", err)
+		return
+	}
+
+	active := make(map[int64]models.Ban, len(bans))
+	for _, ban := range bans {
+		active[ban.UserID] = ban
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.bans = active
+	for id := range g.notified {
+		if _, ok := active[id]; !ok {
+			delete(g.notified, id)
+		}
+	}
+}
+
+// Reject проверяет автора обновления и, если он заблокирован, сообщает ему об этом.
+// Возвращает true, если обновление обрабатывать не нужно.
+func (g *BanGuard) Reject(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) bool {
+	user := update.SentFrom()
+	if user == nil {
+		return false
+	}
+
+	ban := g.activeBan(user.ID)
+	if ban == nil {
+		return false
+	}
+
+	switch {
+	case update.CallbackQuery != nil:
+		if _, err := bot.Request(tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, BanText(ban))); err != nil {
+			slog.ErrorContext(ctx, "Error answering callback", "error", err)
+		}
+	case update.InlineQuery != nil:
+		if _, err := bot.Request(tgbotapi.InlineConfig{
+			InlineQueryID: update.InlineQuery.ID,
+			Results:       []interface{}{},
+			IsPersonal:    true,
+			CacheTime:     int(banRefreshInterval.Seconds()),
+		}); err != nil {
+			slog.ErrorContext(ctx, "Error answering inline query", "error", err)
+		}
+	case update.Message != nil && update.Message.Chat.IsPrivate() && g.shouldNotify(user.ID):
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, BanText(ban))); err != nil {
+			slog.ErrorContext(ctx, "Error sending message", "error", err)
+		}
+	}
+	return true
+}
+
+// activeBan возвращает действующую блокировку пользователя или nil
+func (g *BanGuard) activeBan(userID int64) *models.Ban {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ban, ok := g.bans[userID]
+	if !ok || (ban.ExpiresAt != nil && !ban.ExpiresAt.After(time.Now())) {
+		return nil
+	}
+	return &ban
+}
+
+// shouldNotify ограничивает напоминания о блокировке, чтобы не отвечать на каждое сообщение
+func (g *BanGuard) shouldNotify(userID int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if time.Since(g.notified[userID]) < banNoticeInterval {
+		return false
+	}
+	g.notified[userID] = time.Now()
+	return true
+}
+
+// BanText сообщение заблокированному пользователю с причиной и сроком блокировки
+func BanText(ban *models.Ban) string {
+	text := "⛔️ Доступ к боту заблокирован администратором."
+	if ban.Reason != "" {
+		text += "\nПричина: " + ban.Reason
+	}
+	if ban.ExpiresAt != nil {
+		text += "\nБлокировка действует до " + ban.ExpiresAt.Format("02.01.2006 15:04") + " (UTC)."
+	} else {
+		text += "\nБлокировка бессрочная."
+	}
+	return text
+}
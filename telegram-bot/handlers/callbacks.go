@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// callbackDataLimit ограничение Telegram на размер callback_data
+	callbackDataLimit = 64
+	// callbackSignatureSize число байт HMAC в подписи (8 символов base64)
+	callbackSignatureSize = 6
+	// callbackSeparator разделяет действие, аргументы и подпись
+	callbackSeparator = ":"
+)
+
+// CallbackFunc обработчик нажатия inline кнопки
+type CallbackFunc func(ctx *CallbackContext)
+
+// CallbackDispatcher формирует подписанные данные inline кнопок
+// и направляет нажатия обработчикам по имени действия.
+//
+// Формат данных: "<действие>:<арг1>:...:<подпись>". Подпись — усеченный
+// HMAC-SHA256 от остальной части, поэтому кнопку нельзя подделать,
+// отправив произвольный callback_data из модифицированного клиента.
+type CallbackDispatcher struct {
+	secret   []byte
+	handlers map[string]CallbackFunc
+}
+
+// NewCallbackDispatcher создает диспетчер с ключом подписи
+func NewCallbackDispatcher(secret []byte) *CallbackDispatcher {
+	return &CallbackDispatcher{
+		secret:   secret,
+		handlers: make(map[string]CallbackFunc),
+	}
+}
+
+// Register регистрирует обработчик действия. Повторная регистрация — ошибка программиста.
+func (d *CallbackDispatcher) Register(action string, handler CallbackFunc) {
+	if _, exists := d.handlers[action]; exists {
+		panic(fmt.Sprintf("callback action %q is already registered", action))
+	}
+	if action == "" || strings.Contains(action, callbackSeparator) {
+		panic(fmt.Sprintf("invalid callback action %q", action))
+	}
+	d.handlers[action] = handler
+}
+
+// Data возвращает подписанные данные кнопки для действия с аргументами.
+// Аргументы не должны содержать ":", а итог — превышать 64 байта.
+func (d *CallbackDispatcher) Data(action string, args ...string) string {
+	payload := strings.Join(append([]string{action}, args...), callbackSeparator)
+	data := payload + callbackSeparator + d.sign(payload)
+	if len(data) > callbackDataLimit {
+		panic(fmt.Sprintf("callback data for %q exceeds %d bytes", action, callbackDataLimit))
+	}
+	return data
+}
+
+// Button создает inline кнопку с подписанными данными
+func (d *CallbackDispatcher) Button(text, action string, args ...string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(text, d.Data(action, args...))
+}
+
+// Dispatch проверяет подпись и вызывает обработчик. На каждое нажатие
+// Telegram ждет answerCallbackQuery, поэтому, если обработчик не ответил сам,
+// диспетчер отвечает пустым уведомлением.
+func (d *CallbackDispatcher) Dispatch(parent context.Context, bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	ctx := &CallbackContext{Context: parent, Bot: bot, Query: query}
+	defer func() {
+		if !ctx.answered {
+			ctx.Answer("")
+		}
+	}()
+
+	action, args, ok := d.parse(query.Data)
+	if !ok {
+		slog.WarnContext(ctx, "Rejected callback data", "user_id", query.From.ID, "data", query.Data)
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	handler, exists := d.handlers[action]
+	if !exists {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	ctx.Args = args
+	handler(ctx)
+}
+
+// parse разбирает данные кнопки и проверяет подпись
+func (d *CallbackDispatcher) parse(data string) (string, []string, bool) {
+	cut := strings.LastIndex(data, callbackSeparator)
+	if cut < 0 {
+		return "", nil, false
+	}
+
+	payload, signature := data[:cut], data[cut+1:]
+	if !hmac.Equal([]byte(signature), []byte(d.sign(payload))) {
+		return "", nil, false
+	}
+
+	parts := strings.Split(payload, callbackSeparator)
+	return parts[0], parts[1:], true
+}
+
+// sign вычисляет подпись данных кнопки
+func (d *CallbackDispatcher) sign(payload string) string {
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureSize])
+}
+
+// CallbackContext нажатие кнопки с разобранными аргументами. Встроенный
+// context.Context относится к обработке обновления и передается в запросы к API.
+type CallbackContext struct {
+	context.Context
+	Bot   *tgbotapi.BotAPI
+	Query *tgbotapi.CallbackQuery
+	Args  []string
+
+	answered bool
+}
+
+// UserID возвращает Telegram ID нажавшего кнопку
+func (c *CallbackContext) UserID() int64 {
+	return c.Query.From.ID
+}
+
+// Arg возвращает аргумент по номеру или пустую строку
+func (c *CallbackContext) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// Answer отвечает на нажатие всплывающим уведомлением (пустой текст — без уведомления).
+// Долгие обработчики должны ответить сразу, иначе Telegram покажет ошибку.
+func (c *CallbackContext) Answer(text string) {
+	c.answer(tgbotapi.NewCallback(c.Query.ID, text))
+}
+
+// Alert отвечает на нажатие окном, которое нужно закрыть вручную
+func (c *CallbackContext) Alert(text string) {
+	c.answer(tgbotapi.NewCallbackWithAlert(c.Query.ID, text))
+}
+
+func (c *CallbackContext) answer(cfg tgbotapi.CallbackConfig) {
+	if c.answered {
+		return
+	}
+	c.answered = true
+	if _, err := c.Bot.Request(cfg); err != nil {
+		slog.ErrorContext(c, "Error answering callback", "error", err)
+	}
+}
+
+// EditMessage заменяет текст и клавиатуру сообщения с кнопкой.
+// У сообщений, отправленных в inline режиме, нет чата, и они не редактируются.
+func (c *CallbackContext) EditMessage(text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if c.Query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(c.Query.Message.Chat.ID, c.Query.Message.MessageID, text, keyboard)
+	if _, err := c.Bot.Request(edit); err != nil {
+		slog.ErrorContext(c, "Error editing message", "error", err)
+	}
+}
+
+// EditKeyboard заменяет только клавиатуру сообщения с кнопкой
+func (c *CallbackContext) EditKeyboard(keyboard tgbotapi.InlineKeyboardMarkup) {
+	if c.Query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(c.Query.Message.Chat.ID, c.Query.Message.MessageID, keyboard)
+	if _, err := c.Bot.Request(edit); err != nil {
+		slog.ErrorContext(c, "Error editing message keyboard", "error", err)
+	}
+}
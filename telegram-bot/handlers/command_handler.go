@@ -1,47 +1,178 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"telegram-bot/models"
+	"telegram-bot/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // CommandHandler обрабатывает команды Telegram бота
 type CommandHandler struct {
-	userRepo models.UserRepository
+	userRepo  models.UserRepository
+	apiClient *services.APIClient
+	router    *CommandRouter
+	callbacks *CallbackDispatcher
 }
 
-// NewCommandHandler создает новый обработчик команд
-func NewCommandHandler(userRepo models.UserRepository) *CommandHandler {
-	return &CommandHandler{
-		userRepo: userRepo,
+// NewCommandHandler создает новый обработчик команд и регистрирует его кнопки
+func NewCommandHandler(
+	userRepo models.UserRepository,
+	apiClient *services.APIClient,
+	callbacks *CallbackDispatcher,
+) *CommandHandler {
+	h := &CommandHandler{
+		userRepo:  userRepo,
+		apiClient: apiClient,
+		router:    NewCommandRouter(),
+		callbacks: callbacks,
 	}
+	h.registerCommands()
+	h.registerSettingsCallbacks()
+	return h
+}
+
+// registerCommands регистрирует команды в порядке показа в /help и меню
+func (h *CommandHandler) registerCommands() {
+	h.router.Register(Command{
+		Name:        "start",
+		Description: map[string]string{"ru": "Начать работу с ботом", "en": "Start the bot"},
+		Handler:     h.handleStartCommand,
+	})
+	h.router.Register(Command{
+		Name:        "help",
+		Description: map[string]string{"ru": "Список команд", "en": "List of commands"},
+		Handler:     h.handleHelpCommand,
+	})
+	h.router.Register(Command{
+		Name:        "new",
+		Description: map[string]string{"ru": "Начать новый диалог", "en": "Start a new conversation"},
+		Handler:     h.handleNewCommand,
+	})
+	h.router.Register(Command{
+		Name:        "stats",
+		Description: map[string]string{"ru": "Сколько сообщений осталось сегодня", "en": "Messages left today"},
+		Handler:     h.handleStatsCommand,
+	})
+	h.router.Register(Command{
+		Name:        "model",
+		Description: map[string]string{"ru": "Выбрать модель ИИ", "en": "Choose the AI model"},
+		Handler:     h.handleModelCommand,
+	})
+	h.router.Register(Command{
+		Name:        "persona",
+		Description: map[string]string{"ru": "Выбрать стиль общения", "en": "Choose the assistant persona"},
+		Handler:     h.handlePersonaCommand,
+	})
+	h.router.Register(Command{
+		Name:        "settings",
+		Description: map[string]string{"ru": "Настройки", "en": "Settings"},
+		Handler:     h.handleSettingsCommand,
+	})
+	h.router.Register(Command{
+		Name:        "memory",
+		Description: map[string]string{"ru": "Что бот помнит о вас", "en": "What the bot remembers about you"},
+		Handler:     h.handleMemoryCommand,
+	})
+	h.router.Register(Command{
+		Name:        "apikey",
+		Description: map[string]string{"ru": "Ключи для доступа к API", "en": "Personal API keys"},
+		Handler:     h.handleAPIKeyCommand,
+	})
+	h.router.Register(Command{
+		Name:        "group",
+		Description: map[string]string{"ru": "Настройки бота в группе", "en": "Bot settings in a group"},
+		Handler:     h.handleGroupCommand,
+	})
+	h.router.Register(Command{
+		Name:        "ban",
+		Description: map[string]string{"ru": "Заблокировать пользователя", "en": "Ban a user"},
+		Handler:     h.handleBanCommand,
+		Hidden:      true,
+	})
+	h.router.Register(Command{
+		Name:        "unban",
+		Description: map[string]string{"ru": "Снять блокировку", "en": "Unban a user"},
+		Handler:     h.handleUnbanCommand,
+		Hidden:      true,
+	})
+}
+
+// PublishCommands публикует меню команд в Telegram
+func (h *CommandHandler) PublishCommands(bot *tgbotapi.BotAPI) error {
+	return h.router.Publish(bot)
 }
 
 // HandleCommand обрабатывает входящую команду
-func (h *CommandHandler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	if message.Command() == "start" {
-		h.handleStartCommand(bot, message)
+func (h *CommandHandler) HandleCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if h.router.Handle(ctx, bot, message) {
+		return
+	}
+
+	// В группах команды могут быть адресованы другим ботам
+	if message.Chat.IsPrivate() {
+		h.send(bot, message.Chat.ID, "Неизвестная команда. Список команд: /help")
+	}
+}
+
+// handleHelpCommand обрабатывает команду /help
+func (h *CommandHandler) handleHelpCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	h.send(bot, message.Chat.ID, h.router.HelpText(userLanguage(message.From)))
+}
+
+// handleNewCommand обрабатывает команду /new
+func (h *CommandHandler) handleNewCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if err := h.apiClient.ResetContext(ctx, message.From.ID); err != nil {
+		slog.ErrorContext(ctx, "Error resetting context", "error", err)
+		h.send(bot, message.Chat.ID, "Не удалось начать новый диалог.")
+		return
+	}
+	h.send(bot, message.Chat.ID, "🆕 Начат новый диалог. Предыдущие сообщения больше не учитываются.")
+}
+
+// handleStatsCommand обрабатывает команду /stats
+func (h *CommandHandler) handleStatsCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	stats, err := h.apiClient.GetStats(ctx, message.From.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting stats", "error", err)
+		h.send(bot, message.Chat.ID, "Не удалось получить статистику.")
+		return
+	}
+
+	h.send(bot, message.Chat.ID, fmt.Sprintf(
+		"📊 Сообщений сегодня: %d из %d\nОсталось: %d\n\nЛимит обновляется каждый день.",
+		stats.DailyMessages, stats.DailyLimit, max(stats.Remaining, 0),
+	))
+}
+
+// send отправляет текстовое сообщение в чат
+func (h *CommandHandler) send(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		slog.Error("Error sending message", "error", err)
 	}
 }
 
 // handleStartCommand обрабатывает команду /start
-func (h *CommandHandler) handleStartCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func (h *CommandHandler) handleStartCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	user := &models.User{
-		UserID:    int64(message.From.ID),
-		Username:  message.From.UserName,
-		FirstName: message.From.FirstName,
-		LastName:  message.From.LastName,
-		CreatedAt: time.Now(),
+		UserID:       int64(message.From.ID),
+		Username:     message.From.UserName,
+		FirstName:    message.From.FirstName,
+		LastName:     message.From.LastName,
+		LanguageCode: message.From.LanguageCode,
+		CreatedAt:    time.Now(),
 	}
 
 	// Сохраняем пользователя в базу данных
 	if err := h.userRepo.Save(user); err != nil {
-		log.Printf("Error saving user: %v", err)
+		slog.ErrorContext(ctx, "Error saving user", "error", err)
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при сохранении данных")
 		bot.Send(msg)
 		return
@@ -66,6 +197,97 @@ func (h *CommandHandler) handleStartCommand(bot *tgbotapi.BotAPI, message *tgbot
 		welcomeText += fmt.Sprintf("\nUsername: @%s", user.Username)
 	}
 
+	welcomeText += "\n\nЧто я умею: /help"
+
 	msg := tgbotapi.NewMessage(message.Chat.ID, welcomeText)
 	bot.Send(msg)
 }
+
+// handleMemoryCommand обрабатывает команду /memory:
+// /memory — список фактов, /memory add <факт>, /memory delete <номер>, /memory clear.
+// Факты личные, поэтому команда работает только в личном чате.
+func (h *CommandHandler) handleMemoryCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if !message.Chat.IsPrivate() {
+		h.replyCommand(bot, message, "Команда /memory работает только в личном чате с ботом.")
+		return
+	}
+
+	userID := message.From.ID
+	args := strings.Fields(message.CommandArguments())
+
+	var text string
+	switch {
+	case len(args) == 0:
+		text = h.memoryListText(ctx, userID)
+	case args[0] == "add" && len(args) > 1:
+		content := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "add"))
+		if _, err := h.apiClient.AddMemory(ctx, userID, content); err != nil {
+			slog.ErrorContext(ctx, "Error adding memory", "error", err)
+			text = "Не удалось сохранить факт."
+		} else {
+			text = "✅ Запомнил: " + content
+		}
+	case args[0] == "delete" && len(args) == 2:
+		text = h.deleteMemory(ctx, userID, args[1])
+	case args[0] == "clear":
+		if err := h.apiClient.ClearMemories(ctx, userID); err != nil {
+			slog.ErrorContext(ctx, "Error clearing memories", "error", err)
+			text = "Не удалось очистить память."
+		} else {
+			text = "🧹 Память очищена. Я больше ничего не помню о вас."
+		}
+	default:
+		text = "Использование:\n" +
+			"/memory — что я о вас помню\n" +
+			"/memory add <факт> — запомнить факт\n" +
+			"/memory delete <номер> — забыть факт\n" +
+			"/memory clear — забыть всё"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	bot.Send(msg)
+}
+
+// memoryListText формирует нумерованный список фактов о пользователе
+func (h *CommandHandler) memoryListText(ctx context.Context, userID int64) string {
+	memories, err := h.apiClient.ListMemories(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing memories", "error", err)
+		return "Не удалось загрузить память."
+	}
+	if len(memories) == 0 {
+		return "Я пока ничего о вас не помню. Расскажите о себе или используйте /memory add <факт>."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🧠 Что я о вас помню:\n\n")
+	for i, memory := range memories {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, memory.Content)
+	}
+	sb.WriteString("\nЗабыть факт: /memory delete <номер>, забыть всё: /memory clear")
+	return sb.String()
+}
+
+// deleteMemory удаляет факт по номеру из списка /memory
+func (h *CommandHandler) deleteMemory(ctx context.Context, userID int64, arg string) string {
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 {
+		return "Укажите номер факта из списка /memory."
+	}
+
+	memories, err := h.apiClient.ListMemories(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing memories", "error", err)
+		return "Не удалось загрузить память."
+	}
+	if number > len(memories) {
+		return "Факта с таким номером нет."
+	}
+
+	memory := memories[number-1]
+	if err := h.apiClient.DeleteMemory(ctx, userID, memory.ID); err != nil {
+		slog.ErrorContext(ctx, "Error deleting memory", "error", err)
+		return "Не удалось удалить факт."
+	}
+	return "🗑 Забыл: " + memory.Content
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// defaultLanguage язык описаний команд по умолчанию
+	defaultLanguage = "ru"
+	// englishLanguage язык для пользователей, у которых Telegram не на русском
+	englishLanguage = "en"
+)
+
+// CommandFunc обработчик команды бота
+type CommandFunc func(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message)
+
+// Command описание команды бота
+type Command struct {
+	Name        string
+	Description map[string]string // описание по языкам, обязательно для defaultLanguage
+	Handler     CommandFunc
+	Hidden      bool // не показывать в /help и меню команд
+}
+
+// CommandRouter направляет команды обработчикам и публикует их список в Telegram
+type CommandRouter struct {
+	commands []Command
+	byName   map[string]Command
+}
+
+// NewCommandRouter создает пустой маршрутизатор команд
+func NewCommandRouter() *CommandRouter {
+	return &CommandRouter{
+		byName: make(map[string]Command),
+	}
+}
+
+// Register добавляет команду. Повторная регистрация — ошибка программиста.
+func (r *CommandRouter) Register(cmd Command) {
+	if _, exists := r.byName[cmd.Name]; exists {
+		panic(fmt.Sprintf("command /%s is already registered", cmd.Name))
+	}
+	if cmd.Description[defaultLanguage] == "" {
+		panic(fmt.Sprintf("command /%s has no %s description", cmd.Name, defaultLanguage))
+	}
+
+	r.commands = append(r.commands, cmd)
+	r.byName[cmd.Name] = cmd
+}
+
+// Handle выполняет команду из сообщения и сообщает, была ли она найдена
+func (r *CommandRouter) Handle(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	// В группах команда вида /help@other_bot адресована другому боту
+	if _, addressee, found := strings.Cut(message.CommandWithAt(), "@"); found && !strings.EqualFold(addressee, bot.Self.UserName) {
+		return true
+	}
+
+	cmd, ok := r.byName[message.Command()]
+	if !ok {
+		return false
+	}
+
+	cmd.Handler(ctx, bot, message)
+	return true
+}
+
+// HelpText формирует список команд на языке пользователя
+func (r *CommandRouter) HelpText(language string) string {
+	var sb strings.Builder
+	if language == englishLanguage {
+		sb.WriteString("I am an AI assistant. Just write or send a voice message, a document or a question.\n\nCommands:\n")
+	} else {
+		sb.WriteString("Я ИИ-ассистент. Просто напишите сообщение, отправьте голосовое, документ или вопрос.\n\nКоманды:\n")
+	}
+
+	for _, cmd := range r.commands {
+		if !cmd.Hidden {
+			fmt.Fprintf(&sb, "/%s — %s\n", cmd.Name, cmd.description(language))
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Publish публикует меню команд через setMyCommands: по умолчанию на русском,
+// для пользователей с английским интерфейсом Telegram — на английском
+func (r *CommandRouter) Publish(bot *tgbotapi.BotAPI) error {
+	scope := tgbotapi.NewBotCommandScopeDefault()
+
+	configs := []tgbotapi.SetMyCommandsConfig{
+		tgbotapi.NewSetMyCommandsWithScope(scope, r.botCommands(defaultLanguage)...),
+		tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, englishLanguage, r.botCommands(englishLanguage)...),
+	}
+	for _, cfg := range configs {
+		if _, err := bot.Request(cfg); err != nil {
+			return fmt.Errorf("failed to set bot commands: %w", err)
+		}
+	}
+	return nil
+}
+
+// botCommands возвращает видимые команды для меню Telegram
+func (r *CommandRouter) botCommands(language string) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, cmd := range r.commands {
+		if !cmd.Hidden {
+			commands = append(commands, tgbotapi.BotCommand{
+				Command:     cmd.Name,
+				Description: cmd.description(language),
+			})
+		}
+	}
+	return commands
+}
+
+// description возвращает описание команды на языке или на языке по умолчанию
+func (c Command) description(language string) string {
+	if text := c.Description[language]; text != "" {
+		return text
+	}
+	return c.Description[defaultLanguage]
+}
+
+// userLanguage выбирает язык интерфейса по настройкам Telegram пользователя
+func userLanguage(user *tgbotapi.User) string {
+	if user == nil || user.LanguageCode == "" {
+		return defaultLanguage
+	}
+	switch strings.SplitN(user.LanguageCode, "-", 2)[0] {
+	case "ru", "uk", "be", "kk":
+		return defaultLanguage
+	default:
+		return englishLanguage
+	}
+}
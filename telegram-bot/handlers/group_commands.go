@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"telegram-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// groupUsage справка по команде /group
+const groupUsage = "Использование:\n" +
+	"/group — настройки бота в группе\n" +
+	"/group on | off — включить или выключить ответы бота\n" +
+	"/group model <номер> — выбрать модель\n" +
+	"/group persona <номер> — выбрать стиль общения\n" +
+	"/group reset — начать новый диалог\n\n" +
+	"Менять настройки могут только администраторы группы."
+
+// handleGroupCommand обрабатывает команду /group: просмотр настроек доступен всем
+// участникам, изменение — только администраторам группы
+func (h *CommandHandler) handleGroupCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if message.Chat.IsPrivate() {
+		h.send(bot, message.Chat.ID, "Команда /group работает только в группах.")
+		return
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		h.replyCommand(bot, message, h.groupStatusText(ctx, message))
+		return
+	}
+
+	if !isGroupAdmin(ctx, bot, message) {
+		h.replyCommand(bot, message, "Менять настройки бота могут только администраторы группы.")
+		return
+	}
+
+	var text string
+	switch {
+	case args[0] == "on" || args[0] == "off":
+		enabled := args[0] == "on"
+		text = h.updateGroup(ctx, message, models.GroupSettingsUpdate{Enabled: &enabled})
+	case args[0] == "model" && len(args) == 2:
+		text = h.setGroupModel(ctx, message, args[1])
+	case args[0] == "persona" && len(args) == 2:
+		text = h.setGroupPersona(ctx, message, args[1])
+	case args[0] == "reset":
+		if err := h.apiClient.ResetGroupContext(ctx, message.From.ID, message.Chat.ID); err != nil {
+			slog.ErrorContext(ctx, "Error resetting group context", "error", err)
+			text = "Не удалось начать новый диалог."
+		} else {
+			text = "🆕 Начат новый диалог. Предыдущие сообщения группы больше не учитываются."
+		}
+	default:
+		text = groupUsage
+	}
+
+	h.replyCommand(bot, message, text)
+}
+
+// groupStatusText формирует описание настроек группы с нумерованными списками для выбора
+func (h *CommandHandler) groupStatusText(ctx context.Context, message *tgbotapi.Message) string {
+	group, err := h.apiClient.GetGroupSettings(ctx, message.From.ID, message.Chat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting group settings", "error", err)
+		return "Не удалось загрузить настройки группы."
+	}
+
+	status := "✅ включен"
+	if !group.Settings.Enabled {
+		status = "⛔️ выключен"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚙️ Бот в группе: %s\nМодель: %s\nСтиль общения: %s\n\n",
+		status, group.Settings.Model, groupPersonaName(group, group.Settings.Persona))
+
+	sb.WriteString("Модели:\n")
+	for i, model := range group.Models {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, model)
+	}
+	sb.WriteString("\nСтили общения:\n")
+	for i, persona := range group.Personas {
+		fmt.Fprintf(&sb, "%d. %s — %s\n", i+1, persona.Name, persona.Description)
+	}
+
+	sb.WriteString("\nЧтобы задать вопрос, упомяните бота или ответьте на его сообщение.\n\n")
+	sb.WriteString(groupUsage)
+	return sb.String()
+}
+
+// setGroupModel выбирает модель группы по номеру из списка /group
+func (h *CommandHandler) setGroupModel(ctx context.Context, message *tgbotapi.Message, arg string) string {
+	group, err := h.apiClient.GetGroupSettings(ctx, message.From.ID, message.Chat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting group settings", "error", err)
+		return "Не удалось загрузить настройки группы."
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 || number > len(group.Models) {
+		return "Укажите номер модели из списка /group."
+	}
+
+	model := group.Models[number-1]
+	return h.updateGroup(ctx, message, models.GroupSettingsUpdate{Model: &model})
+}
+
+// setGroupPersona выбирает стиль общения группы по номеру из списка /group
+func (h *CommandHandler) setGroupPersona(ctx context.Context, message *tgbotapi.Message, arg string) string {
+	group, err := h.apiClient.GetGroupSettings(ctx, message.From.ID, message.Chat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting group settings", "error", err)
+		return "Не удалось загрузить настройки группы."
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 || number > len(group.Personas) {
+		return "Укажите номер стиля общения из списка /group."
+	}
+
+	persona := group.Personas[number-1]
+	return h.updateGroup(ctx, message, models.GroupSettingsUpdate{Persona: &persona.ID})
+}
+
+// updateGroup сохраняет изменение настроек группы и описывает результат
+func (h *CommandHandler) updateGroup(ctx context.Context, message *tgbotapi.Message, update models.GroupSettingsUpdate) string {
+	settings, err := h.apiClient.UpdateGroupSettings(ctx, message.From.ID, message.Chat.ID, update)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating group settings", "error", err)
+		return "Не удалось изменить настройки группы."
+	}
+
+	switch {
+	case update.Enabled != nil && settings.Enabled:
+		return "✅ Бот включен. Упомяните его или ответьте на его сообщение, чтобы задать вопрос."
+	case update.Enabled != nil:
+		return "⛔️ Бот выключен и не будет отвечать в этой группе."
+	case update.Model != nil:
+		return "✅ Модель группы: " + settings.Model
+	default:
+		return "✅ Стиль общения группы изменен."
+	}
+}
+
+// replyCommand отвечает на команду, чтобы ответ остался в той же теме форума
+func (h *CommandHandler) replyCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := bot.Send(msg); err != nil {
+		slog.Error("Error sending message", "error", err)
+	}
+}
+
+// isGroupAdmin проверяет, что автор сообщения — администратор группы.
+// Сообщения анонимных администраторов отправляются от имени самой группы.
+func isGroupAdmin(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	if message.SenderChat != nil && message.SenderChat.ID == message.Chat.ID {
+		return true
+	}
+
+	member, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatID: message.Chat.ID,
+			UserID: message.From.ID,
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting chat member", "error", err)
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// groupPersonaName возвращает название персоны группы по идентификатору
+func groupPersonaName(group *models.GroupSettingsResponse, id string) string {
+	for _, persona := range group.Personas {
+		if persona.ID == id {
+			return persona.Name
+		}
+	}
+	return id
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"telegram-bot/models"
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleGroupText отвечает на сообщение в группе, если бота упомянули или ответили
+// на его сообщение. История диалога общая для группы (или темы форума threadID).
+func (h *MessageHandler) HandleGroupText(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message, threadID int64) {
+	if h.takeFeedbackComment(ctx, bot, message) {
+		return
+	}
+
+	text, ok := addressedText(bot.Self, message)
+	if !ok {
+		return
+	}
+	if text == "" {
+		h.reply(bot, message, "Напишите вопрос вместе с упоминанием бота.")
+		return
+	}
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		h.reply(bot, message, fmt.Sprintf("Сообщение слишком длинное (максимум %d символов).", maxMessageLength))
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+
+	response, err := h.apiClient.SendMessage(ctx, message.From.ID, models.ChatRequest{
+		Message:    text,
+		ChatID:     message.Chat.ID,
+		ThreadID:   threadID,
+		AuthorName: authorName(message.From),
+	})
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		// Администраторы выключили бота в группе командой /group off
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending group message to API", "error", err)
+		reportError("group", err)
+		h.reply(bot, message, apiErrorText(err))
+		return
+	}
+
+	h.replyAnswer(bot, message.Chat.ID, message.MessageID, response)
+}
+
+// addressedText проверяет, что сообщение адресовано боту, и возвращает его текст
+// без упоминания бота
+func addressedText(self tgbotapi.User, message *tgbotapi.Message) (string, bool) {
+	addressed := message.ReplyToMessage != nil &&
+		message.ReplyToMessage.From != nil &&
+		message.ReplyToMessage.From.ID == self.ID
+
+	// Смещения сущностей считаются в единицах UTF-16
+	text := utf16.Encode([]rune(message.Text))
+	var stripped []uint16
+	last := 0
+	for _, entity := range message.Entities {
+		end := entity.Offset + entity.Length
+		if entity.Offset < last || end > len(text) {
+			continue
+		}
+
+		mention := entity.Type == "mention" &&
+			strings.EqualFold(string(utf16.Decode(text[entity.Offset:end])), "@"+self.UserName)
+		textMention := entity.Type == "text_mention" && entity.User != nil && entity.User.ID == self.ID
+		if !mention && !textMention {
+			continue
+		}
+
+		addressed = true
+		stripped = append(stripped, text[last:entity.Offset]...)
+		last = end
+	}
+	stripped = append(stripped, text[last:]...)
+
+	if !addressed {
+		return "", false
+	}
+	return strings.TrimSpace(string(utf16.Decode(stripped))), true
+}
+
+// authorName имя участника группы, под которым его сообщение попадает в историю
+func authorName(user *tgbotapi.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.UserName
+	}
+	return truncateRunes(name, 64)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// inlineDebounce пауза после последнего изменения запроса, после которой он отправляется ИИ
+	inlineDebounce = 800 * time.Millisecond
+	// inlineMinQueryLength запросы короче считаются недописанными
+	inlineMinQueryLength = 3
+	// inlineCacheTTL время жизни ответа в кеше
+	inlineCacheTTL = 10 * time.Minute
+	// inlineTelegramCacheTime время кеширования результата на стороне Telegram, секунды
+	inlineTelegramCacheTime = 300
+	// inlineDescriptionLength длина превью ответа в списке результатов
+	inlineDescriptionLength = 100
+	// inlineAnswerDeadline сколько ждать ответа ИИ с момента получения запроса.
+	// Telegram принимает ответ на inline запрос около 10 секунд, после этого он теряется.
+	inlineAnswerDeadline = 8 * time.Second
+)
+
+// inlineCacheEntry ответ ИИ на inline запрос
+type inlineCacheEntry struct {
+	answer    string
+	expiresAt time.Time
+}
+
+// inlineCall запрос к ИИ, который еще выполняется. Повторные inline запросы
+// с тем же текстом ждут его, а не отправляют вопрос заново.
+type inlineCall struct {
+	done   chan struct{}
+	answer string
+	err    error
+}
+
+// InlineHandler отвечает на inline запросы "@бот вопрос" из любых чатов.
+//
+// Telegram присылает новый запрос на каждое нажатие клавиши, поэтому запрос
+// отправляется ИИ только после паузы в наборе, а более ранние запросы
+// пользователя остаются без ответа. Ответы на одинаковые запросы пользователя
+// берутся из кеша и не расходуют дневной лимит повторно.
+//
+// Если ИИ не успевает ответить до истечения запроса в Telegram, пользователь
+// получает подсказку повторить запрос, а ответ продолжает готовиться и
+// попадает в кеш.
+type InlineHandler struct {
+	apiClient *services.APIClient
+
+	mu       sync.Mutex
+	latest   map[int64]uint64 // номер последнего запроса каждого пользователя
+	sequence uint64
+	cache    map[string]inlineCacheEntry
+	calls    map[string]*inlineCall
+}
+
+// NewInlineHandler создает новый обработчик inline запросов
+func NewInlineHandler(apiClient *services.APIClient) *InlineHandler {
+	return &InlineHandler{
+		apiClient: apiClient,
+		latest:    make(map[int64]uint64),
+		cache:     make(map[string]inlineCacheEntry),
+		calls:     make(map[string]*inlineCall),
+	}
+}
+
+// HandleInlineQuery обрабатывает inline запрос
+func (h *InlineHandler) HandleInlineQuery(ctx context.Context, bot *tgbotapi.BotAPI, query *tgbotapi.InlineQuery) {
+	deadline := time.NewTimer(inlineAnswerDeadline)
+	defer deadline.Stop()
+
+	text := strings.TrimSpace(query.Query)
+	userID := query.From.ID
+	if utf8.RuneCountInString(text) < inlineMinQueryLength {
+		h.answerHint(bot, query.ID, "Введите вопрос после имени бота")
+		return
+	}
+
+	key := inlineCacheKey(userID, text)
+	if answer, ok := h.cached(key); ok {
+		h.answerResult(bot, query.ID, text, answer)
+		return
+	}
+
+	// Ждем паузы в наборе: если за это время пришел новый запрос, этот уже не нужен
+	seq := h.track(userID)
+	time.Sleep(inlineDebounce)
+	if !h.isLatest(userID, seq) {
+		return
+	}
+
+	call := h.fetch(ctx, key, userID, text)
+	select {
+	case <-call.done:
+	case <-deadline.C:
+		// Запрос в Telegram вот-вот истечет; ответ попадет в кеш, когда будет готов
+		if h.isLatest(userID, seq) {
+			h.answerHint(bot, query.ID, "⏳ Ответ готовится, повторите запрос через несколько секунд")
+		}
+		return
+	}
+
+	if call.err != nil {
+		h.answerHint(bot, query.ID, inlineErrorText(call.err))
+		return
+	}
+	if !h.isLatest(userID, seq) {
+		// Ответ сохранен в кеше и пригодится, если пользователь вернется к этому вопросу
+		return
+	}
+	h.answerResult(bot, query.ID, text, call.answer)
+}
+
+// fetch запускает запрос к ИИ или возвращает уже выполняющийся запрос с тем же ключом.
+// Запрос не зависит от ожидания inline запроса: его ограничивает только таймаут
+// клиента API, а готовый ответ сохраняется в кеш.
+func (h *InlineHandler) fetch(ctx context.Context, key string, userID int64, text string) *inlineCall {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if call, ok := h.calls[key]; ok {
+		return call
+	}
+
+	call := &inlineCall{done: make(chan struct{})}
+	h.calls[key] = call
+
+	go func() {
+		response, err := h.apiClient.Inline(context.WithoutCancel(ctx), userID, text)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error sending inline query to API", "error", err)
+			reportError("inline", err)
+			call.err = err
+		} else {
+			call.answer = response.Message
+			h.store(key, response.Message)
+		}
+
+		h.mu.Lock()
+		delete(h.calls, key)
+		h.mu.Unlock()
+		close(call.done)
+	}()
+
+	return call
+}
+
+// answerResult отвечает одним результатом с вопросом и ответом ИИ
+func (h *InlineHandler) answerResult(bot *tgbotapi.BotAPI, queryID, question, answer string) {
+	content := inlineMessageHTML(question, answer)
+	article := tgbotapi.NewInlineQueryResultArticleHTML(fmt.Sprintf("answer-%d", time.Now().UnixNano()), "🤖 Ответ ИИ", content)
+	article.Description = truncateRunes(strings.Join(strings.Fields(answer), " "), inlineDescriptionLength)
+
+	h.answer(bot, tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       []interface{}{article},
+		CacheTime:     inlineTelegramCacheTime,
+		IsPersonal:    true,
+	})
+}
+
+// answerHint отвечает без результатов, показывая подсказку с переходом в личный чат с ботом.
+// Подсказка почти не кешируется Telegram, чтобы повтор запроса дошел до бота.
+func (h *InlineHandler) answerHint(bot *tgbotapi.BotAPI, queryID, hint string) {
+	h.answer(bot, tgbotapi.InlineConfig{
+		InlineQueryID:     queryID,
+		Results:           []interface{}{},
+		CacheTime:         1,
+		IsPersonal:        true,
+		SwitchPMText:      hint,
+		SwitchPMParameter: "inline",
+	})
+}
+
+func (h *InlineHandler) answer(bot *tgbotapi.BotAPI, cfg tgbotapi.InlineConfig) {
+	if _, err := bot.Request(cfg); err != nil {
+		slog.Error("Error answering inline query", "error", err)
+	}
+}
+
+// track запоминает запрос как последний для пользователя и возвращает его номер
+func (h *InlineHandler) track(userID int64) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sequence++
+	h.latest[userID] = h.sequence
+	return h.sequence
+}
+
+// isLatest проверяет, что после запроса seq пользователь ничего не вводил
+func (h *InlineHandler) isLatest(userID int64, seq uint64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.latest[userID] == seq
+}
+
+// cached возвращает ответ из кеша, если он еще не устарел
+func (h *InlineHandler) cached(key string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.answer, true
+}
+
+// store сохраняет ответ в кеш, заодно удаляя устаревшие записи
+func (h *InlineHandler) store(key, answer string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range h.cache {
+		if now.After(entry.expiresAt) {
+			delete(h.cache, k)
+		}
+	}
+	h.cache[key] = inlineCacheEntry{answer: answer, expiresAt: now.Add(inlineCacheTTL)}
+}
+
+// inlineCacheKey ключ кеша: ответы зависят от настроек и памяти пользователя,
+// поэтому кеш у каждого пользователя свой
+func inlineCacheKey(userID int64, text string) string {
+	return fmt.Sprintf("%d:%s", userID, strings.ToLower(strings.Join(strings.Fields(text), " ")))
+}
+
+// inlineMessageHTML формирует сообщение, которое будет отправлено в чат
+func inlineMessageHTML(question, answer string) string {
+	header := "❓ <i>" + html.EscapeString(question) + "</i>\n\n"
+
+	// В inline режиме можно отправить только одно сообщение: берем первую часть ответа
+	body := ""
+	parts := RenderMarkdown(answer)
+	if len(parts) > 0 && parts[0].FileName == "" {
+		body = parts[0].HTML
+	}
+	if len(parts) > 1 {
+		body += "\n\n<i>…ответ сокращен, полностью — в личном чате с ботом</i>"
+	}
+
+	if textLength(header+body) > telegramMessageLimit {
+		// Слишком длинный ответ отправляем обычным текстом без разметки
+		return html.EscapeString(truncateRunes(answer, telegramMessageLimit/2))
+	}
+	return header + body
+}
+
+// inlineErrorText короткое описание ошибки для подсказки inline режима
+func inlineErrorText(err error) string {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		if apiErr.Code == "rate_limited" {
+			return "Слишком много запросов, подождите немного"
+		}
+		return "Дневной лимит сообщений исчерпан"
+	}
+	return "Не удалось получить ответ, попробуйте позже"
+}
+
+// truncateRunes обрезает строку до limit символов, добавляя многоточие
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
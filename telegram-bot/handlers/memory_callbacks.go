@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"telegram-bot/models"
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок под предложениями запомнить факт
+const (
+	callbackMemoryConfirm = "mc"
+	callbackMemoryReject  = "mx"
+)
+
+// removedKeyboard пустая клавиатура: убирает кнопки при редактировании сообщения
+var removedKeyboard = tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+
+// registerMemoryCallbacks регистрирует обработчики кнопок подтверждения памяти
+func (h *MessageHandler) registerMemoryCallbacks() {
+	h.callbacks.Register(callbackMemoryConfirm, h.handleMemoryConfirmCallback)
+	h.callbacks.Register(callbackMemoryReject, h.handleMemoryRejectCallback)
+}
+
+// sendMemoryProposals просит пользователя подтвердить факты, которые ассистент
+// предложил запомнить. Без подтверждения факт в память не попадает.
+func (h *MessageHandler) sendMemoryProposals(bot *tgbotapi.BotAPI, chatID int64, proposals []models.MemoryProposal) {
+	for _, proposal := range proposals {
+		id := strconv.FormatInt(proposal.ID, 10)
+		msg := tgbotapi.NewMessage(chatID, "💡 Запомнить о вас?\n"+proposal.Content)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button("✅ Запомнить", callbackMemoryConfirm, id),
+			h.callbacks.Button("✖️ Не нужно", callbackMemoryReject, id),
+		))
+		if _, err := bot.Send(msg); err != nil {
+			slog.Error("Error sending memory proposal", "error", err)
+		}
+	}
+}
+
+// handleMemoryConfirmCallback сохраняет предложенный факт в память
+func (h *MessageHandler) handleMemoryConfirmCallback(ctx *CallbackContext) {
+	proposalID, err := strconv.ParseInt(ctx.Arg(0), 10, 64)
+	if err != nil {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	memory, err := h.apiClient.ConfirmMemory(ctx, ctx.UserID(), proposalID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error confirming memory", "error", err)
+		reportError("memory", err)
+		ctx.Answer(memoryProposalErrorText(err))
+		return
+	}
+
+	ctx.Answer("")
+	ctx.EditMessage("✅ Запомнил: "+memory.Content+"\nВсё, что я помню: /memory", removedKeyboard)
+}
+
+// handleMemoryRejectCallback отклоняет предложенный факт
+func (h *MessageHandler) handleMemoryRejectCallback(ctx *CallbackContext) {
+	proposalID, err := strconv.ParseInt(ctx.Arg(0), 10, 64)
+	if err != nil {
+		ctx.Answer("Кнопка устарела")
+		return
+	}
+
+	if err := h.apiClient.RejectMemory(ctx, ctx.UserID(), proposalID); err != nil {
+		slog.ErrorContext(ctx, "Error rejecting memory", "error", err)
+		reportError("memory", err)
+		ctx.Answer(memoryProposalErrorText(err))
+		return
+	}
+
+	ctx.Answer("")
+	ctx.EditMessage("Хорошо, не буду это запоминать.", removedKeyboard)
+}
+
+// memoryProposalErrorText описание ошибки подтверждения факта
+func memoryProposalErrorText(err error) string {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound:
+			return "Предложение устарело или уже рассмотрено."
+		case http.StatusConflict:
+			return "Память заполнена: удалите лишние факты командой /memory delete."
+		}
+	}
+	return "Не удалось сохранить факт, попробуйте позже."
+}
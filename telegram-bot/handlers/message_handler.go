@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"telegram-bot/metrics"
+	"telegram-bot/models"
+	"telegram-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxMessageLength совпадает с ограничением API на длину сообщения
+	maxMessageLength = 300
+	// maxDocumentSize совпадает с ограничением API на размер документа
+	maxDocumentSize = 10 << 20
+	// maxAudioSize ограничение Telegram на скачивание файлов ботом
+	maxAudioSize = 20 << 20
+	// activeDocumentTTL время, в течение которого вопросы относятся к последнему документу
+	activeDocumentTTL = 30 * time.Minute
+	// maxFeedbackComment совпадает с ограничением API на длину комментария к оценке
+	maxFeedbackComment = 1000
+)
+
+// activeDocument последний загруженный пользователем документ
+type activeDocument struct {
+	id         int64
+	uploadedAt time.Time
+}
+
+// MessageHandler обрабатывает обычные сообщения и документы, передавая их в API
+type MessageHandler struct {
+	apiClient  *services.APIClient
+	httpClient *http.Client
+	callbacks  *CallbackDispatcher
+
+	mu              sync.Mutex
+	activeDocuments map[int64]activeDocument
+	pendingComments map[int64]pendingComment
+}
+
+// NewMessageHandler создает новый обработчик сообщений и регистрирует кнопки под ответами
+// и под предложениями запомнить факт
+func NewMessageHandler(apiClient *services.APIClient, callbacks *CallbackDispatcher) *MessageHandler {
+	h := &MessageHandler{
+		apiClient:       apiClient,
+		httpClient:      &http.Client{Timeout: 60 * time.Second},
+		callbacks:       callbacks,
+		activeDocuments: make(map[int64]activeDocument),
+		pendingComments: make(map[int64]pendingComment),
+	}
+	h.registerAnswerCallbacks()
+	h.registerMemoryCallbacks()
+	return h
+}
+
+// HandleText отправляет текст пользователя ИИ и возвращает ответ
+func (h *MessageHandler) HandleText(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if h.takeFeedbackComment(ctx, bot, message) {
+		return
+	}
+
+	if utf8.RuneCountInString(message.Text) > maxMessageLength {
+		h.reply(bot, message, fmt.Sprintf(
+			"Сообщение слишком длинное (максимум %d символов). "+
+				"Отправьте большой текст файлом (.txt, .md, .pdf, .docx) — и задайте вопрос о нём.",
+			maxMessageLength,
+		))
+		return
+	}
+
+	userID := message.From.ID
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+
+	response, err := h.apiClient.SendMessage(ctx, userID, models.ChatRequest{
+		Message:    message.Text,
+		DocumentID: h.activeDocument(userID),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending message to API", "error", err)
+		reportError("text", err)
+		h.reply(bot, message, apiErrorText(err))
+		return
+	}
+
+	h.replyAnswer(bot, message.Chat.ID, message.MessageID, response)
+}
+
+// HandleDocument загружает документ пользователя в API
+func (h *MessageHandler) HandleDocument(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	document := message.Document
+	if document.FileSize > maxDocumentSize {
+		h.reply(bot, message, "Файл слишком большой: максимум 10 МБ.")
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatUploadDocument))
+
+	file, err := h.downloadFile(bot, document.FileID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error downloading document", "error", err)
+		reportError("document", err)
+		h.reply(bot, message, "Не удалось скачать файл.")
+		return
+	}
+	defer file.Close()
+
+	uploaded, err := h.apiClient.UploadDocument(ctx, message.From.ID, document.FileName, file)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error uploading document to API", "error", err)
+		reportError("document", err)
+		h.reply(bot, message, apiErrorText(err))
+		return
+	}
+
+	h.mu.Lock()
+	h.activeDocuments[message.From.ID] = activeDocument{id: uploaded.ID, uploadedAt: time.Now()}
+	h.mu.Unlock()
+
+	h.reply(bot, message, fmt.Sprintf(
+		"📄 Документ «%s» загружен (%d фрагм.).\n\n"+
+			"Задавайте вопросы — в ближайшие 30 минут я буду отвечать с учётом этого документа.",
+		uploaded.Filename,
+		uploaded.ChunkCount,
+	))
+}
+
+// HandleVoice распознает голосовое сообщение или аудиофайл, показывает расшифровку и отвечает
+func (h *MessageHandler) HandleVoice(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	var fileID, filename string
+	var fileSize int
+	if message.Voice != nil {
+		fileID, filename, fileSize = message.Voice.FileID, "voice.ogg", message.Voice.FileSize
+	} else {
+		fileID, filename, fileSize = message.Audio.FileID, message.Audio.FileName, message.Audio.FileSize
+		if filename == "" {
+			filename = "audio.mp3"
+		}
+	}
+
+	if fileSize > maxAudioSize {
+		h.reply(bot, message, "Аудио слишком большое: максимум 20 МБ.")
+		return
+	}
+
+	bot.Request(tgbotapi.NewChatAction(message.Chat.ID, tgbotapi.ChatTyping))
+
+	file, err := h.downloadFile(bot, fileID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error downloading audio", "error", err)
+		reportError("voice", err)
+		h.reply(bot, message, "Не удалось скачать аудио.")
+		return
+	}
+	defer file.Close()
+
+	userID := message.From.ID
+	response, err := h.apiClient.SendVoice(ctx, userID, filename, file, h.activeDocument(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending voice to API", "error", err)
+		reportError("voice", err)
+		h.reply(bot, message, apiErrorText(err))
+		return
+	}
+
+	h.reply(bot, message, "🎤 "+response.Transcript)
+	h.replyAnswer(bot, message.Chat.ID, message.MessageID, response)
+}
+
+// downloadFile скачивает файл с серверов Telegram
+func (h *MessageHandler) downloadFile(bot *tgbotapi.BotAPI, fileID string) (io.ReadCloser, error) {
+	fileURL, err := bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file URL: %w", err)
+	}
+
+	resp, err := h.httpClient.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// activeDocument возвращает ID недавно загруженного документа пользователя
+func (h *MessageHandler) activeDocument(userID int64) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	doc, ok := h.activeDocuments[userID]
+	if !ok {
+		return 0
+	}
+	if time.Since(doc.uploadedAt) > activeDocumentTTL {
+		delete(h.activeDocuments, userID)
+		return 0
+	}
+	return doc.id
+}
+
+// reply отправляет ответ на сообщение пользователя
+func (h *MessageHandler) reply(bot *tgbotapi.BotAPI, message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := bot.Send(msg); err != nil {
+		slog.Error("Error sending message", "error", err)
+	}
+}
+
+// replyAnswer отправляет ответ модели с форматированием, разбиением на части
+// и кнопками под последней частью, а затем предложения запомнить факты
+func (h *MessageHandler) replyAnswer(bot *tgbotapi.BotAPI, chatID int64, replyTo int, response *models.ChatResponse) {
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	if response.MessageID != 0 {
+		markup := h.answerKeyboard(response.MessageID, 0)
+		keyboard = &markup
+	}
+
+	if err := SendRendered(bot, chatID, replyTo, response.Message, keyboard); err != nil {
+		slog.Error("Error sending answer", "error", err)
+	}
+	h.sendMemoryProposals(bot, chatID, response.MemoryProposals)
+}
+
+// reportError учитывает ошибку обработчика в метриках. Отказы API с кодом 4xx
+// (лимиты, блокировки, неподдерживаемые файлы) — ожидаемое поведение, а не сбой.
+func reportError(handler string, err error) {
+	var apiErr *services.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+		return
+	}
+	metrics.HandlerErrors.Inc(handler)
+}
+
+// apiErrorText возвращает понятное пользователю описание ошибки API
+func apiErrorText(err error) string {
+	var apiErr *services.APIError
+	if !errors.As(err, &apiErr) {
+		return "Сервис временно недоступен, попробуйте позже."
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		if apiErr.Code == "rate_limited" {
+			return "Слишком много запросов. Подождите немного и попробуйте снова."
+		}
+		return "Достигнут дневной лимит сообщений. Попробуйте завтра."
+	case http.StatusForbidden:
+		return "⛔️ Доступ к боту заблокирован администратором."
+	case http.StatusUnsupportedMediaType:
+		return "Поддерживаются только файлы PDF, DOCX, TXT и Markdown."
+	case http.StatusRequestEntityTooLarge:
+		if apiErr.Code == "content_too_large" {
+			return "Документ слишком большой: после распаковки в нем слишком много данных."
+		}
+		return "Файл слишком большой: максимум 10 МБ."
+	case http.StatusUnprocessableEntity:
+		return "Не удалось распознать содержимое: в файле нет текста или речи."
+	case http.StatusNotFound:
+		return "Документ не найден. Загрузите его заново."
+	case http.StatusServiceUnavailable:
+		if apiErr.Code == "transcription_unavailable" {
+			return "Распознавание голосовых сообщений сейчас недоступно."
+		}
+		return "ИИ сервис временно недоступен. Попробуйте через пару минут."
+	case http.StatusBadGateway:
+		return "Не удалось распознать аудио. Попробуйте ещё раз."
+	default:
+		return "Произошла ошибка при обработке запроса. Попробуйте позже."
+	}
+}
@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// telegramMessageLimit максимальная длина сообщения Telegram в UTF-16 единицах
+	telegramMessageLimit = 4096
+	// codeFileThreshold блоки кода длиннее этого отправляются файлом
+	codeFileThreshold = 3000
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	bulletPattern      = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedPattern    = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	rulePattern        = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	codeLanguageFilter = regexp.MustCompile(`[^a-zA-Z0-9_+-]`)
+)
+
+// codeFileExtensions расширения файлов для языков блоков кода
+var codeFileExtensions = map[string]string{
+	"go": "go", "python": "py", "py": "py", "javascript": "js", "js": "js",
+	"typescript": "ts", "ts": "ts", "java": "java", "c": "c", "cpp": "cpp",
+	"csharp": "cs", "cs": "cs", "rust": "rs", "ruby": "rb", "php": "php",
+	"bash": "sh", "sh": "sh", "shell": "sh", "sql": "sql", "json": "json",
+	"yaml": "yaml", "yml": "yaml", "html": "html", "css": "css", "kotlin": "kt",
+	"swift": "swift", "xml": "xml", "markdown": "md", "md": "md",
+}
+
+// RenderedPart часть ответа для отправки в Telegram: HTML сообщение или файл
+type RenderedPart struct {
+	HTML  string // текст сообщения в формате Telegram HTML
+	Plain string // исходный текст для отправки без форматирования, если HTML отклонен
+
+	FileName string // если задано, часть отправляется документом
+	FileData []byte
+}
+
+// markdownBlock блок исходного текста: абзац или блок кода
+type markdownBlock struct {
+	text     string
+	code     bool
+	language string
+}
+
+// RenderMarkdown переводит Markdown ответа модели в Telegram HTML и разбивает его
+// на сообщения не длиннее лимита Telegram. Разбиение идет по границам абзацев и
+// блоков кода; слишком длинные блоки кода выносятся в файлы.
+func RenderMarkdown(markdown string) []RenderedPart {
+	var parts []RenderedPart
+	var currentHTML, currentPlain strings.Builder
+
+	flush := func() {
+		if strings.TrimSpace(currentPlain.String()) != "" {
+			parts = append(parts, RenderedPart{
+				HTML:  strings.TrimSpace(currentHTML.String()),
+				Plain: strings.TrimSpace(currentPlain.String()),
+			})
+		}
+		currentHTML.Reset()
+		currentPlain.Reset()
+	}
+
+	appendPiece := func(htmlText, plain string) {
+		if currentHTML.Len() > 0 && textLength(currentHTML.String())+textLength(htmlText)+2 > telegramMessageLimit {
+			flush()
+		}
+		if currentHTML.Len() > 0 {
+			currentHTML.WriteString("\n\n")
+			currentPlain.WriteString("\n\n")
+		}
+		currentHTML.WriteString(htmlText)
+		currentPlain.WriteString(plain)
+	}
+
+	codeFiles := 0
+	for _, block := range parseMarkdownBlocks(markdown) {
+		if block.code {
+			rendered := renderCodeBlock(block)
+			if textLength(rendered) <= codeFileThreshold {
+				appendPiece(rendered, block.text)
+				continue
+			}
+
+			// Длинный код отправляется файлом в порядке следования в ответе
+			codeFiles++
+			fileName := codeFileName(block.language, codeFiles)
+			appendPiece(
+				fmt.Sprintf("📎 <i>Код отправлен файлом %s</i>", html.EscapeString(fileName)),
+				"📎 Код отправлен файлом "+fileName,
+			)
+			flush()
+			parts = append(parts, RenderedPart{FileName: fileName, FileData: []byte(block.text)})
+			continue
+		}
+
+		for _, piece := range splitParagraph(block.text, telegramMessageLimit/2) {
+			appendPiece(renderParagraph(piece), piece)
+		}
+	}
+	flush()
+
+	return parts
+}
+
+// parseMarkdownBlocks разбивает текст на абзацы и блоки кода
+func parseMarkdownBlocks(markdown string) []markdownBlock {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	lines := strings.Split(markdown, "\n")
+
+	var blocks []markdownBlock
+	var paragraph []string
+
+	flushParagraph := func() {
+		if text := strings.Trim(strings.Join(paragraph, "\n"), "\n"); strings.TrimSpace(text) != "" {
+			blocks = append(blocks, markdownBlock{text: text})
+		}
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		fence := ""
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+		case strings.HasPrefix(trimmed, "~~~"):
+			fence = "~~~"
+		}
+
+		if fence == "" {
+			if trimmed == "" {
+				flushParagraph()
+			} else {
+				paragraph = append(paragraph, lines[i])
+			}
+			continue
+		}
+
+		flushParagraph()
+		language := codeLanguageFilter.ReplaceAllString(strings.TrimSpace(strings.TrimPrefix(trimmed, fence)), "")
+
+		// Незакрытый блок кода продолжается до конца ответа
+		var code []string
+		for i++; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == fence {
+				break
+			}
+			code = append(code, lines[i])
+		}
+		blocks = append(blocks, markdownBlock{
+			text:     strings.Join(code, "\n"),
+			code:     true,
+			language: strings.ToLower(language),
+		})
+	}
+	flushParagraph()
+
+	return blocks
+}
+
+// splitParagraph делит слишком длинный абзац по строкам, а строки — по словам
+func splitParagraph(text string, limit int) []string {
+	if textLength(text) <= limit {
+		return []string{text}
+	}
+
+	var pieces []string
+	var current []string
+	currentLength := 0
+	for _, line := range strings.Split(text, "\n") {
+		for _, segment := range splitLine(line, limit) {
+			if currentLength > 0 && currentLength+textLength(segment)+1 > limit {
+				pieces = append(pieces, strings.Join(current, "\n"))
+				current, currentLength = nil, 0
+			}
+			current = append(current, segment)
+			currentLength += textLength(segment) + 1
+		}
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, "\n"))
+	}
+	return pieces
+}
+
+// splitLine делит строку по пробелам, а слишком длинные слова — по символам
+func splitLine(line string, limit int) []string {
+	if textLength(line) <= limit {
+		return []string{line}
+	}
+
+	var segments []string
+	var current strings.Builder
+	for _, word := range strings.Fields(line) {
+		for textLength(word) > limit {
+			runes := []rune(word)
+			cut := limit / 2
+			segments = append(segments, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		if current.Len() > 0 && textLength(current.String())+textLength(word)+1 > limit {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString(" ")
+		}
+		current.WriteString(word)
+	}
+	if current.Len() > 0 {
+		segments = append(segments, current.String())
+	}
+	return segments
+}
+
+// renderCodeBlock оформляет блок кода
+func renderCodeBlock(block markdownBlock) string {
+	code := html.EscapeString(block.text)
+	if block.language == "" {
+		return "<pre>" + code + "</pre>"
+	}
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, block.language, code)
+}
+
+// codeFileName подбирает имя файла для блока кода
+func codeFileName(language string, index int) string {
+	extension, ok := codeFileExtensions[language]
+	if !ok {
+		extension = "txt"
+	}
+	if index == 1 {
+		return "code." + extension
+	}
+	return fmt.Sprintf("code_%d.%s", index, extension)
+}
+
+// renderParagraph оформляет строки абзаца: заголовки, списки, цитаты и inline разметку
+func renderParagraph(text string) string {
+	var out []string
+	var quote []string
+
+	flushQuote := func() {
+		if len(quote) > 0 {
+			out = append(out, "<blockquote>"+strings.Join(quote, "\n")+"</blockquote>")
+			quote = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			quote = append(quote, renderInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+			continue
+		}
+		flushQuote()
+
+		switch {
+		case rulePattern.MatchString(line):
+			out = append(out, "──────────")
+		case headingPattern.MatchString(trimmed):
+			out = append(out, "<b>"+renderInline(headingPattern.FindStringSubmatch(trimmed)[1])+"</b>")
+		case bulletPattern.MatchString(line):
+			m := bulletPattern.FindStringSubmatch(line)
+			out = append(out, m[1]+"• "+renderInline(m[2]))
+		case numberedPattern.MatchString(line):
+			m := numberedPattern.FindStringSubmatch(line)
+			out = append(out, m[1]+m[2]+" "+renderInline(m[3]))
+		default:
+			out = append(out, renderInline(line))
+		}
+	}
+	flushQuote()
+
+	return strings.Join(out, "\n")
+}
+
+// renderInline переводит inline разметку Markdown в HTML. Незакрытые маркеры
+// остаются как есть, весь остальной текст экранируется.
+func renderInline(text string) string {
+	runes := []rune(text)
+	var sb strings.Builder
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		switch {
+		case c == '\\' && i+1 < len(runes) && isMarkdownPunct(runes[i+1]):
+			sb.WriteString(html.EscapeString(string(runes[i+1])))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := indexRunes(runes, i+1, "`"); end > i+1 {
+				sb.WriteString("<code>" + html.EscapeString(string(runes[i+1:end])) + "</code>")
+				i = end + 1
+				continue
+			}
+
+		case c == '[':
+			if label, url, next, ok := parseLink(runes, i); ok {
+				fmt.Fprintf(&sb, `<a href="%s">%s</a>`, html.EscapeString(url), renderInline(label))
+				i = next
+				continue
+			}
+		}
+
+		if tag, marker, ok := emphasisAt(runes, i); ok {
+			start := i + len([]rune(marker))
+			if end := findClosing(runes, start, marker); end > start {
+				sb.WriteString("<" + tag + ">" + renderInline(string(runes[start:end])) + "</" + tag + ">")
+				i = end + len([]rune(marker))
+				continue
+			}
+		}
+
+		sb.WriteString(html.EscapeString(string(c)))
+		i++
+	}
+
+	return sb.String()
+}
+
+// emphasisAt определяет маркер выделения в позиции i
+func emphasisAt(runes []rune, i int) (tag, marker string, ok bool) {
+	rest := string(runes[i:min(i+2, len(runes))])
+	switch {
+	case rest == "**" || rest == "__":
+		marker, tag = rest, "b"
+	case rest == "~~":
+		marker, tag = rest, "s"
+	case runes[i] == '*' || runes[i] == '_':
+		marker, tag = string(runes[i]), "i"
+	default:
+		return "", "", false
+	}
+
+	// Открывающий маркер должен стоять перед непробельным символом,
+	// а "_" внутри слова (snake_case) не считается выделением
+	next := i + len([]rune(marker))
+	if next >= len(runes) || unicode.IsSpace(runes[next]) {
+		return "", "", false
+	}
+	if marker[0] == '_' && i > 0 && isWordRune(runes[i-1]) {
+		return "", "", false
+	}
+	return tag, marker, true
+}
+
+// findClosing ищет закрывающий маркер выделения
+func findClosing(runes []rune, start int, marker string) int {
+	for j := start; j < len(runes); j++ {
+		if runes[j] == '`' {
+			// Маркеры внутри inline кода не закрывают выделение
+			if end := indexRunes(runes, j+1, "`"); end > j {
+				j = end
+				continue
+			}
+		}
+		if runes[j] == '\\' {
+			j++
+			continue
+		}
+		// Вложенное выделение другим маркером ("*a **b** c*") пропускается целиком,
+		// чтобы его маркеры не закрыли внешнее
+		if j > start && !isWordRune(runes[j-1]) && runes[j-1] != rune(marker[0]) {
+			if _, nested, ok := emphasisAt(runes, j); ok && nested != marker {
+				nestedStart := j + len([]rune(nested))
+				if nestedEnd := findClosing(runes, nestedStart, nested); nestedEnd > nestedStart {
+					j = nestedEnd + len([]rune(nested)) - 1
+					continue
+				}
+			}
+		}
+		end := indexRunes(runes, j, marker)
+		if end != j {
+			continue
+		}
+		if unicode.IsSpace(runes[j-1]) {
+			continue
+		}
+		after := j + len([]rune(marker))
+		if marker[0] == '_' && after < len(runes) && isWordRune(runes[after]) {
+			continue
+		}
+		// Закрывающий маркер берется самым правым из серии ("***" закрывает "*" и "**")
+		if after < len(runes) && runes[after] == rune(marker[0]) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// parseLink разбирает ссылку вида [текст](url). Скобки внутри url допускаются
+// парами, как в адресах Википедии
+func parseLink(runes []rune, i int) (label, url string, next int, ok bool) {
+	closeLabel := indexRunes(runes, i+1, "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeURL := closingParen(runes, closeLabel+2)
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+
+	url = strings.TrimSpace(string(runes[closeLabel+2 : closeURL]))
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "tg://") {
+		return "", "", 0, false
+	}
+	if strings.ContainsAny(url, " \n") {
+		return "", "", 0, false
+	}
+
+	return string(runes[i+1 : closeLabel]), url, closeURL + 1, true
+}
+
+// closingParen ищет скобку, закрывающую url ссылки, с учетом вложенных пар скобок
+func closingParen(runes []rune, from int) int {
+	depth := 0
+	for j := from; j < len(runes); j++ {
+		switch runes[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// indexRunes ищет подстроку в срезе рун начиная с позиции from
+func indexRunes(runes []rune, from int, substr string) int {
+	needle := []rune(substr)
+	for j := from; j+len(needle) <= len(runes); j++ {
+		match := true
+		for k, r := range needle {
+			if runes[j+k] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return j
+		}
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isMarkdownPunct(r rune) bool {
+	return strings.ContainsRune("\\`*_{}[]()#+-.!~|>", r)
+}
+
+// textLength возвращает длину текста в единицах UTF-16, как считает Telegram
+func textLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// SendRendered отправляет ответ модели, разбитый RenderMarkdown. Если Telegram
+// не принимает HTML разметку части, она отправляется обычным текстом.
+// Клавиатура, если задана, прикрепляется к последней части ответа.
+//
+// В группах каждая часть отвечает на исходное сообщение: так ответ остается
+// в теме форума, даже если Telegram не передает ее явно.
+func SendRendered(bot *tgbotapi.BotAPI, chatID int64, replyTo int, markdown string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	parts := RenderMarkdown(markdown)
+	for i, part := range parts {
+		var markup interface{}
+		if keyboard != nil && i == len(parts)-1 {
+			markup = *keyboard
+		}
+
+		var err error
+		if part.FileName != "" {
+			doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: part.FileName, Bytes: part.FileData})
+			doc.ReplyMarkup = markup
+			if i == 0 || isGroupChat(chatID) {
+				doc.ReplyToMessageID = replyTo
+			}
+			_, err = bot.Send(doc)
+		} else {
+			msg := tgbotapi.NewMessage(chatID, part.HTML)
+			msg.ParseMode = tgbotapi.ModeHTML
+			msg.DisableWebPagePreview = true
+			msg.ReplyMarkup = markup
+			if i == 0 || isGroupChat(chatID) {
+				msg.ReplyToMessageID = replyTo
+			}
+			if _, err = bot.Send(msg); err != nil {
+				slog.Error("Telegram rejected rendered HTML, sending plain text", "error", err)
+				msg.Text = part.Plain
+				msg.ParseMode = ""
+				_, err = bot.Send(msg)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send reply part %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// isGroupChat сообщает, что чат является группой (идентификаторы групп отрицательные)
+func isGroupChat(chatID int64) bool {
+	return chatID < 0
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"bold", "**bold**", "<b>bold</b>"},
+		{"italic star", "*italic*", "<i>italic</i>"},
+		{"italic underscore", "_italic_", "<i>italic</i>"},
+		{"strikethrough", "~~gone~~", "<s>gone</s>"},
+		{"nested emphasis", "**bold _and italic_**", "<b>bold <i>and italic</i></b>"},
+		{"italic inside bold", "*italic **bold** italic*", "<i>italic <b>bold</b> italic</i>"},
+		{"triple star", "***both***", "<b><i>both</i></b>"},
+		{"triple star in sentence", "a ***b*** c", "a <b><i>b</i></b> c"},
+		{"bold inside italic after paren", "*see (**x**) now*", "<i>see (<b>x</b>) now</i>"},
+		{"snake_case", "call snake_case_name here", "call snake_case_name here"},
+		{"snake_case pair", "use my_var and other_var", "use my_var and other_var"},
+		{"double underscore identifier", "__init__ method", "<b>init</b> method"},
+		{"double underscore inside word", "a__b__c", "a__b__c"},
+		{"unclosed bold", "**not closed", "**not closed"},
+		{"unclosed italic", "2 * 3 = 6", "2 * 3 = 6"},
+		{"marker before space", "a * b * c", "a * b * c"},
+		{"escaped marker", `\*not italic\*`, "*not italic*"},
+		{"inline code", "`a_b * c`", "<code>a_b * c</code>"},
+		{"inline code escapes html", "`<b>`", "<code>&lt;b&gt;</code>"},
+		{"markers inside code do not close", "*a `*` b*", "<i>a <code>*</code> b</i>"},
+		{"unclosed backtick", "`open", "`open"},
+		{"html escaped", "1 < 2 & 3 > 2", "1 &lt; 2 &amp; 3 &gt; 2"},
+		{"link", "[site](https://example.com)", `<a href="https://example.com">site</a>`},
+		{"link with underscore", "[docs](https://example.com/my_page_name)", `<a href="https://example.com/my_page_name">docs</a>`},
+		{"link label snake_case", "[my_func](https://example.com)", `<a href="https://example.com">my_func</a>`},
+		{"link with parentheses", "[Go](https://en.wikipedia.org/wiki/Go_(programming_language))",
+			`<a href="https://en.wikipedia.org/wiki/Go_(programming_language)">Go</a>`},
+		{"link followed by paren", "(see [docs](https://example.com))", `(see <a href="https://example.com">docs</a>)`},
+		{"link with query", "[q](https://example.com/?a=1&b=2)", `<a href="https://example.com/?a=1&amp;b=2">q</a>`},
+		{"link emphasis label", "[**bold**](https://example.com)", `<a href="https://example.com"><b>bold</b></a>`},
+		{"link unsupported scheme", "[x](javascript:alert(1))", "[x](javascript:alert(1))"},
+		{"link with space", "[x](https://a.com/b c)", "[x](https://a.com/b c)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderInline(tt.markdown); got != tt.want {
+				t.Errorf("renderInline(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderParagraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"heading", "## Title ##", "<b>Title</b>"},
+		{"bullets", "- one\n* two", "• one\n• two"},
+		{"nested bullet", "- one\n  - two", "• one\n  • two"},
+		{"numbered", "1. one\n2) two", "1. one\n2) two"},
+		{"quote", "> a\n> b\nc", "<blockquote>a\nb</blockquote>\nc"},
+		{"rule", "---", "──────────"},
+		{"rule with spaces", "* * *", "──────────"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderParagraph(tt.markdown); got != tt.want {
+				t.Errorf("renderParagraph(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"fenced with language", "```go\nfmt.Println(\"<hi>\")\n```", `<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>`},
+		{"tilde fence", "~~~\na_b *c*\n~~~", "<pre>a_b *c*</pre>"},
+		{"language sanitized", "```go\"><script>\nx\n```", `<pre><code class="language-goscript">x</code></pre>`},
+		{"unclosed fence", "text\n\n```python\nprint(1)\n**not bold**", "text\n\n<pre><code class=\"language-python\">print(1)\n**not bold**</code></pre>"},
+		{"other fence inside", "~~~\n```\ncode\n~~~", "<pre>```\ncode</pre>"},
+		{"blank lines kept in code", "```\na\n\nb\n```", "<pre>a\n\nb</pre>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := RenderMarkdown(tt.markdown)
+			if len(parts) != 1 {
+				t.Fatalf("RenderMarkdown(%q) returned %d parts, want 1", tt.markdown, len(parts))
+			}
+			if parts[0].HTML != tt.want {
+				t.Errorf("RenderMarkdown(%q) = %q, want %q", tt.markdown, parts[0].HTML, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownCodeFileThreshold(t *testing.T) {
+	// Длина оформленного блока складывается из кода и тегов <pre></pre>
+	wrapper := textLength("<pre></pre>")
+	fits := strings.Repeat("x", codeFileThreshold-wrapper)
+	tooLong := strings.Repeat("x", codeFileThreshold-wrapper+1)
+
+	parts := RenderMarkdown("```\n" + fits + "\n```")
+	if len(parts) != 1 || parts[0].FileName != "" {
+		t.Fatalf("code block at threshold should stay inline, got %d parts", len(parts))
+	}
+
+	parts = RenderMarkdown("Intro\n\n```go\n" + tooLong + "\n```\n\nOutro\n\n```\n" + tooLong + "\n```")
+	if len(parts) != 4 {
+		t.Fatalf("got %d parts, want 4", len(parts))
+	}
+	if !strings.HasPrefix(parts[0].HTML, "Intro\n\n📎") || !strings.Contains(parts[0].HTML, "code.go") {
+		t.Errorf("first part should announce code.go, got %q", parts[0].HTML)
+	}
+	if parts[1].FileName != "code.go" || string(parts[1].FileData) != tooLong {
+		t.Errorf("second part should be code.go with the code, got %q", parts[1].FileName)
+	}
+	if !strings.HasPrefix(parts[2].HTML, "Outro\n\n📎") || !strings.Contains(parts[2].HTML, "code_2.txt") {
+		t.Errorf("third part should announce code_2.txt, got %q", parts[2].HTML)
+	}
+	if parts[3].FileName != "code_2.txt" {
+		t.Errorf("fourth part should be code_2.txt, got %q", parts[3].FileName)
+	}
+}
+
+func TestRenderMarkdownSplitsLongMessages(t *testing.T) {
+	// Эмодзи занимают две единицы UTF-16, поэтому длина в рунах меньше лимита Telegram
+	paragraph := strings.Repeat("😀 **жирный текст** _курсив_ [ссылка](https://example.com/a_b) ", 60)
+	markdown := strings.Repeat(paragraph+"\n\n", 5)
+
+	parts := RenderMarkdown(markdown)
+	if len(parts) < 2 {
+		t.Fatalf("long text should be split, got %d parts", len(parts))
+	}
+
+	for i, part := range parts {
+		if part.FileName != "" {
+			t.Fatalf("part %d unexpectedly sent as file", i)
+		}
+		if n := textLength(part.HTML); n > telegramMessageLimit {
+			t.Errorf("part %d is %d UTF-16 units, limit %d", i, n, telegramMessageLimit)
+		}
+		for _, tag := range []string{"b", "i", "a"} {
+			opened := strings.Count(part.HTML, "<"+tag+">") + strings.Count(part.HTML, "<"+tag+" ")
+			closed := strings.Count(part.HTML, "</"+tag+">")
+			if opened != closed {
+				t.Errorf("part %d has %d <%s> and %d </%s>", i, opened, tag, closed, tag)
+			}
+		}
+	}
+
+	var plain []string
+	for _, part := range parts {
+		plain = append(plain, part.Plain)
+	}
+	if got, want := strings.Count(strings.Join(plain, " "), "😀"), 300; got != want {
+		t.Errorf("split lost text: %d emoji, want %d", got, want)
+	}
+}
+
+func TestRenderMarkdownSplitsLongParagraph(t *testing.T) {
+	// Один абзац без пустых строк делится по строкам и словам, не разрывая разметку
+	line := strings.Repeat("слово **важно** ", 40)
+	markdown := strings.TrimSpace(strings.Repeat(line+"\n", 20))
+
+	parts := RenderMarkdown(markdown)
+	if len(parts) < 2 {
+		t.Fatalf("long paragraph should be split, got %d parts", len(parts))
+	}
+	for i, part := range parts {
+		if n := textLength(part.HTML); n > telegramMessageLimit {
+			t.Errorf("part %d is %d UTF-16 units, limit %d", i, n, telegramMessageLimit)
+		}
+		if strings.Count(part.HTML, "<b>") != strings.Count(part.HTML, "</b>") {
+			t.Errorf("part %d has unbalanced <b>", i)
+		}
+		if strings.Contains(part.HTML, "**") {
+			t.Errorf("part %d has a broken bold marker", i)
+		}
+	}
+}
+
+func TestSplitLineLongWord(t *testing.T) {
+	word := strings.Repeat("я", 25)
+	segments := splitLine(word, 10)
+	if strings.Join(segments, "") != word {
+		t.Errorf("segments %q do not reassemble the word", segments)
+	}
+	for _, segment := range segments {
+		if textLength(segment) > 10 {
+			t.Errorf("segment %q is longer than the limit", segment)
+		}
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"abc", 3},
+		{"абв", 3},
+		{"😀", 2},
+		{"a😀b", 4},
+	}
+	for _, tt := range tests {
+		if got := textLength(tt.text); got != tt.want {
+			t.Errorf("textLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
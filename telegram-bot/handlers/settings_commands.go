@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"telegram-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Действия кнопок настроек. Модели и персоны передаются номером в списке,
+// так как названия моделей могут не поместиться в 64 байта callback_data.
+const (
+	callbackSettings = "set"
+	callbackModel    = "mdl"
+	callbackPersona  = "prs"
+)
+
+// registerSettingsCallbacks регистрирует обработчики кнопок меню настроек
+func (h *CommandHandler) registerSettingsCallbacks() {
+	h.callbacks.Register(callbackSettings, h.handleSettingsCallback)
+	h.callbacks.Register(callbackModel, h.handleModelCallback)
+	h.callbacks.Register(callbackPersona, h.handlePersonaCallback)
+}
+
+// handleModelCommand обрабатывает команду /model
+func (h *CommandHandler) handleModelCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	h.sendSettingsMenu(ctx, bot, message, h.modelMenu)
+}
+
+// handlePersonaCommand обрабатывает команду /persona
+func (h *CommandHandler) handlePersonaCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	h.sendSettingsMenu(ctx, bot, message, h.personaMenu)
+}
+
+// handleSettingsCommand обрабатывает команду /settings
+func (h *CommandHandler) handleSettingsCommand(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	h.sendSettingsMenu(ctx, bot, message, h.settingsMenu)
+}
+
+// settingsMenuFunc формирует текст и клавиатуру меню по настройкам пользователя
+type settingsMenuFunc func(settings *models.SettingsResponse) (string, tgbotapi.InlineKeyboardMarkup)
+
+// sendSettingsMenu загружает настройки пользователя и отправляет меню
+func (h *CommandHandler) sendSettingsMenu(ctx context.Context, bot *tgbotapi.BotAPI, message *tgbotapi.Message, menu settingsMenuFunc) {
+	settings, err := h.apiClient.GetSettings(ctx, message.From.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting settings", "error", err)
+		h.send(bot, message.Chat.ID, "Не удалось загрузить настройки.")
+		return
+	}
+
+	text, keyboard := menu(settings)
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = keyboard
+	if _, err := bot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", err)
+	}
+}
+
+// handleSettingsCallback обрабатывает кнопки меню /settings
+func (h *CommandHandler) handleSettingsCallback(ctx *CallbackContext) {
+	switch ctx.Arg(0) {
+	case "model":
+		h.editSettingsMenu(ctx, h.modelMenu)
+	case "persona":
+		h.editSettingsMenu(ctx, h.personaMenu)
+	case "new":
+		if err := h.apiClient.ResetContext(ctx, ctx.UserID()); err != nil {
+			slog.ErrorContext(ctx, "Error resetting context", "error", err)
+			ctx.Answer("Не удалось начать новый диалог")
+			return
+		}
+		ctx.Answer("🆕 Начат новый диалог")
+	}
+}
+
+// handleModelCallback сохраняет модель по ее номеру в списке доступных
+func (h *CommandHandler) handleModelCallback(ctx *CallbackContext) {
+	settings, err := h.apiClient.GetSettings(ctx, ctx.UserID())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting settings", "error", err)
+		ctx.Answer("Не удалось загрузить настройки")
+		return
+	}
+
+	index, err := strconv.Atoi(ctx.Arg(0))
+	if err != nil || index < 0 || index >= len(settings.Models) {
+		// Список моделей изменился с момента отправки меню
+		ctx.Answer("Модель больше недоступна")
+		h.editSettingsMenu(ctx, h.modelMenu)
+		return
+	}
+
+	model := settings.Models[index]
+	if _, err := h.apiClient.UpdateSettings(ctx, ctx.UserID(), models.SettingsUpdate{Model: &model}); err != nil {
+		slog.ErrorContext(ctx, "Error updating settings", "error", err)
+		ctx.Answer("Не удалось сменить модель")
+		return
+	}
+	ctx.Answer("✅ Модель: " + model)
+	h.editSettingsMenu(ctx, h.modelMenu)
+}
+
+// handlePersonaCallback сохраняет персону по ее номеру в списке
+func (h *CommandHandler) handlePersonaCallback(ctx *CallbackContext) {
+	settings, err := h.apiClient.GetSettings(ctx, ctx.UserID())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting settings", "error", err)
+		ctx.Answer("Не удалось загрузить настройки")
+		return
+	}
+
+	index, err := strconv.Atoi(ctx.Arg(0))
+	if err != nil || index < 0 || index >= len(settings.Personas) {
+		ctx.Answer("Стиль общения больше недоступен")
+		h.editSettingsMenu(ctx, h.personaMenu)
+		return
+	}
+
+	persona := settings.Personas[index]
+	if _, err := h.apiClient.UpdateSettings(ctx, ctx.UserID(), models.SettingsUpdate{Persona: &persona.ID}); err != nil {
+		slog.ErrorContext(ctx, "Error updating settings", "error", err)
+		ctx.Answer("Не удалось сменить стиль общения")
+		return
+	}
+	ctx.Answer("✅ Стиль общения: " + persona.Name)
+	h.editSettingsMenu(ctx, h.personaMenu)
+}
+
+// editSettingsMenu заменяет сообщение с кнопкой актуальным меню
+func (h *CommandHandler) editSettingsMenu(ctx *CallbackContext, menu settingsMenuFunc) {
+	settings, err := h.apiClient.GetSettings(ctx, ctx.UserID())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting settings", "error", err)
+		ctx.Answer("Не удалось загрузить настройки")
+		return
+	}
+
+	// Отвечаем до редактирования, чтобы у кнопки не висел индикатор загрузки
+	ctx.Answer("")
+	text, keyboard := menu(settings)
+	ctx.EditMessage(text, keyboard)
+}
+
+// settingsMenu меню /settings с текущими настройками
+func (h *CommandHandler) settingsMenu(settings *models.SettingsResponse) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := fmt.Sprintf(
+		"⚙️ Настройки\n\nМодель: %s\nСтиль общения: %s",
+		settings.Settings.Model,
+		personaName(settings, settings.Settings.Persona),
+	)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button("🤖 Модель", callbackSettings, "model"),
+			h.callbacks.Button("🎭 Стиль", callbackSettings, "persona"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button("🆕 Новый диалог", callbackSettings, "new"),
+		),
+	)
+	return text, keyboard
+}
+
+// modelMenu меню выбора модели; текущая модель отмечена галочкой
+func (h *CommandHandler) modelMenu(settings *models.SettingsResponse) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := "🤖 Текущая модель: " + settings.Settings.Model + "\n\nВыберите модель:"
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, model := range settings.Models {
+		label := model
+		if model == settings.Settings.Model {
+			label = "✅ " + model
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button(label, callbackModel, strconv.Itoa(i)),
+		))
+	}
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// personaMenu меню выбора персоны с описаниями
+func (h *CommandHandler) personaMenu(settings *models.SettingsResponse) (string, tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString("🎭 Стиль общения: " + personaName(settings, settings.Settings.Persona) + "\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, persona := range settings.Personas {
+		fmt.Fprintf(&sb, "• %s — %s\n", persona.Name, persona.Description)
+
+		label := persona.Name
+		if persona.ID == settings.Settings.Persona {
+			label = "✅ " + persona.Name
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			h.callbacks.Button(label, callbackPersona, strconv.Itoa(i)),
+		))
+	}
+	return strings.TrimSpace(sb.String()), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// personaName возвращает название персоны по идентификатору
+func personaName(settings *models.SettingsResponse, id string) string {
+	for _, persona := range settings.Personas {
+		if persona.ID == id {
+			return persona.Name
+		}
+	}
+	return id
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"telegram-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// userTouchInterval как часто сохранять время последней активности пользователя
+	userTouchInterval = 5 * time.Minute
+	// userFlushInterval как часто накопленная активность записывается в БД
+	userFlushInterval = 10 * time.Second
+)
+
+// trackedUser последний сохраненный профиль пользователя
+type trackedUser struct {
+	profile   models.User
+	touchedAt time.Time
+}
+
+// UserTracker отслеживает активность пользователей: время последнего обращения,
+// язык и Telegram Premium, а также блокировку и разблокировку бота.
+// Track только запоминает активность, в БД она пишется пачкой раз в userFlushInterval,
+// для каждого пользователя не чаще раза в userTouchInterval или при изменении профиля.
+type UserTracker struct {
+	userRepo models.UserRepository
+
+	mu      sync.Mutex
+	seen    map[int64]trackedUser
+	pending map[int64]models.User
+}
+
+// NewUserTracker создает новый трекер активности пользователей
+func NewUserTracker(userRepo models.UserRepository) *UserTracker {
+	return &UserTracker{
+		userRepo: userRepo,
+		seen:     make(map[int64]trackedUser),
+		pending:  make(map[int64]models.User),
+	}
+}
+
+// Track запоминает активность автора обновления для следующей записи в БД.
+// Признак Premium передается отдельно: в tgbotapi v5.5.1 нет поля is_premium.
+func (t *UserTracker) Track(user *tgbotapi.User, premium bool) {
+	if user == nil || user.IsBot {
+		return
+	}
+
+	now := time.Now()
+	profile := models.User{
+		UserID:       user.ID,
+		Username:     user.UserName,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		LanguageCode: user.LanguageCode,
+		IsPremium:    premium,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.seen[user.ID]
+	if ok && last.profile == profile && now.Sub(last.touchedAt) < userTouchInterval {
+		return
+	}
+	t.seen[user.ID] = trackedUser{profile: profile, touchedAt: now}
+
+	profile.LastSeenAt = &now
+	t.pending[user.ID] = profile
+}
+
+// Run записывает накопленную активность раз в userFlushInterval;
+// запускается в отдельной горутине
+func (t *UserTracker) Run() {
+	ticker := time.NewTicker(userFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.flush()
+	}
+}
+
+// flush сохраняет накопленную активность в БД
+func (t *UserTracker) flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[int64]models.User)
+
+	now := time.Now()
+	for id, entry := range t.seen {
+		if _, ok := pending[id]; !ok && now.Sub(entry.touchedAt) >= userTouchInterval {
+			delete(t.seen, id)
+		}
+	}
+	t.mu.Unlock()
+
+	for id, profile := range pending {
+		// Пользователь, который еще не запускал бота (например, пишет из группы),
+		// не найдется: его профиль появится после /start
+		if _, err := t.userRepo.Touch(&profile); err != nil {
+			slog.Error("Error tracking user activity", "user_id", id, "error", err)
+
+			// Активность будет сохранена при следующем обновлении от пользователя
+			t.mu.Lock()
+			delete(t.seen, id)
+			t.mu.Unlock()
+		}
+	}
+}
+
+// HandleMyChatMember отмечает блокировку и разблокировку бота пользователем
+// по изменению статуса бота в личном чате
+func (t *UserTracker) HandleMyChatMember(ctx context.Context, update *tgbotapi.ChatMemberUpdated) {
+	if update.Chat.Type != "private" {
+		return
+	}
+
+	var blocked bool
+	switch update.NewChatMember.Status {
+	case "kicked":
+		blocked = true
+	case "member":
+		blocked = false
+	default:
+		return
+	}
+
+	if err := t.userRepo.SetBlocked(update.Chat.ID, blocked); err != nil {
+		slog.ErrorContext(ctx, "Error updating user blocked status", "error", err)
+	}
+
+	// После разблокировки профиль и активность сохраняются заново
+	t.mu.Lock()
+	delete(t.seen, update.Chat.ID)
+	t.mu.Unlock()
+}
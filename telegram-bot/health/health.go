@@ -0,0 +1,141 @@
+// Package health реализует проверки живости и готовности бота для docker.
+//
+// Это сокращенная копия api/health: бот и API — отдельные Go модули, и каждый
+// собирается в docker из своего каталога, поэтому общий пакет им не подключить
+// без смены контекста сборки. Проверки схемы БД здесь нет: схему проверяет API.
+// Формат ответа /readyz должен совпадать с api/health.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check проверяет зависимость сервиса и возвращает ошибку, если она недоступна
+type Check func(ctx context.Context) error
+
+// Result результат одной проверки
+type Result struct {
+	Status     string `json:"status"` // ok или fail
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report результат всех проверок; Status равен ok, только если прошли все проверки
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name    string
+	timeout time.Duration
+	check   Check
+}
+
+// Checker набор проверок готовности
+type Checker struct {
+	checks []namedCheck
+}
+
+// NewChecker создает пустой набор проверок
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add добавляет проверку; проверка, не уложившаяся в timeout, считается проваленной
+func (c *Checker) Add(name string, timeout time.Duration, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, timeout: timeout, check: check})
+}
+
+// Run выполняет все проверки параллельно
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: "ok", Checks: make(map[string]Result, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, check.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.check(checkCtx)
+			result := Result{Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Handler отвечает результатом проверок: 200, если все прошли, иначе 503
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+// Live отвечает 200, пока процесс способен обрабатывать HTTP запросы.
+// Зависимости не проверяются: их недоступность не лечится перезапуском.
+func Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Cached переиспользует результат проверки в течение ttl. Подходит для проверок
+// внешних сервисов, которые нельзя дергать на каждый запрос docker или балансировщика.
+func Cached(ttl time.Duration, check Check) Check {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var lastErr error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return lastErr
+		}
+		lastErr = check(ctx)
+		checkedAt = time.Now()
+		return lastErr
+	}
+}
+
+// Ping проверяет соединение с БД
+func Ping(db *sql.DB) Check {
+	return db.PingContext
+}
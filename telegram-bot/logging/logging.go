@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Ключи атрибутов с персональными данными, которые скрываются согласно настройкам
+const (
+	KeyContent  = "content"  // текст сообщений пользователей и ответов модели
+	KeyUsername = "username" // username и имя пользователя Telegram
+)
+
+// Config настройки логирования
+type Config struct {
+	Level           string // debug, info, warn, error
+	Format          string // json или text
+	RedactContent   bool
+	RedactUsernames bool
+}
+
+// Setup настраивает slog логгером по умолчанию. Стандартный пакет log после этого
+// тоже пишет через slog, поэтому сообщения библиотек попадают в тот же формат.
+func Setup(cfg Config) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactor(cfg),
+	}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, options)
+	default:
+		return fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+	return nil
+}
+
+// redactor скрывает значения атрибутов с персональными данными, оставляя длину,
+// чтобы при разборе инцидентов было видно, что поле было заполнено
+func redactor(cfg Config) func(groups []string, attr slog.Attr) slog.Attr {
+	return func(_ []string, attr slog.Attr) slog.Attr {
+		switch {
+		case attr.Key == KeyContent && cfg.RedactContent,
+			attr.Key == KeyUsername && cfg.RedactUsernames:
+			return slog.String(attr.Key, fmt.Sprintf("[redacted, %d chars]", len([]rune(attr.Value.String()))))
+		default:
+			return attr
+		}
+	}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID создает случайный идентификатор запроса
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler добавляет к записям идентификатор запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"log"
+	"log/slog"
+	"os"
 
 	"telegram-bot/bot"
 	"telegram-bot/config"
+	"telegram-bot/logging"
 )
 
 func main() {
@@ -16,10 +19,21 @@ func main() {
 		log.Fatalf("Configuration error: %v", err)
 	}
 
+	// Настраиваем логирование
+	if err := logging.Setup(logging.Config{
+		Level:           cfg.LogLevel,
+		Format:          cfg.LogFormat,
+		RedactContent:   cfg.LogRedactContent,
+		RedactUsernames: cfg.LogRedactUsernames,
+	}); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
 	// Создаем экземпляр бота
 	telegramBot, err := bot.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create bot: %v", err)
+		slog.Error("Failed to create bot", "error", err)
+		os.Exit(1)
 	}
 	defer telegramBot.Close()
 
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// Метрики бота
+var (
+	// Updates полученные обновления Telegram по типам
+	Updates = Default.NewCounterVec(
+		"bot_updates_total",
+		"Telegram updates received by type.",
+		"type",
+	)
+
+	// HandlerDuration длительность обработки обновлений по обработчикам
+	HandlerDuration = Default.NewHistogramVec(
+		"bot_handler_duration_seconds",
+		"Update handling latency by handler.",
+		DefaultBuckets,
+		"handler",
+	)
+
+	// HandlerErrors обновления, которые не удалось обработать, по обработчикам
+	HandlerErrors = Default.NewCounterVec(
+		"bot_handler_errors_total",
+		"Updates that failed to be handled by handler.",
+		"handler",
+	)
+
+	// APIRequestDuration длительность запросов бота к API сервису
+	APIRequestDuration = Default.NewHistogramVec(
+		"bot_api_request_duration_seconds",
+		"Latency of bot requests to the API service by endpoint and status.",
+		DefaultBuckets,
+		"method", "endpoint", "status",
+	)
+
+	// BroadcastMessages сообщения рассылок по результату доставки
+	BroadcastMessages = Default.NewCounterVec(
+		"bot_broadcast_messages_total",
+		"Broadcast messages by delivery status.",
+		"status",
+	)
+)
+
+// RegisterDBStats добавляет метрики пула соединений с БД
+func RegisterDBStats(registry *Registry, db *sql.DB) {
+	registry.NewGaugeFunc("db_open_connections", "Established database connections.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	registry.NewGaugeFunc("db_in_use_connections", "Database connections currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	registry.NewGaugeFunc("db_idle_connections", "Idle database connections.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	registry.NewGaugeFunc("db_max_open_connections", "Maximum number of open database connections.", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	registry.NewCounterFunc("db_wait_count_total", "Connections waited for because the pool was exhausted.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	registry.NewCounterFunc("db_wait_duration_seconds_total", "Total time spent waiting for a database connection.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
@@ -0,0 +1,254 @@
+// Package metrics отдает метрики бота в текстовом формате Prometheus.
+//
+// Это сокращенная копия api/metrics: бот и API — отдельные Go модули, и каждый
+// собирается в docker из своего каталога, поэтому общий пакет им не подключить
+// без смены контекста сборки. Здесь оставлено только то, что использует бот;
+// формат вывода должен совпадать с api/metrics, правки вносятся в оба пакета.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets границы гистограмм длительности в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// collector метрика, которую можно вывести в текстовом формате Prometheus
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry набор метрик сервиса
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// Default набор метрик, который отдает обработчик /metrics
+var Default = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		r.mu.Lock()
+		collectors := append([]collector(nil), r.collectors...)
+		r.mu.Unlock()
+
+		out := bufio.NewWriter(w)
+		for _, c := range collectors {
+			c.write(out)
+		}
+		out.Flush()
+	})
+}
+
+// series значения метрики с одним набором меток
+type series struct {
+	labels []string
+	value  float64
+	// Для гистограмм: число наблюдений в каждом бакете (не накопительно)
+	buckets []uint64
+	count   uint64
+}
+
+// vec метрика с метками; значения хранятся по ключу из значений меток
+type vec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// get возвращает значения для набора меток; вызывается под mu
+func (v *vec) get(values []string, buckets int) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...), buckets: make([]uint64, buckets)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted возвращает копии значений в стабильном порядке; вызывается под mu
+func (v *vec) sorted() []series {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]series, 0, len(keys))
+	for _, key := range keys {
+		s := *v.series[key]
+		s.buckets = append([]uint64(nil), s.buckets...)
+		result = append(result, s)
+	}
+	return result
+}
+
+func (v *vec) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, kind)
+}
+
+// CounterVec счетчик с метками
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec регистрирует счетчик
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labels)}
+	r.register(c)
+	return c
+}
+
+// Inc увеличивает счетчик на единицу
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues, 0).value++
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	all := c.sorted()
+	c.mu.Unlock()
+
+	c.header(w, "counter")
+	for _, s := range all {
+		writeSample(w, c.name, c.labels, s.labels, "", "", s.value)
+	}
+}
+
+// HistogramVec гистограмма с метками
+type HistogramVec struct {
+	vec
+	bounds []float64
+}
+
+// NewHistogramVec регистрирует гистограмму с границами бакетов bounds (по возрастанию)
+func (r *Registry) NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{vec: newVec(name, help, labels), bounds: bounds}
+	r.register(h)
+	return h
+}
+
+// Observe добавляет наблюдение
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	idx := sort.SearchFloat64s(h.bounds, value)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues, len(h.bounds))
+	if idx < len(h.bounds) {
+		s.buckets[idx]++
+	}
+	s.count++
+	s.value += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	all := h.sorted()
+	h.mu.Unlock()
+
+	h.header(w, "histogram")
+	for _, s := range all {
+		var cumulative uint64
+		for i, bound := range h.bounds {
+			cumulative += s.buckets[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labels, "", "", s.value)
+		writeSample(w, h.name+"_count", h.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+// funcMetric метрика без меток, значение которой вычисляется при каждом запросе /metrics
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	fn   func() float64
+}
+
+// NewGaugeFunc регистрирует показатель, значение которого возвращает fn
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc регистрирует счетчик, значение которого возвращает fn
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+	writeSample(w, m.name, nil, nil, "", "", m.fn())
+}
+
+// writeSample выводит одну строку метрики; extraName/extraValue — дополнительная
+// метка (le для бакетов гистограммы)
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, labelEscaper.Replace(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// labelEscaper экранирует значения меток по правилам текстового формата Prometheus
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
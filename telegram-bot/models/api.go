@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+)
+
+// ChatRequest запрос к API на отправку сообщения ИИ
+type ChatRequest struct {
+	Message    string `json:"message"`
+	DocumentID int64  `json:"document_id,omitempty"`
+	// ChatID, ThreadID и AuthorName заполняются для сообщений из групп
+	ChatID     int64  `json:"chat_id,omitempty"`
+	ThreadID   int64  `json:"thread_id,omitempty"`
+	AuthorName string `json:"author_name,omitempty"`
+}
+
+// ChatResponse ответ ИИ от API
+type ChatResponse struct {
+	MessageID  int64     `json:"message_id"`
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+	Transcript string    `json:"transcript,omitempty"`
+	// MemoryProposals факты, которые ассистент предложил запомнить
+	MemoryProposals []MemoryProposal `json:"memory_proposals,omitempty"`
+}
+
+// FeedbackRequest оценка ответа ассистента
+type FeedbackRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Document загруженный в API документ
+type Document struct {
+	ID         int64     `json:"id"`
+	Filename   string    `json:"filename"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ChunkCount int       `json:"chunk_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Memory факт о пользователе, который помнит ассистент
+type Memory struct {
+	ID        int64     `json:"id"`
+	Content   string    `json:"content"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MemoryProposal факт, который ассистент предложил запомнить; сохраняется после подтверждения
+type MemoryProposal struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+}
+
+// Settings настройки пользователя в API
+type Settings struct {
+	Model   string `json:"model"`
+	Persona string `json:"persona"`
+}
+
+// SettingsUpdate изменение настроек; nil поля не меняются
+type SettingsUpdate struct {
+	Model   *string `json:"model,omitempty"`
+	Persona *string `json:"persona,omitempty"`
+}
+
+// Persona стиль общения ассистента
+type Persona struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SettingsResponse настройки пользователя и доступные для выбора варианты
+type SettingsResponse struct {
+	Settings Settings  `json:"settings"`
+	Models   []string  `json:"models"`
+	Personas []Persona `json:"personas"`
+}
+
+// Stats использование дневного лимита сообщений
+type Stats struct {
+	DailyMessages int `json:"daily_messages"`
+	DailyLimit    int `json:"daily_limit"`
+	Remaining     int `json:"remaining"`
+}
+
+// GroupSettings настройки бота в группе
+type GroupSettings struct {
+	ChatID  int64  `json:"chat_id"`
+	Enabled bool   `json:"enabled"`
+	Model   string `json:"model"`
+	Persona string `json:"persona"`
+}
+
+// GroupSettingsUpdate изменение настроек группы; nil поля не меняются
+type GroupSettingsUpdate struct {
+	Enabled *bool   `json:"enabled,omitempty"`
+	Model   *string `json:"model,omitempty"`
+	Persona *string `json:"persona,omitempty"`
+}
+
+// GroupSettingsResponse настройки группы и доступные для выбора варианты
+type GroupSettingsResponse struct {
+	Settings GroupSettings `json:"settings"`
+	Models   []string      `json:"models"`
+	Personas []Persona     `json:"personas"`
+}
+
+// APIKey персональный API ключ пользователя (без самого ключа)
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreated созданный API ключ; Key показывается пользователю один раз
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Ban действующая блокировка пользователя администратором
+type Ban struct {
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Reason    string     `json:"reason" db:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"` // nil — бессрочно
+}
+
+// BanRepository интерфейс для проверки блокировок в БД
+type BanRepository interface {
+	// ListActive возвращает действующие блокировки всех пользователей
+	ListActive() ([]Ban, error)
+}
@@ -0,0 +1,25 @@
+package models
+
+// Статусы доставки рассылки
+const (
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliveryBlocked = "blocked" // пользователь заблокировал бота
+)
+
+// BroadcastDelivery сообщение рассылки, ожидающее отправки пользователю
+type BroadcastDelivery struct {
+	BroadcastID int64
+	UserID      int64
+	Text        string // HTML разметка Telegram
+	ButtonText  string
+	ButtonURL   string
+}
+
+// BroadcastRepository интерфейс очереди рассылок. Рассылки создаются через
+// админский API, бот только отправляет их.
+type BroadcastRepository interface {
+	NextBatch(limit int) ([]*BroadcastDelivery, error)
+	MarkDelivery(delivery *BroadcastDelivery, status, errorText string) error
+	CompleteFinished() error
+}
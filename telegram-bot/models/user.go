@@ -6,15 +6,21 @@ import (
 
 // User представляет пользователя Telegram бота
 type User struct {
-	ID        int64     `json:"id" db:"id"`
-	UserID    int64     `json:"user_id" db:"user_id"`
-	Username  string    `json:"username" db:"username"`
-	FirstName string    `json:"first_name" db:"first_name"`
-	LastName  string    `json:"last_name" db:"last_name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID           int64      `json:"id" db:"id"`
+	UserID       int64      `json:"user_id" db:"user_id"`
+	Username     string     `json:"username" db:"username"`
+	FirstName    string     `json:"first_name" db:"first_name"`
+	LastName     string     `json:"last_name" db:"last_name"`
+	LanguageCode string     `json:"language_code" db:"language_code"`
+	IsPremium    bool       `json:"is_premium" db:"is_premium"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`
 }
 
 // UserRepository интерфейс для работы с пользователями в БД
 type UserRepository interface {
 	Save(user *User) error
+	Touch(user *User) (bool, error)
+	FindByUsername(username string) (*User, error)
+	SetBlocked(userID int64, blocked bool) error
 }
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-bot/logging"
+	"telegram-bot/metrics"
+	"telegram-bot/models"
+)
+
+// APIError ошибка, возвращенная API сервисом
+type APIError struct {
+	StatusCode int
+	Code       string // машинный код ошибки, если API его вернул (banned, rate_limited, model_unavailable)
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error: %d - %s", e.StatusCode, e.Message)
+}
+
+// apiRequestTimeout общий срок запроса к API. Он больше суммы сроков этапов API
+// (CHAT_*_TIMEOUT) для голосового сообщения: БД, распознавание, контекст, модель
+// и сохранение (5+60+10+45+2×5 = 130 с по умолчанию), чтобы API успел ответить
+// своей ошибкой, а не был оборван клиентом.
+const apiRequestTimeout = 150 * time.Second
+
+// APIClient клиент API сервиса, выполняющий запросы от имени пользователей бота
+type APIClient struct {
+	baseURL      string
+	serviceToken string
+	client       *http.Client
+}
+
+// NewAPIClient создает новый клиент API
+func NewAPIClient(baseURL, serviceToken string) *APIClient {
+	return &APIClient{
+		baseURL:      baseURL,
+		serviceToken: serviceToken,
+		client: &http.Client{
+			Timeout: apiRequestTimeout,
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   5 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				MaxIdleConns:    10,
+				IdleConnTimeout: 90 * time.Second,
+			},
+		},
+	}
+}
+
+// SendMessage отправляет сообщение пользователя в чат с ИИ
+func (c *APIClient) SendMessage(ctx context.Context, userID int64, request models.ChatRequest) (*models.ChatResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var response models.ChatResponse
+	if err := c.do(ctx, userID, http.MethodPost, "/api/chat", bytes.NewReader(body), "application/json", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Inline отправляет отдельный вопрос из inline режима: ответ не сохраняется в историю диалога
+func (c *APIClient) Inline(ctx context.Context, userID int64, message string) (*models.ChatResponse, error) {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var response models.ChatResponse
+	if err := c.do(ctx, userID, http.MethodPost, "/api/chat/inline", bytes.NewReader(body), "application/json", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Regenerate заново генерирует ответ ассистента с указанным идентификатором
+func (c *APIClient) Regenerate(ctx context.Context, userID, messageID int64) (*models.ChatResponse, error) {
+	body, err := json.Marshal(map[string]int64{"message_id": messageID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var response models.ChatResponse
+	if err := c.do(ctx, userID, http.MethodPost, "/api/chat/regenerate", bytes.NewReader(body), "application/json", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Continue просит модель продолжить ответ ассистента с указанным идентификатором
+func (c *APIClient) Continue(ctx context.Context, userID, messageID int64) (*models.ChatResponse, error) {
+	body, err := json.Marshal(map[string]int64{"message_id": messageID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var response models.ChatResponse
+	if err := c.do(ctx, userID, http.MethodPost, "/api/chat/continue", bytes.NewReader(body), "application/json", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SendFeedback сохраняет оценку ответа ассистента (1 или -1) и необязательный комментарий
+func (c *APIClient) SendFeedback(ctx context.Context, userID, messageID int64, rating int, comment string) error {
+	body, err := json.Marshal(models.FeedbackRequest{Rating: rating, Comment: comment})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	path := "/api/messages/" + strconv.FormatInt(messageID, 10) + "/feedback"
+	return c.do(ctx, userID, http.MethodPost, path, bytes.NewReader(body), "application/json", nil)
+}
+
+// SendVoice отправляет голосовое сообщение на распознавание и получает ответ ИИ
+func (c *APIClient) SendVoice(ctx context.Context, userID int64, filename string, content io.Reader, documentID int64) (*models.ChatResponse, error) {
+	fields := map[string]string{}
+	if documentID != 0 {
+		fields["document_id"] = strconv.FormatInt(documentID, 10)
+	}
+
+	body, contentType, err := multipartBody(filename, content, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.ChatResponse
+	if err := c.do(ctx, userID, http.MethodPost, "/api/chat/voice", body, contentType, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UploadDocument загружает документ пользователя
+func (c *APIClient) UploadDocument(ctx context.Context, userID int64, filename string, content io.Reader) (*models.Document, error) {
+	body, contentType, err := multipartBody(filename, content, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var document models.Document
+	if err := c.do(ctx, userID, http.MethodPost, "/api/documents", body, contentType, &document); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// multipartBody формирует форму с файлом в поле "file" и дополнительными полями
+func multipartBody(filename string, content io.Reader, fields map[string]string) (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, "", fmt.Errorf("failed to write form field: %w", err)
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return nil, "", fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finish form: %w", err)
+	}
+
+	return &body, writer.FormDataContentType(), nil
+}
+
+// ListMemories возвращает факты, которые ассистент помнит о пользователе
+func (c *APIClient) ListMemories(ctx context.Context, userID int64) ([]models.Memory, error) {
+	var response struct {
+		Memories []models.Memory `json:"memories"`
+	}
+	if err := c.do(ctx, userID, http.MethodGet, "/api/memory", nil, "", &response); err != nil {
+		return nil, err
+	}
+	return response.Memories, nil
+}
+
+// AddMemory сохраняет факт о пользователе
+func (c *APIClient) AddMemory(ctx context.Context, userID int64, content string) (*models.Memory, error) {
+	body, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var memory models.Memory
+	if err := c.do(ctx, userID, http.MethodPost, "/api/memory", bytes.NewReader(body), "application/json", &memory); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// DeleteMemory удаляет факт о пользователе
+func (c *APIClient) DeleteMemory(ctx context.Context, userID, memoryID int64) error {
+	return c.do(ctx, userID, http.MethodDelete, "/api/memory/"+strconv.FormatInt(memoryID, 10), nil, "", nil)
+}
+
+// ClearMemories удаляет все факты о пользователе
+func (c *APIClient) ClearMemories(ctx context.Context, userID int64) error {
+	return c.do(ctx, userID, http.MethodDelete, "/api/memory", nil, "", nil)
+}
+
+// ConfirmMemory сохраняет предложенный ассистентом факт в память
+func (c *APIClient) ConfirmMemory(ctx context.Context, userID, proposalID int64) (*models.Memory, error) {
+	path := "/api/memory/proposals/" + strconv.FormatInt(proposalID, 10) + "/confirm"
+	var memory models.Memory
+	if err := c.do(ctx, userID, http.MethodPost, path, nil, "", &memory); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// RejectMemory отклоняет предложенный ассистентом факт
+func (c *APIClient) RejectMemory(ctx context.Context, userID, proposalID int64) error {
+	return c.do(ctx, userID, http.MethodDelete, "/api/memory/proposals/"+strconv.FormatInt(proposalID, 10), nil, "", nil)
+}
+
+// ListAPIKeys возвращает действующие API ключи пользователя
+func (c *APIClient) ListAPIKeys(ctx context.Context, userID int64) ([]models.APIKey, error) {
+	var response struct {
+		Keys []models.APIKey `json:"keys"`
+	}
+	if err := c.do(ctx, userID, http.MethodGet, "/api/keys", nil, "", &response); err != nil {
+		return nil, err
+	}
+	return response.Keys, nil
+}
+
+// CreateAPIKey создает API ключ пользователя; пустой scopes — области по умолчанию
+func (c *APIClient) CreateAPIKey(ctx context.Context, userID int64, name string, scopes []string) (*models.APIKeyCreated, error) {
+	body, err := json.Marshal(map[string]interface{}{"name": name, "scopes": scopes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var key models.APIKeyCreated
+	if err := c.do(ctx, userID, http.MethodPost, "/api/keys", bytes.NewReader(body), "application/json", &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey отзывает API ключ пользователя
+func (c *APIClient) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	return c.do(ctx, userID, http.MethodDelete, "/api/keys/"+strconv.FormatInt(keyID, 10), nil, "", nil)
+}
+
+// GetSettings возвращает настройки пользователя, доступные модели и персоны
+func (c *APIClient) GetSettings(ctx context.Context, userID int64) (*models.SettingsResponse, error) {
+	var response models.SettingsResponse
+	if err := c.do(ctx, userID, http.MethodGet, "/api/settings", nil, "", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateSettings меняет модель и/или персону пользователя
+func (c *APIClient) UpdateSettings(ctx context.Context, userID int64, update models.SettingsUpdate) (*models.Settings, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var settings models.Settings
+	if err := c.do(ctx, userID, http.MethodPatch, "/api/settings", bytes.NewReader(body), "application/json", &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ResetContext начинает новый диалог с ИИ
+func (c *APIClient) ResetContext(ctx context.Context, userID int64) error {
+	return c.do(ctx, userID, http.MethodPost, "/api/history/reset", nil, "", nil)
+}
+
+// GetStats возвращает использование дневного лимита сообщений
+func (c *APIClient) GetStats(ctx context.Context, userID int64) (*models.Stats, error) {
+	var stats models.Stats
+	if err := c.do(ctx, userID, http.MethodGet, "/api/stats", nil, "", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetGroupSettings возвращает настройки бота в группе, доступные модели и персоны
+func (c *APIClient) GetGroupSettings(ctx context.Context, userID, chatID int64) (*models.GroupSettingsResponse, error) {
+	var response models.GroupSettingsResponse
+	if err := c.do(ctx, userID, http.MethodGet, groupPath(chatID, "settings"), nil, "", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateGroupSettings меняет настройки бота в группе; права администратора проверяет вызывающий
+func (c *APIClient) UpdateGroupSettings(ctx context.Context, userID, chatID int64, update models.GroupSettingsUpdate) (*models.GroupSettings, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var settings models.GroupSettings
+	if err := c.do(ctx, userID, http.MethodPatch, groupPath(chatID, "settings"), bytes.NewReader(body), "application/json", &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ResetGroupContext начинает новый диалог с ИИ в группе
+func (c *APIClient) ResetGroupContext(ctx context.Context, userID, chatID int64) error {
+	return c.do(ctx, userID, http.MethodPost, groupPath(chatID, "reset"), nil, "", nil)
+}
+
+// groupPath возвращает путь к ресурсу группы
+func groupPath(chatID int64, resource string) string {
+	return "/api/groups/" + strconv.FormatInt(chatID, 10) + "/" + resource
+}
+
+// BanUser блокирует пользователя от имени администратора adminID; hours = 0 — бессрочно
+func (c *APIClient) BanUser(ctx context.Context, adminID, userID int64, reason string, hours int) (*models.Ban, error) {
+	body, err := json.Marshal(map[string]interface{}{"reason": reason, "duration_hours": hours})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var ban models.Ban
+	if err := c.do(ctx, adminID, http.MethodPost, adminUserPath(userID, "ban"), bytes.NewReader(body), "application/json", &ban); err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// UnbanUser снимает блокировку пользователя от имени администратора adminID
+func (c *APIClient) UnbanUser(ctx context.Context, adminID, userID int64) error {
+	return c.do(ctx, adminID, http.MethodDelete, adminUserPath(userID, "ban"), nil, "", nil)
+}
+
+// adminUserPath возвращает путь к ресурсу пользователя в админском API
+func adminUserPath(userID int64, resource string) string {
+	return "/admin/users/" + strconv.FormatInt(userID, 10) + "/" + resource
+}
+
+// do выполняет запрос к API с сервисной аутентификацией и передает идентификатор
+// запроса из контекста, чтобы обработку одного обновления можно было найти в логах обоих сервисов
+func (c *APIClient) do(ctx context.Context, userID int64, method, path string, body io.Reader, contentType string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+	req.Header.Set("X-Telegram-User-ID", strconv.FormatInt(userID, 10))
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		metrics.APIRequestDuration.Observe(time.Since(start).Seconds(), method, metricsEndpoint(path), "error")
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	metrics.APIRequestDuration.Observe(time.Since(start).Seconds(), method, metricsEndpoint(path), strconv.Itoa(resp.StatusCode))
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Error == "" {
+			apiErr.Error = string(data)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Code: apiErr.Code, Message: apiErr.Error}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// Ping проверяет, что API сервис отвечает
+func (c *APIClient) Ping(ctx context.Context) error {
+	return c.do(ctx, 0, http.MethodGet, "/livez", nil, "", nil)
+}
+
+// metricsEndpoint заменяет идентификаторы в пути запроса на :id, чтобы метрики
+// группировались по эндпоинтам, а не по отдельным сообщениям и пользователям
+func metricsEndpoint(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.ParseInt(segment, 10, 64); err == nil {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"telegram-bot/metrics"
+	"telegram-bot/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// broadcastInterval пауза между сообщениями рассылки: Telegram разрешает
+	// ботам не более 30 сообщений в секунду, оставляем запас для ответов пользователям
+	broadcastInterval = time.Second / 25
+	// broadcastBatchSize сколько сообщений брать из очереди за раз; небольшой размер
+	// позволяет быстро остановиться после паузы рассылки
+	broadcastBatchSize = 25
+	// broadcastIdleDelay пауза между проверками очереди, когда отправлять нечего
+	broadcastIdleDelay = 5 * time.Second
+)
+
+// Broadcaster отправляет сообщения рассылок из очереди в БД с ограничением скорости.
+// Пользователи, заблокировавшие бота, отмечаются и исключаются из следующих рассылок.
+type Broadcaster struct {
+	bot           *tgbotapi.BotAPI
+	broadcastRepo models.BroadcastRepository
+	userRepo      models.UserRepository
+}
+
+// NewBroadcaster создает новый воркер рассылок
+func NewBroadcaster(bot *tgbotapi.BotAPI, broadcastRepo models.BroadcastRepository, userRepo models.UserRepository) *Broadcaster {
+	return &Broadcaster{
+		bot:           bot,
+		broadcastRepo: broadcastRepo,
+		userRepo:      userRepo,
+	}
+}
+
+// Run обрабатывает очередь рассылок; запускается в отдельной горутине
+func (w *Broadcaster) Run() {
+	limiter := time.NewTicker(broadcastInterval)
+	defer limiter.Stop()
+
+	for {
+		deliveries, err := w.broadcastRepo.NextBatch(broadcastBatchSize)
+		if err != nil {
+			slog.Error("Error getting broadcast queue", "error", err)
+			time.Sleep(broadcastIdleDelay)
+			continue
+		}
+
+		if len(deliveries) == 0 {
+			if err := w.broadcastRepo.CompleteFinished(); err != nil {
+				slog.Error("Error completing broadcasts", "error", err)
+			}
+			time.Sleep(broadcastIdleDelay)
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			<-limiter.C
+			w.deliver(delivery)
+		}
+	}
+}
+
+// deliver отправляет одно сообщение рассылки и сохраняет результат.
+// При превышении лимита Telegram ждет указанное время и повторяет отправку.
+func (w *Broadcaster) deliver(delivery *models.BroadcastDelivery) {
+	msg := tgbotapi.NewMessage(delivery.UserID, delivery.Text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if delivery.ButtonText != "" && delivery.ButtonURL != "" {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(delivery.ButtonText, delivery.ButtonURL),
+		))
+	}
+
+	for {
+		_, err := w.bot.Send(msg)
+
+		var tgErr *tgbotapi.Error
+		switch {
+		case err == nil:
+			w.mark(delivery, models.DeliverySent, "")
+		case errors.As(err, &tgErr) && tgErr.Code == http.StatusTooManyRequests:
+			time.Sleep(time.Duration(max(tgErr.RetryAfter, 1)) * time.Second)
+			continue
+		case errors.As(err, &tgErr) && tgErr.Code == http.StatusForbidden:
+			// Пользователь заблокировал бота или удалил аккаунт
+			w.mark(delivery, models.DeliveryBlocked, tgErr.Message)
+			if err := w.userRepo.SetBlocked(delivery.UserID, true); err != nil {
+				slog.Error("Error marking user as blocked", "error", err)
+			}
+		default:
+			slog.Warn("Error sending broadcast", "broadcast_id", delivery.BroadcastID, "user_id", delivery.UserID, "error", err)
+			w.mark(delivery, models.DeliveryFailed, err.Error())
+		}
+		return
+	}
+}
+
+func (w *Broadcaster) mark(delivery *models.BroadcastDelivery, status, errorText string) {
+	metrics.BroadcastMessages.Inc(status)
+	if err := w.broadcastRepo.MarkDelivery(delivery, status, errorText); err != nil {
+		slog.Error("Error saving broadcast delivery", "error", err)
+	}
+}